@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/audit"
+	"github.com/mikesmitty/file-search/internal/gemini"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Inspect the audit log of changes to stores, files and documents",
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+
+	var since, actor string
+	auditShowCmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show audit log entries",
+		Long: `Show the creates, deletes, uploads and imports made by the CLI and the MCP
+server, oldest first.
+
+Examples:
+  # Everything from the last day
+  file-search audit show --since 24h
+
+  # Changes made through the MCP server since a point in time, as JSON lines
+  file-search audit show --since 2026-01-02T15:04:05Z --actor claude-code --format jsonl`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := auditLogPath()
+			if path == "" {
+				return fmt.Errorf("the audit log is disabled (audit_log: off)")
+			}
+			start, err := parseSince(since, time.Now())
+			if err != nil {
+				return err
+			}
+			entries, err := audit.Read(path, start)
+			if err != nil {
+				return err
+			}
+			filtered := []audit.Entry{}
+			for _, e := range entries {
+				if actor == "" || e.Actor == actor {
+					filtered = append(filtered, e)
+				}
+			}
+			return printOutput(filtered, outputFormat)
+		},
+	}
+	auditShowCmd.Flags().StringVar(&since, "since", "", "Only show entries newer than a duration (e.g. 24h) or an RFC 3339 time")
+	auditShowCmd.Flags().StringVar(&actor, "actor", "", "Only show entries by this actor")
+	auditCmd.AddCommand(auditShowCmd)
+}
+
+// parseSince parses a --since value: a duration before now, or an RFC 3339
+// time. An empty value means the beginning of time.
+func parseSince(since string, now time.Time) (time.Time, error) {
+	if since == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(since); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, since); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since: %s (use a duration such as 24h or an RFC 3339 time)", since)
+}
+
+// dataDir is where the CLI keeps its logs and other state.
+func dataDir() string {
+	if dir := viper.GetString("data_dir"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".file-search"
+	}
+	return filepath.Join(home, ".file-search")
+}
+
+// dataFilePath returns the path the config key sets, the file name in the
+// data directory if it is unset, or "" if it is off, false or none.
+func dataFilePath(key, name string) string {
+	switch path := viper.GetString(key); strings.ToLower(path) {
+	case "":
+		return filepath.Join(dataDir(), name)
+	case "off", "false", "none":
+		return ""
+	default:
+		return path
+	}
+}
+
+// auditLogPath returns the audit log's path, or "" if auditing is off.
+func auditLogPath() string {
+	return dataFilePath("audit_log", "audit.jsonl")
+}
+
+// auditMiddleware records mutating calls to the audit log. Calls are
+// attributed to the OS user unless the context names another actor, as the
+// MCP server's tool calls do.
+func auditMiddleware() gemini.Middleware {
+	path := auditLogPath()
+	if path == "" {
+		return nil
+	}
+	return audit.New(path).Middleware(&audit.Options{
+		DefaultActor: audit.Actor{Name: currentUser(), Source: "cli"},
+		OnError: func(err error) {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		},
+	})
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mikesmitty/file-search/internal/gemini"
+	"github.com/mikesmitty/file-search/internal/querycache"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the local query response cache",
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+
+	var storeName string
+	cachePurgeCmd := &cobra.Command{
+		Use:   "purge",
+		Short: "Remove cached query responses",
+		Long: `Remove cached query responses: every one, or only those that searched a
+store. Entries for a store are also removed whenever a file is uploaded to,
+imported into or deleted from it through this tool.
+
+Examples:
+  file-search cache purge
+  file-search cache purge --store "My Knowledge Base"`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			storeID := storeName
+			if storeName != "" {
+				ctx := context.Background()
+				client, err := getClient(ctx)
+				if err != nil {
+					return err
+				}
+				defer client.Close()
+				if storeID, err = client.ResolveStoreName(ctx, storeName); err != nil {
+					return err
+				}
+			}
+			removed, err := querycache.New(queryCacheDir(), 0).Purge(storeID)
+			if err != nil {
+				return err
+			}
+			if outputFormat != "text" {
+				return printOutput(map[string]any{"status": "purged", "removed": removed}, outputFormat)
+			}
+			fmt.Printf("Removed %d cached responses\n", removed)
+			return nil
+		},
+	}
+	cachePurgeCmd.Flags().StringVar(&storeName, "store", "", "Only remove responses that searched this store (display name or resource name)")
+	cachePurgeCmd.RegisterFlagCompletionFunc("store", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return getCompleter().GetStoreNames(), cobra.ShellCompDirectiveNoFileComp
+	})
+	cacheCmd.AddCommand(cachePurgeCmd)
+}
+
+// queryCacheDir is where cached query responses are kept.
+func queryCacheDir() string {
+	return filepath.Join(dataDir(), "cache", "queries")
+}
+
+// queryCacheMiddleware answers repeat queries from the cache, or returns nil
+// if caching is off (the default, or a query_cache_ttl of 0).
+func queryCacheMiddleware() gemini.Middleware {
+	if !viper.GetBool("query_cache") {
+		return nil
+	}
+	ttl := viper.GetDuration("query_cache_ttl")
+	if ttl <= 0 {
+		return nil
+	}
+	return querycache.New(queryCacheDir(), ttl).Middleware(&querycache.Options{
+		OnError: func(err error) {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		},
+	})
+}
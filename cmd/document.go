@@ -162,8 +162,8 @@ func init() {
 			if err != nil {
 				return err
 			}
-			if outputFormat == "json" {
-				return printOutput(map[string]string{"status": "deleted", "document": docID}, "json")
+			if outputFormat != "text" {
+				return printOutput(map[string]string{"status": "deleted", "document": docID}, outputFormat)
 			}
 			fmt.Printf("Deleted document: %s\n", args[0])
 			return nil
@@ -0,0 +1,384 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/gemini/fake"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// The E2E suite runs the CLI in-process against the fake File Search server.
+// Each testdata/e2e/*.txtar file is a scenario: the comment section is a script
+// and the remaining sections are files extracted into $WORK before it runs.
+//
+// Script commands (one per line, # starts a comment, ! negates):
+//
+//	fs args...          run file-search with args; must exit 0 (or non-zero with !)
+//	stdin file          use $WORK/file as stdin for the next fs command
+//	stdout regexp       last stdout must match (or not match with !)
+//	stderr regexp       last stderr must match (or not match with !)
+//	capture VAR regexp  set $VAR to the first submatch of regexp in last stdout
+//	lastop VAR          set $VAR to the most recently started operation name
+//	env KEY=VALUE       set an environment variable for the rest of the script
+//	wait                wait for pending fake operations to finish
+//
+// Arguments are split on spaces, may be single- or double-quoted, and expand
+// $VAR and ${VAR}. $WORK is the scenario's working directory.
+
+func TestE2E(t *testing.T) {
+	scripts, err := filepath.Glob(filepath.Join("testdata", "e2e", "*.txtar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scripts) == 0 {
+		t.Fatal("no E2E scripts found")
+	}
+	for _, script := range scripts {
+		name := strings.TrimSuffix(filepath.Base(script), ".txtar")
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(script)
+			if err != nil {
+				t.Fatal(err)
+			}
+			newE2EScript(t, parseTxtar(data)).run()
+		})
+	}
+}
+
+// txtarArchive is a parsed txtar file.
+type txtarArchive struct {
+	comment string
+	files   map[string]string
+}
+
+var txtarMarker = regexp.MustCompile(`^-- (.+) --$`)
+
+// parseTxtar parses the txtar format: a leading comment followed by
+// "-- name --" marker lines, each starting a file.
+func parseTxtar(data []byte) *txtarArchive {
+	a := &txtarArchive{files: make(map[string]string)}
+	var name string
+	var buf strings.Builder
+	flush := func() {
+		if name == "" {
+			a.comment = buf.String()
+		} else {
+			a.files[name] = buf.String()
+		}
+		buf.Reset()
+	}
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		if m := txtarMarker.FindStringSubmatch(strings.TrimRight(line, "\r\n")); m != nil {
+			flush()
+			name = m[1]
+			continue
+		}
+		buf.WriteString(line)
+	}
+	flush()
+	return a
+}
+
+type e2eScript struct {
+	t       *testing.T
+	archive *txtarArchive
+	srv     *fake.Server
+	work    string
+	vars    map[string]string
+
+	stdin  string
+	stdout string
+	stderr string
+}
+
+func newE2EScript(t *testing.T, archive *txtarArchive) *e2eScript {
+	srv := fake.NewServer(&fake.Options{PageSize: 2, IndexingDelay: 20 * time.Millisecond})
+	t.Cleanup(srv.Close)
+
+	work := t.TempDir()
+	for name, content := range archive.files {
+		path := filepath.Join(work, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Isolate from the developer's config and credentials
+	t.Setenv("HOME", work)
+	for _, key := range []string{"GOOGLE_API_KEY", "MCP_TOOLS", "FILE_SEARCH_PROFILE", "FILE_SEARCH_BACKEND"} {
+		t.Setenv(key, "")
+	}
+	t.Setenv("GEMINI_API_KEY", "e2e-test-key")
+	t.Setenv("FILE_SEARCH_BASE_URL", srv.URL)
+	t.Setenv("FILE_SEARCH_POLL_INTERVAL", "10ms")
+	t.Setenv("COMPLETION_ENABLED", "false")
+
+	return &e2eScript{
+		t:       t,
+		archive: archive,
+		srv:     srv,
+		work:    work,
+		vars:    map[string]string{"WORK": work},
+	}
+}
+
+func (s *e2eScript) run() {
+	for i, line := range strings.Split(s.archive.comment, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		s.t.Logf("> %s", line)
+
+		negate := false
+		if strings.HasPrefix(line, "! ") {
+			negate = true
+			line = strings.TrimSpace(line[2:])
+		}
+		args, err := s.splitArgs(line)
+		if err != nil {
+			s.t.Fatalf("line %d: %v", i+1, err)
+		}
+		if err := s.exec(args[0], args[1:], negate); err != nil {
+			s.t.Fatalf("line %d: %s: %v\nstdout:\n%s\nstderr:\n%s", i+1, line, err, s.stdout, s.stderr)
+		}
+	}
+}
+
+func (s *e2eScript) exec(command string, args []string, negate bool) error {
+	switch command {
+	case "fs":
+		code := s.runCLI(args)
+		if negate && code == 0 {
+			return fmt.Errorf("unexpected success")
+		}
+		if !negate && code != 0 {
+			return fmt.Errorf("exit code %d", code)
+		}
+	case "stdin":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: stdin file")
+		}
+		content, ok := s.archive.files[args[0]]
+		if !ok {
+			return fmt.Errorf("no file %s in archive", args[0])
+		}
+		s.stdin = s.expand(content)
+	case "stdout", "stderr":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: %s regexp", command)
+		}
+		re, err := regexp.Compile(`(?m)` + args[0])
+		if err != nil {
+			return err
+		}
+		output := s.stdout
+		if command == "stderr" {
+			output = s.stderr
+		}
+		if matched := re.MatchString(output); matched == negate {
+			if negate {
+				return fmt.Errorf("%s unexpectedly matched %q", command, args[0])
+			}
+			return fmt.Errorf("%s did not match %q", command, args[0])
+		}
+	case "capture":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: capture VAR regexp")
+		}
+		re, err := regexp.Compile(`(?m)` + args[1])
+		if err != nil {
+			return err
+		}
+		m := re.FindStringSubmatch(s.stdout)
+		if len(m) < 2 {
+			return fmt.Errorf("stdout did not match %q", args[1])
+		}
+		s.vars[args[0]] = m[1]
+	case "lastop":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: lastop VAR")
+		}
+		ops := s.srv.Operations()
+		if len(ops) == 0 {
+			return fmt.Errorf("no operations started")
+		}
+		s.vars[args[0]] = ops[len(ops)-1]
+	case "env":
+		if len(args) != 1 || !strings.Contains(args[0], "=") {
+			return fmt.Errorf("usage: env KEY=VALUE")
+		}
+		key, value, _ := strings.Cut(args[0], "=")
+		s.t.Setenv(key, value)
+	case "wait":
+		s.srv.WaitIdle()
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+	return nil
+}
+
+// runCLI executes the root command in-process the way main does, capturing
+// stdout, stderr and the exit code.
+func (s *e2eScript) runCLI(args []string) int {
+	resetCommandState()
+	rootCmd.SetArgs(args)
+	defer rootCmd.SetArgs(nil)
+
+	stdin := s.stdin
+	s.stdin = ""
+
+	code := 0
+	s.stdout, s.stderr = captureOutput(s.t, stdin, func() {
+		if err := Execute(context.Background()); err != nil {
+			fmt.Fprintln(ErrorOutput(), err)
+			code = 1
+		}
+	})
+	return code
+}
+
+// resetCommandState restores flags and configuration to their startup values so
+// each in-process run behaves like a fresh process.
+func resetCommandState() {
+	var reset func(c *cobra.Command)
+	reset = func(c *cobra.Command) {
+		for _, fs := range []*pflag.FlagSet{c.Flags(), c.PersistentFlags()} {
+			fs.VisitAll(func(f *pflag.Flag) {
+				if sv, ok := f.Value.(pflag.SliceValue); ok {
+					sv.Replace([]string{})
+				} else {
+					f.Value.Set(f.DefValue)
+				}
+				f.Changed = false
+			})
+		}
+		for _, sub := range c.Commands() {
+			reset(sub)
+		}
+	}
+	reset(rootCmd)
+
+	viper.Reset()
+	for key, flag := range flagBindings {
+		viper.BindPFlag(key, flag)
+	}
+	globalCompleter = nil
+}
+
+// captureOutput runs fn with os.Stdin, os.Stdout and os.Stderr redirected.
+func captureOutput(t *testing.T, stdin string, fn func()) (string, string) {
+	t.Helper()
+
+	inR, inW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	errR, errW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	origIn, origOut, origErr := os.Stdin, os.Stdout, os.Stderr
+	os.Stdin, os.Stdout, os.Stderr = inR, outW, errW
+	defer func() {
+		os.Stdin, os.Stdout, os.Stderr = origIn, origOut, origErr
+	}()
+
+	var wg sync.WaitGroup
+	var stdout, stderr bytes.Buffer
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		io.WriteString(inW, stdin)
+		inW.Close()
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(&stdout, outR)
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(&stderr, errR)
+	}()
+
+	fn()
+
+	outW.Close()
+	errW.Close()
+	wg.Wait()
+	inR.Close()
+	outR.Close()
+	errR.Close()
+	return stdout.String(), stderr.String()
+}
+
+var scriptVar = regexp.MustCompile(`\$(\w+)|\$\{(\w+)\}`)
+
+func (s *e2eScript) expand(text string) string {
+	return scriptVar.ReplaceAllStringFunc(text, func(m string) string {
+		sub := scriptVar.FindStringSubmatch(m)
+		name := sub[1]
+		if name == "" {
+			name = sub[2]
+		}
+		if v, ok := s.vars[name]; ok {
+			return v
+		}
+		return m
+	})
+}
+
+// splitArgs splits a script line into words, honouring quotes and expanding variables.
+func (s *e2eScript) splitArgs(line string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inWord := false
+	var quote rune
+	for _, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			cur.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				args = append(args, s.expand(cur.String()))
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inWord {
+		args = append(args, s.expand(cur.String()))
+	}
+	return args, nil
+}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/mikesmitty/file-search/internal/batch"
 	"github.com/mikesmitty/file-search/internal/constants"
 	"github.com/mikesmitty/file-search/internal/gemini"
 	"github.com/spf13/cobra"
@@ -96,8 +97,8 @@ func init() {
 			if err != nil {
 				return err
 			}
-			if outputFormat == "json" {
-				return printOutput(map[string]string{"status": "deleted", "file": fileID}, "json")
+			if outputFormat != "text" {
+				return printOutput(map[string]string{"status": "deleted", "file": fileID}, outputFormat)
 			}
 			fmt.Printf("Deleted file: %s\n", args[0])
 			return nil
@@ -147,6 +148,8 @@ func init() {
 				}
 			}
 
+			display := newBatchProgress("upload", args)
+
 			// Define the processor function for a single file
 			processor := func(ctx context.Context, path string) error {
 				displayName := uploadDisplayName
@@ -154,9 +157,7 @@ func init() {
 					displayName = filepath.Base(path)
 				}
 
-				if !quiet {
-					fmt.Printf("[+] Starting upload: %s\n", displayName)
-				}
+				display.Started(displayName)
 
 				opts := &gemini.UploadFileOptions{
 					StoreName:      storeID,
@@ -165,27 +166,32 @@ func init() {
 					MaxChunkTokens: uploadChunkSize,
 					ChunkOverlap:   uploadChunkOverlap,
 					Metadata:       metadataMap,
-					Quiet:          true, // Force quiet for inner operation to prevent output interleaving
+					Progress:       display.Reporter(path),
 				}
 				_, err := client.UploadFile(ctx, path, opts)
 				return err
 			}
 
-			// Define the progress callback
-			onProgress := func(current, total int, file string, err error) {
-				if err != nil {
-					fmt.Printf("[%d/%d] ✗ Failed: %s (%v)\n", current, total, filepath.Base(file), err)
-				} else {
-					fmt.Printf("[%d/%d] ✓ Finished: %s\n", current, total, filepath.Base(file))
-				}
+			logger, err := getLogger()
+			if err != nil {
+				return err
 			}
 
 			// Process files using the batch processor
-			batchResult := processBatch(ctx, args, processor, &BatchOptions{
+			batchResult := batch.Process(ctx, args, processor, &batch.Options{
 				Concurrency: uploadConcurrency,
+				Logger:      logger,
 				Quiet:       quiet,
-				OnProgress:  onProgress,
+				OnProgress:  display.Finished(filepath.Base),
 			})
+			display.Stop()
+
+			if display.Summary(batchResult) {
+				if len(batchResult.Failed) > 0 {
+					return fmt.Errorf("some files failed to upload")
+				}
+				return nil
+			}
 
 			// Print summary
 			if !quiet {
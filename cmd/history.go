@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/grounding"
+	"github.com/mikesmitty/file-search/internal/history"
+	"github.com/mikesmitty/file-search/internal/querycache"
+	"github.com/spf13/cobra"
+)
+
+// defaultCompareWidth is the width of rerun comparisons when $COLUMNS is unset.
+const defaultCompareWidth = 120
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Find, show and rerun past queries",
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+
+	var listSince string
+	var listLimit int
+	historyListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List past queries",
+		Long: `List the questions asked with the query command, oldest first. Only the
+most recent --limit queries are shown.
+
+Examples:
+  file-search history list
+  file-search history list --since 48h --limit 0`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := readHistory(listSince)
+			if err != nil {
+				return err
+			}
+			return printOutput(lastEntries(entries, listLimit), outputFormat)
+		},
+	}
+	historyListCmd.Flags().StringVar(&listSince, "since", "", "Only list queries newer than a duration (e.g. 24h) or an RFC 3339 time")
+	historyListCmd.Flags().IntVar(&listLimit, "limit", 20, "Maximum number of queries to list (0 for all)")
+	historyCmd.AddCommand(historyListCmd)
+
+	historyShowCmd := &cobra.Command{
+		Use:   "show <id>",
+		Short: "Show a past query's answer and sources",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			e, err := historyEntry(args[0])
+			if err != nil {
+				return err
+			}
+			return printOutput(e, outputFormat)
+		},
+	}
+	historyCmd.AddCommand(historyShowCmd)
+
+	var searchSince string
+	var searchLimit int
+	historySearchCmd := &cobra.Command{
+		Use:   "search <text...>",
+		Short: "Search past questions and answers",
+		Long: `List the past queries whose question, answer or store contains every word
+of the search text, ignoring case.
+
+Examples:
+  file-search history search vacation days
+  file-search history search calibration --since 168h`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := readHistory(searchSince)
+			if err != nil {
+				return err
+			}
+			matches := history.Search(entries, strings.Join(args, " "))
+			return printOutput(lastEntries(matches, searchLimit), outputFormat)
+		},
+	}
+	historySearchCmd.Flags().StringVar(&searchSince, "since", "", "Only search queries newer than a duration (e.g. 24h) or an RFC 3339 time")
+	historySearchCmd.Flags().IntVar(&searchLimit, "limit", 20, "Maximum number of matches to list, most recent (0 for all)")
+	historyCmd.AddCommand(historySearchCmd)
+
+	var rerunStoreName, rerunStoreID, rerunModel, rerunMetadataFilter string
+	var rerunNoCache bool
+	var rerunWidth int
+	historyRerunCmd := &cobra.Command{
+		Use:   "rerun <id>",
+		Short: "Ask a past question again and compare the answers",
+		Long: `Ask a past question again, optionally with a different model, store or
+metadata filter, and show the original and new answers side by side. The
+new answer is added to the history.
+
+An unchanged question may be answered from the query cache; use --no-cache
+to ask the API again.
+
+Examples:
+  # Compare with another model
+  file-search history rerun 12 --model gemini-2.5-pro
+
+  # Compare with another store, as JSON
+  file-search history rerun 12 --store "Handbook 2026" --format json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			orig, err := historyEntry(args[0])
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			client, err := getClient(ctx)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			storeID, storeName := orig.Store, orig.StoreName
+			switch {
+			case rerunStoreName != "":
+				if storeID, err = client.ResolveStoreName(ctx, rerunStoreName); err != nil {
+					return err
+				}
+				storeName = rerunStoreName
+			case cmd.Flags().Changed("store-id"):
+				storeID, storeName = rerunStoreID, ""
+			}
+			model := orig.Model
+			if rerunModel != "" {
+				model = rerunModel
+			}
+			filter := orig.MetadataFilter
+			if cmd.Flags().Changed("metadata-filter") {
+				filter = rerunMetadataFilter
+			}
+
+			var cached querycache.Status
+			ctx = querycache.WithStatus(ctx, &cached)
+			if rerunNoCache {
+				ctx = querycache.Bypass(ctx)
+			}
+			resp, err := client.Query(ctx, orig.Question, storeID, model, filter)
+			if err != nil {
+				return err
+			}
+			rerun := history.FromResponse(resp, orig.Question, storeID, filter, model)
+			rerun.StoreName = storeName
+			rerun.Cached = cached.Hit
+			rerun.RerunOf = orig.ID
+			recordHistory(rerun)
+
+			if outputFormat != "text" {
+				return printOutput(map[string]*history.Entry{"original": orig, "rerun": rerun}, outputFormat)
+			}
+			history.Compare(os.Stdout, orig, rerun, compareWidth(rerunWidth))
+			return nil
+		},
+	}
+	historyRerunCmd.Flags().StringVar(&rerunStoreName, "store", "", "Ask this store instead (display name)")
+	historyRerunCmd.Flags().StringVar(&rerunStoreID, "store-id", "", "Ask this store instead (resource ID; empty for no store)")
+	historyRerunCmd.Flags().StringVar(&rerunModel, "model", "", "Ask this model instead")
+	historyRerunCmd.Flags().StringVar(&rerunMetadataFilter, "metadata-filter", "", "Use this metadata filter instead (empty for none)")
+	historyRerunCmd.Flags().BoolVar(&rerunNoCache, "no-cache", false, "Ask the API even if a cached answer exists")
+	historyRerunCmd.Flags().IntVar(&rerunWidth, "width", 0, "Width of the side-by-side comparison (default: $COLUMNS, or 120)")
+	historyRerunCmd.RegisterFlagCompletionFunc("store", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return getCompleter().GetStoreNames(), cobra.ShellCompDirectiveNoFileComp
+	})
+	historyRerunCmd.RegisterFlagCompletionFunc("model", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return getCompleter().GetModelNames(), cobra.ShellCompDirectiveNoFileComp
+	})
+	historyCmd.AddCommand(historyRerunCmd)
+}
+
+// historyPath returns the query history's path, or "" if it is off.
+func historyPath() string {
+	return dataFilePath("query_history", "history.jsonl")
+}
+
+// recordHistory adds a query to the history. A failure is only a warning,
+// since the query has already been answered.
+func recordHistory(e *history.Entry) {
+	path := historyPath()
+	if path == "" {
+		return
+	}
+	if err := history.New(path).Add(e); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+func readHistory(since string) ([]history.Entry, error) {
+	path := historyPath()
+	if path == "" {
+		return nil, fmt.Errorf("the query history is disabled (query_history: off)")
+	}
+	start, err := parseSince(since, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	entries, err := history.Read(path, start)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = []history.Entry{}
+	}
+	return entries, nil
+}
+
+func historyEntry(arg string) (*history.Entry, error) {
+	id, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+	if err != nil || id <= 0 {
+		return nil, fmt.Errorf("invalid history ID: %s", arg)
+	}
+	path := historyPath()
+	if path == "" {
+		return nil, fmt.Errorf("the query history is disabled (query_history: off)")
+	}
+	return history.Get(path, id)
+}
+
+// lastEntries returns the last limit entries, or all of them if limit is 0.
+func lastEntries(entries []history.Entry, limit int) []history.Entry {
+	if limit > 0 && len(entries) > limit {
+		return entries[len(entries)-limit:]
+	}
+	return entries
+}
+
+// compareWidth is the --width flag, or else $COLUMNS, or defaultCompareWidth.
+func compareWidth(flag int) int {
+	if flag > 0 {
+		return flag
+	}
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return defaultCompareWidth
+}
+
+// printHistory lists entries one per line with a truncated question.
+func printHistory(entries []history.Entry) {
+	if len(entries) == 0 {
+		fmt.Println("No queries found.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTIME\tMODEL\tSTORE\tQUESTION")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", e.ID, e.Time.Local().Format("2006-01-02 15:04"), e.Model, e.StoreLabel(), grounding.Snippet(e.Question, 80))
+	}
+	w.Flush()
+}
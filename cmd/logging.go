@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/mikesmitty/file-search/internal/logging"
+	"github.com/spf13/viper"
+)
+
+var (
+	// runLogger is the logger for the current run, created by getLogger.
+	runLogger *slog.Logger
+	// closeLogFile closes the --log-file opened by getLogger, or is nil.
+	closeLogFile func() error
+)
+
+func init() {
+	rootCmd.PersistentFlags().String("log-level", "", "Write logs at this level and above: debug, info, warn or error (default: off, or debug with --debug)")
+	rootCmd.PersistentFlags().String("log-format", logging.FormatText, "Log format: text or json")
+	rootCmd.PersistentFlags().String("log-file", "", "Append logs to this file instead of stderr")
+	bindFlag("log_level", rootCmd.PersistentFlags().Lookup("log-level"))
+	bindFlag("log_format", rootCmd.PersistentFlags().Lookup("log-format"))
+	bindFlag("log_file", rootCmd.PersistentFlags().Lookup("log-file"))
+}
+
+// loggingEnabled reports whether logs were asked for with a level, a log
+// file or --debug.
+func loggingEnabled() bool {
+	return debug || viper.GetString("log_level") != "" || viper.GetString("log_file") != ""
+}
+
+// getLogger returns the logger configured by --log-level, --log-format and
+// --log-file, creating it on first use. Logging is off unless one of them or
+// --debug is set. Logs go to stderr by default, never to stdout.
+func getLogger() (*slog.Logger, error) {
+	if runLogger != nil {
+		return runLogger, nil
+	}
+	if !loggingEnabled() {
+		runLogger = logging.Discard()
+		return runLogger, nil
+	}
+
+	levelName := viper.GetString("log_level")
+	if levelName == "" && debug {
+		levelName = "debug"
+	}
+	level, err := logging.ParseLevel(levelName)
+	if err != nil {
+		return nil, err
+	}
+	logger, closeFn, err := logging.New(&logging.Options{
+		Level:  level,
+		Format: viper.GetString("log_format"),
+		File:   viper.GetString("log_file"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	runLogger, closeLogFile = logger, closeFn
+	return runLogger, nil
+}
+
+// closeLogger closes the log file, if any, at the end of a run.
+func closeLogger() {
+	if closeLogFile != nil {
+		if err := closeLogFile(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to close log file: %v\n", err)
+		}
+	}
+	runLogger, closeLogFile = nil, nil
+}
+
+// logHTTPClient logs the HTTP requests made through client, creating a
+// default client if it is nil.
+func logHTTPClient(client *http.Client, logger *slog.Logger) *http.Client {
+	if client == nil {
+		client = &http.Client{}
+	}
+	client.Transport = logging.Transport(client.Transport, logger)
+	return client
+}
+
+// logsToStdout reports whether the configured log file is the process's
+// stdout, which the MCP stdio transport reserves for protocol messages.
+func logsToStdout() bool {
+	path := viper.GetString("log_file")
+	if path == "" {
+		return false
+	}
+	if path == "/dev/stdout" {
+		return true
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	stdout, err := os.Stdout.Stat()
+	return err == nil && os.SameFile(info, stdout)
+}
@@ -2,8 +2,15 @@ package cmd
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"syscall"
 
-	"github.com/mikesmitty/file-search/internal/gemini"
+	"github.com/mikesmitty/file-search/internal/audit"
 	"github.com/mikesmitty/file-search/internal/mcp"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -14,29 +21,192 @@ var mcpCmd = &cobra.Command{
 	Short: "Start MCP Server",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := context.Background()
-		// For MCP, we start the server even without API key configured.
-		// Tools will fail gracefully when invoked if auth is missing.
-		key, _ := getAPIKey()
-
-		var client mcp.GeminiClient
-		if key != "" {
-			c, err := gemini.NewClient(ctx, key, nil)
+		destructive, err := mcp.ParseDestructivePolicy(viper.GetString("mcp_destructive"))
+		if err != nil {
+			return err
+		}
+		access, err := storeAccessConfig()
+		if err != nil {
+			return err
+		}
+		serverOpts := &mcp.Options{
+			Tools:       getMCPTools(),
+			Destructive: destructive,
+			StoreAccess: access,
+			ReadOnly:    viper.GetBool("mcp_read_only"),
+			// wait_operation polls as often as the CLI's wait commands
+			PollInterval: viper.GetDuration("poll_interval"),
+			Upload: &mcp.UploadPolicy{
+				AllowedRoots: configList("mcp_upload_roots"),
+				// An empty list turns the deny patterns off rather than restoring the defaults
+				DenyPatterns: append([]string{}, configList("mcp_upload_deny")...),
+				MaxFileSize:  viper.GetInt64("mcp_upload_max_mb") << 20,
+			},
+		}
+		// Refused calls never reach the client's audit middleware
+		if path := auditLogPath(); path != "" {
+			serverOpts.AuditLog = audit.New(path)
+		}
+		if listTools, _ := cmd.Flags().GetBool("list-tools"); listTools {
+			tools, err := mcp.DescribeTools(serverOpts)
 			if err != nil {
 				return err
 			}
+			return printOutput(tools, outputFormat)
+		}
+		// Catch tool name typos before connecting
+		if _, err := mcp.ResolveTools(serverOpts.Tools); err != nil {
+			return err
+		}
+		// stdout carries the stdio transport's protocol messages
+		if transport := viper.GetString("mcp_transport"); (transport == "" || transport == "stdio") && logsToStdout() {
+			return fmt.Errorf("--log-file cannot be stdout with the stdio transport, which uses stdout for MCP messages")
+		}
+		logger, err := getLogger()
+		if err != nil {
+			return err
+		}
+		serverOpts.Logger = logger
+
+		// For MCP, we start the server even without API key configured.
+		// Tools will fail gracefully when invoked if auth is missing.
+		var client mcp.GeminiClient
+		c, err := getClient(ctx)
+		if err != nil && !errors.Is(err, errAPIKeyNotSet) {
+			return err
+		}
+		if err == nil {
 			defer c.Close()
 			client = c
 		}
 
-		tools := getMCPTools()
-		return mcp.RunServer(ctx, client, tools)
+		switch transport := viper.GetString("mcp_transport"); transport {
+		case "", "stdio":
+			return mcp.RunServer(ctx, client, serverOpts)
+		case "http":
+			// Finish in-flight requests on Ctrl-C or SIGTERM
+			ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			opts := &mcp.HTTPOptions{
+				Addr:           viper.GetString("mcp_listen"),
+				BearerTokens:   configList("mcp_auth_tokens"),
+				AllowedOrigins: configList("mcp_cors_origins"),
+			}
+			if len(opts.BearerTokens) == 0 {
+				fmt.Fprintln(os.Stderr, "Warning: no --auth-token configured; the MCP server accepts unauthenticated requests")
+			}
+			fmt.Fprintf(os.Stderr, "Serving MCP on http://%s/mcp (SSE: /sse)\n", opts.Addr)
+			return mcp.RunHTTPServer(ctx, client, serverOpts, opts)
+		default:
+			return fmt.Errorf("invalid transport: %s (must be 'stdio' or 'http')", transport)
+		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(mcpCmd)
 
-	mcpCmd.Flags().StringVar(&mcpTools, "mcp-tools", "", "Comma-separated list of MCP tools to enable (default: query)")
-	viper.BindPFlag("mcp_tools", mcpCmd.Flags().Lookup("mcp-tools"))
+	mcpCmd.Flags().StringVar(&mcpTools, "mcp-tools", "", "Comma-separated MCP tools and groups (all, read, write, admin, query) to enable; a leading - excludes, e.g. all,-admin (default: all)")
+	mcpCmd.Flags().Bool("list-tools", false, "Print the tools the server would register and exit")
+	bindFlag("mcp_tools", mcpCmd.Flags().Lookup("mcp-tools"))
 	viper.BindEnv("mcp_tools", "MCP_TOOLS")
+
+	mcpCmd.Flags().String("transport", "stdio", "Transport: stdio, or http for streamable HTTP and SSE")
+	mcpCmd.Flags().String("listen", "localhost:8080", "Address to listen on with --transport http")
+	mcpCmd.Flags().String("auth-token", "", "Comma-separated bearer tokens accepted with --transport http")
+	mcpCmd.Flags().String("cors-origins", "", "Comma-separated browser origins allowed with --transport http, or *")
+	bindFlag("mcp_transport", mcpCmd.Flags().Lookup("transport"))
+	bindFlag("mcp_listen", mcpCmd.Flags().Lookup("listen"))
+	bindFlag("mcp_auth_tokens", mcpCmd.Flags().Lookup("auth-token"))
+	bindFlag("mcp_cors_origins", mcpCmd.Flags().Lookup("cors-origins"))
+	viper.BindEnv("mcp_transport", "MCP_TRANSPORT")
+	viper.BindEnv("mcp_listen", "MCP_LISTEN")
+	viper.BindEnv("mcp_auth_tokens", "MCP_AUTH_TOKENS")
+	viper.BindEnv("mcp_cors_origins", "MCP_CORS_ORIGINS")
+
+	mcpCmd.Flags().String("destructive", string(mcp.DestructiveConfirm), "Delete tools: deny, confirm (preview first, then delete with a one-time token) or allow")
+	bindFlag("mcp_destructive", mcpCmd.Flags().Lookup("destructive"))
+	viper.BindEnv("mcp_destructive", "MCP_DESTRUCTIVE")
+
+	mcpCmd.Flags().Bool("read-only", false, "Only register tools that don't modify stores, files or documents")
+	mcpCmd.Flags().StringArray("store-access", nil, "Restrict the stores a tool may use, as tool=pattern,... (repeatable; a leading ! denies, e.g. upload_file=scratch-* or delete_*=!prod-*)")
+	bindFlag("mcp_read_only", mcpCmd.Flags().Lookup("read-only"))
+	bindFlag("mcp_store_access", mcpCmd.Flags().Lookup("store-access"))
+	viper.BindEnv("mcp_read_only", "MCP_READ_ONLY")
+	viper.BindEnv("mcp_store_access", "MCP_STORE_ACCESS")
+
+	mcpCmd.Flags().String("upload-roots", "", "Comma-separated directories upload_file may read from (default: the client's roots, or the working directory; required with --transport http)")
+	mcpCmd.Flags().String("upload-deny", strings.Join(mcp.DefaultDenyPatterns, ","), "Comma-separated glob patterns of file and directory names upload_file refuses")
+	mcpCmd.Flags().Int64("upload-max-mb", mcp.DefaultMaxUploadSize>>20, "Largest file upload_file accepts, in MiB")
+	bindFlag("mcp_upload_roots", mcpCmd.Flags().Lookup("upload-roots"))
+	bindFlag("mcp_upload_deny", mcpCmd.Flags().Lookup("upload-deny"))
+	bindFlag("mcp_upload_max_mb", mcpCmd.Flags().Lookup("upload-max-mb"))
+	viper.BindEnv("mcp_upload_roots", "MCP_UPLOAD_ROOTS")
+	viper.BindEnv("mcp_upload_deny", "MCP_UPLOAD_DENY")
+	viper.BindEnv("mcp_upload_max_mb", "MCP_UPLOAD_MAX_MB")
+}
+
+// configList reads a list from the config file (as a YAML list) or from a
+// comma-separated flag or environment variable, dropping blanks.
+func configList(key string) []string {
+	if _, ok := viper.Get(key).([]any); ok {
+		return viper.GetStringSlice(key)
+	}
+	var items []string
+	for _, item := range strings.Split(viper.GetString(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// storeAccessConfig reads the per-tool store rules. The config file holds a
+// map of tool to patterns; the flag takes repeated tool=pattern,... entries
+// and the environment variable the same entries separated by semicolons.
+func storeAccessConfig() (mcp.StoreAccess, error) {
+	access := mcp.StoreAccess{}
+	var entries []string
+	switch v := viper.Get("mcp_store_access").(type) {
+	case nil:
+	case map[string]any:
+		for tool, patterns := range v {
+			switch p := patterns.(type) {
+			case string:
+				entries = append(entries, tool+"="+p)
+			case []any:
+				for _, pattern := range p {
+					entries = append(entries, fmt.Sprintf("%s=%v", tool, pattern))
+				}
+			default:
+				return nil, fmt.Errorf("invalid mcp_store_access entry for %s: expected a list of store patterns", tool)
+			}
+		}
+	case []string:
+		entries = v
+	case string:
+		entries = strings.Split(v, ";")
+	default:
+		return nil, fmt.Errorf("invalid mcp_store_access: expected a map of tool names to store patterns")
+	}
+
+	for _, entry := range entries {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		tool, patterns, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(tool) == "" {
+			return nil, fmt.Errorf("invalid store access rule %q: expected tool=pattern,...", entry)
+		}
+		tool = strings.TrimSpace(tool)
+		for _, pattern := range strings.Split(patterns, ",") {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				if _, err := path.Match(strings.TrimPrefix(pattern, "!"), ""); err != nil {
+					return nil, fmt.Errorf("invalid store pattern %q for %s: %w", pattern, tool, err)
+				}
+				access[tool] = append(access[tool], pattern)
+			}
+		}
+	}
+	return access, nil
 }
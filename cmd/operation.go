@@ -3,9 +3,13 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/mikesmitty/file-search/internal/gemini"
+	"github.com/mikesmitty/file-search/internal/progress"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var operationCmd = &cobra.Command{
@@ -43,17 +47,9 @@ Examples:
 			}
 			defer client.Close()
 
-			var opType gemini.OperationType
-			switch operationType {
-			case "import":
-				opType = gemini.OperationTypeImport
-			case "upload":
-				opType = gemini.OperationTypeUpload
-			case "":
-				// Auto-detect (empty string is valid)
-				opType = ""
-			default:
-				return fmt.Errorf("invalid operation type: %s (must be 'import' or 'upload')", operationType)
+			opType, err := parseOperationType(operationType)
+			if err != nil {
+				return err
 			}
 
 			status, err := client.GetOperation(ctx, args[0], opType)
@@ -66,4 +62,103 @@ Examples:
 	}
 	operationGetCmd.Flags().StringVar(&operationType, "type", "", "Operation type: import or upload (auto-detect if not specified)")
 	operationCmd.AddCommand(operationGetCmd)
+
+	var waitType string
+	var waitTimeout time.Duration
+	operationWaitCmd := &cobra.Command{
+		Use:   "wait [operation-name]",
+		Short: "Wait for a long-running operation to finish",
+		Long: `Poll a long-running file upload or import operation until it is done,
+then print its final status. The command fails if the operation fails or the
+timeout expires.
+
+Examples:
+  # Wait for an import to finish
+  file-search operation wait "fileSearchStores/abc123/operations/op456"
+
+  # Stream state changes as JSON lines, giving up after ten minutes
+  file-search operation wait "fileSearchStores/abc123/operations/op456" --timeout 10m --format jsonl`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opType, err := parseOperationType(waitType)
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			if waitTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, waitTimeout)
+				defer cancel()
+			}
+			client, err := getClient(ctx)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			var stream *progress.Stream
+			if outputFormat == "jsonl" {
+				stream = progress.NewStream(os.Stdout)
+			}
+			interval := viper.GetDuration("poll_interval")
+			if interval <= 0 {
+				interval = 2 * time.Second
+			}
+
+			pending := false
+			for {
+				status, err := client.GetOperation(ctx, args[0], opType)
+				if err != nil {
+					if ctx.Err() != nil {
+						return fmt.Errorf("timed out waiting for operation %s", args[0])
+					}
+					return err
+				}
+				if status.Done {
+					if stream != nil {
+						ev := progress.StreamEvent{Event: progress.EventDone, Operation: status.Name, Store: status.Parent, Document: status.DocumentName}
+						if status.Failed {
+							ev.Event = progress.EventFailed
+							ev.Error = status.ErrorMessage
+						}
+						stream.Emit(ev)
+					} else if err := printOutput(status, outputFormat); err != nil {
+						return err
+					}
+					if status.Failed {
+						return fmt.Errorf("operation %s failed: %s", status.Name, status.ErrorMessage)
+					}
+					return nil
+				}
+				if !pending && stream != nil {
+					stream.Emit(progress.StreamEvent{Event: progress.EventPending, Operation: status.Name})
+				}
+				pending = true
+
+				select {
+				case <-ctx.Done():
+					return fmt.Errorf("timed out waiting for operation %s", args[0])
+				case <-time.After(interval):
+				}
+			}
+		},
+	}
+	operationWaitCmd.Flags().StringVar(&waitType, "type", "", "Operation type: import or upload (auto-detect if not specified)")
+	operationWaitCmd.Flags().DurationVar(&waitTimeout, "timeout", 0, "Give up after this long (default: wait indefinitely)")
+	operationCmd.AddCommand(operationWaitCmd)
+}
+
+// parseOperationType converts the --type flag to an OperationType. An empty
+// string auto-detects the type.
+func parseOperationType(s string) (gemini.OperationType, error) {
+	switch s {
+	case "import":
+		return gemini.OperationTypeImport, nil
+	case "upload":
+		return gemini.OperationTypeUpload, nil
+	case "":
+		return "", nil
+	}
+	return "", fmt.Errorf("invalid operation type: %s (must be 'import' or 'upload')", s)
 }
@@ -2,9 +2,13 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/mikesmitty/file-search/internal/constants"
+	"github.com/mikesmitty/file-search/internal/history"
+	"github.com/mikesmitty/file-search/internal/querycache"
 	"github.com/spf13/cobra"
 )
 
@@ -37,11 +41,28 @@ var queryCmd = &cobra.Command{
 		// Join all arguments to form the query string
 		queryString := strings.Join(args, " ")
 
+		var cached querycache.Status
+		ctx = querycache.WithStatus(ctx, &cached)
+		if queryNoCache {
+			ctx = querycache.Bypass(ctx)
+		}
 		resp, err := client.Query(ctx, queryString, storeID, queryModel, queryMetadataFilter)
 		if err != nil {
 			return err
 		}
-		return printOutput(resp, outputFormat)
+		entry := history.FromResponse(resp, queryString, storeID, queryMetadataFilter, queryModel)
+		entry.StoreName = queryStoreName
+		entry.Cached = cached.Hit
+		recordHistory(entry)
+
+		if err := printOutput(resp, outputFormat); err != nil {
+			return err
+		}
+		if cached.Hit && outputFormat == "text" {
+			fmt.Printf("\n[Cached]\nAnswered from the local cache (saved %s ago), so no tokens were used. Use --no-cache for a fresh answer.\n",
+				time.Since(cached.Created).Round(time.Second))
+		}
+		return nil
 	},
 }
 
@@ -50,6 +71,7 @@ var (
 	queryStoreID        string
 	queryModel          string
 	queryMetadataFilter string
+	queryNoCache        bool
 )
 
 func init() {
@@ -59,6 +81,7 @@ func init() {
 	queryCmd.Flags().StringVar(&queryStoreID, "store-id", "", "Store resource ID (optional, "+constants.StoreResourcePrefix+"xxx)")
 	queryCmd.Flags().StringVar(&queryModel, "model", constants.DefaultModel, "Model name")
 	queryCmd.Flags().StringVar(&queryMetadataFilter, "metadata-filter", "", "Metadata filter expression (optional)")
+	queryCmd.Flags().BoolVar(&queryNoCache, "no-cache", false, "Ask the API even if a cached answer exists (the fresh answer replaces it)")
 	queryCmd.RegisterFlagCompletionFunc("store", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return getCompleter().GetStoreNames(), cobra.ShellCompDirectiveNoFileComp
 	})
@@ -3,22 +3,36 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
-	"regexp"
+	"reflect"
 	"strings"
 	"time"
 
+	"github.com/mikesmitty/file-search/internal/audit"
+	"github.com/mikesmitty/file-search/internal/batch"
 	"github.com/mikesmitty/file-search/internal/completion"
 	"github.com/mikesmitty/file-search/internal/gemini"
+	"github.com/mikesmitty/file-search/internal/grounding"
+	"github.com/mikesmitty/file-search/internal/history"
+	"github.com/mikesmitty/file-search/internal/mcp"
+	"github.com/mikesmitty/file-search/internal/progress"
+	"github.com/mikesmitty/file-search/internal/usage"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"google.golang.org/genai"
 )
 
 var (
 	cfgFile      string
+	profile      string
+	backend      string
 	apiKey       string
+	baseURL      string
 	apiKeyEnv    string
 	outputFormat string
 	mcpTools     string
@@ -45,15 +59,35 @@ func init() {
 	cobra.OnInitialize(initConfig)
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.file-search.yaml)")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "Named profile from the config file to apply")
+	rootCmd.PersistentFlags().StringVar(&backend, "backend", "", "API backend: gemini or vertex (default: gemini)")
 	rootCmd.PersistentFlags().StringVar(&apiKey, "api-key", "", "Gemini API Key")
+	rootCmd.PersistentFlags().StringVar(&baseURL, "base-url", "", "Override the API base URL (e.g. a regional endpoint or local mock server)")
 	rootCmd.PersistentFlags().StringVar(&apiKeyEnv, "api-key-env", "", "Environment variable to read API Key from")
-	rootCmd.PersistentFlags().StringVar(&outputFormat, "format", "text", "Output format: text or json")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "format", "text", "Output format: text, json or jsonl")
 	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Suppress progress indicators")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
-	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Enable debug output (JSON)")
+	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Enable debug output (JSON) and debug logs")
 
-	viper.BindPFlag("api_key", rootCmd.PersistentFlags().Lookup("api-key"))
-	viper.BindPFlag("api_key_env", rootCmd.PersistentFlags().Lookup("api-key-env"))
+	bindFlag("profile", rootCmd.PersistentFlags().Lookup("profile"))
+	bindFlag("backend", rootCmd.PersistentFlags().Lookup("backend"))
+	bindFlag("api_key", rootCmd.PersistentFlags().Lookup("api-key"))
+	bindFlag("base_url", rootCmd.PersistentFlags().Lookup("base-url"))
+	bindFlag("api_key_env", rootCmd.PersistentFlags().Lookup("api-key-env"))
+
+	rootCmd.PersistentFlags().String("telemetry", "", "Export OpenTelemetry traces and metrics: none, stdout (to stderr) or otlp")
+	bindFlag("telemetry", rootCmd.PersistentFlags().Lookup("telemetry"))
+}
+
+// flagBindings records the config keys bound to flags so the bindings can be
+// restored after viper is reset (e.g. between in-process test runs).
+var flagBindings = map[string]*pflag.Flag{}
+
+// bindFlag binds a config key to a flag, so the flag takes precedence over
+// environment variables and the config file.
+func bindFlag(key string, flag *pflag.Flag) {
+	flagBindings[key] = flag
+	viper.BindPFlag(key, flag)
 }
 
 var globalCompleter *completion.Completer
@@ -71,40 +105,18 @@ func getCompleter() *completion.Completer {
 		cacheTTL = 300 * time.Second // 5 minutes default
 	}
 
-	// Get API key
-	key, err := getAPIKey()
-	if err != nil || key == "" {
-		// If no API key, create disabled completer
-		globalCompleter = completion.NewCompleter("", false, cacheTTL)
-		return globalCompleter
-	}
-
-	// Create completer with configuration
-	globalCompleter = completion.NewCompleter(key, enabled, cacheTTL)
+	// Completion uses the same client as commands, so it honours the
+	// backend, profile, network settings and client factory
+	globalCompleter = completion.NewCompleter(getClient, enabled, cacheTTL)
 	return globalCompleter
 }
 
 // getMCPTools returns the list of enabled MCP tools
 // Supports comma-separated string from flag/env/config
-// Default: ["query"]
+// Default: ["all"] (set in initConfig); an empty setting falls back to ["query"]
 func getMCPTools() []string {
-	// Check if set via flag/env/config
-	toolsStr := viper.GetString("mcp_tools")
-	if toolsStr == "" {
-		// Default to query only
-		return []string{"query"}
-	}
-
-	// Parse comma-separated list
-	tools := strings.Split(toolsStr, ",")
-	result := make([]string, 0, len(tools))
-	for _, tool := range tools {
-		trimmed := strings.TrimSpace(tool)
-		if trimmed != "" {
-			result = append(result, trimmed)
-		}
-	}
-
+	// Check if set via flag/env/config, as a list or comma-separated string
+	result := configList("mcp_tools")
 	if len(result) == 0 {
 		return []string{"query"}
 	}
@@ -131,18 +143,62 @@ func initConfig() {
 	viper.SetDefault("completion_enabled", true)
 	viper.SetDefault("completion_cache_ttl", "300s")
 	viper.SetDefault("mcp_tools", "all")
+	viper.SetDefault("query_cache", false)
+	viper.SetDefault("query_cache_ttl", "1h")
 
 	// Bind environment variables
 	viper.BindEnv("api_key", "GOOGLE_API_KEY", "GEMINI_API_KEY")
 	viper.BindEnv("mcp_tools", "MCP_TOOLS")
 	viper.BindEnv("completion_enabled", "COMPLETION_ENABLED")
 	viper.BindEnv("completion_cache_ttl", "COMPLETION_CACHE_TTL")
+	viper.BindEnv("profile", "FILE_SEARCH_PROFILE")
+	viper.BindEnv("data_dir", "FILE_SEARCH_DATA_DIR")
+	viper.BindEnv("audit_log", "FILE_SEARCH_AUDIT_LOG")
+	viper.BindEnv("usage_ledger", "FILE_SEARCH_USAGE_LEDGER")
+	viper.BindEnv("query_cache", "FILE_SEARCH_QUERY_CACHE")
+	viper.BindEnv("query_cache_ttl", "FILE_SEARCH_QUERY_CACHE_TTL")
+	viper.BindEnv("query_history", "FILE_SEARCH_QUERY_HISTORY")
+	viper.BindEnv("telemetry", "FILE_SEARCH_TELEMETRY")
+	viper.BindEnv("telemetry_endpoint", "FILE_SEARCH_TELEMETRY_ENDPOINT")
+	viper.BindEnv("log_level", "FILE_SEARCH_LOG_LEVEL")
+	viper.BindEnv("log_format", "FILE_SEARCH_LOG_FORMAT")
+	viper.BindEnv("log_file", "FILE_SEARCH_LOG_FILE")
+	viper.BindEnv("backend", "FILE_SEARCH_BACKEND")
+	viper.BindEnv("vertex_project", "GOOGLE_CLOUD_PROJECT")
+	viper.BindEnv("vertex_location", "GOOGLE_CLOUD_LOCATION", "GOOGLE_CLOUD_REGION")
+	viper.BindEnv("base_url", "FILE_SEARCH_BASE_URL")
+	viper.BindEnv("proxy", "FILE_SEARCH_PROXY")
+	viper.BindEnv("ca_cert", "FILE_SEARCH_CA_CERT")
+	viper.BindEnv("client_cert", "FILE_SEARCH_CLIENT_CERT")
+	viper.BindEnv("client_key", "FILE_SEARCH_CLIENT_KEY")
+	viper.BindEnv("request_timeout", "FILE_SEARCH_REQUEST_TIMEOUT")
+	viper.BindEnv("poll_interval", "FILE_SEARCH_POLL_INTERVAL")
 
 	if err := viper.ReadInConfig(); err == nil {
 		// fmt.Println("Using config file:", viper.ConfigFileUsed())
 	}
+
+	if err := applyProfile(viper.GetString("profile")); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 }
 
+// applyProfile merges the settings under profiles.<name> over the top-level config file
+// settings. Flags and environment variables still take precedence over profile values.
+func applyProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+	key := "profiles." + name
+	if !viper.IsSet(key) {
+		return fmt.Errorf("profile not found in config: %s", name)
+	}
+	return viper.MergeConfigMap(viper.GetStringMap(key))
+}
+
+var errAPIKeyNotSet = errors.New("API key not set. Use --api-key, --api-key-env, config file, or GOOGLE_API_KEY/GEMINI_API_KEY")
+
 func getAPIKey() (string, error) {
 	// 1. Check if a custom env var is specified
 	if envVar := viper.GetString("api_key_env"); envVar != "" {
@@ -154,17 +210,204 @@ func getAPIKey() (string, error) {
 	// 2. Check standard config/env
 	key := viper.GetString("api_key")
 	if key == "" {
-		return "", fmt.Errorf("API key not set. Use --api-key, --api-key-env, config file, or GOOGLE_API_KEY/GEMINI_API_KEY")
+		return "", errAPIKeyNotSet
 	}
 	return key, nil
 }
 
-func getClient(ctx context.Context) (*gemini.Client, error) {
-	key, err := getAPIKey()
+// getHTTPClient builds the HTTP client from the proxy, TLS and timeout settings.
+// It returns nil when none are configured.
+func getHTTPClient() (*http.Client, error) {
+	return gemini.NewHTTPClient(&gemini.TransportOptions{
+		ProxyURL:       viper.GetString("proxy"),
+		CACertFile:     viper.GetString("ca_cert"),
+		ClientCertFile: viper.GetString("client_cert"),
+		ClientKeyFile:  viper.GetString("client_key"),
+		Timeout:        viper.GetDuration("request_timeout"),
+	})
+}
+
+// getClientConfig builds the client configuration for the selected backend.
+func getClientConfig() (*gemini.ClientConfig, error) {
+	var cfg *gemini.ClientConfig
+	switch b := gemini.Backend(viper.GetString("backend")); b {
+	case "", gemini.BackendGemini:
+		key, err := getAPIKey()
+		if err != nil {
+			return nil, err
+		}
+		cfg = &gemini.ClientConfig{Backend: gemini.BackendGemini, APIKey: key}
+	case gemini.BackendVertex:
+		cfg = &gemini.ClientConfig{
+			Backend:  gemini.BackendVertex,
+			Project:  viper.GetString("vertex_project"),
+			Location: viper.GetString("vertex_location"),
+		}
+	default:
+		return nil, fmt.Errorf("unknown backend: %s (must be '%s' or '%s')", b, gemini.BackendGemini, gemini.BackendVertex)
+	}
+
+	httpClient, err := getHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	if telemetryEnabled() {
+		httpClient = instrumentHTTPClient(httpClient)
+	}
+	logger, err := getLogger()
+	if err != nil {
+		return nil, err
+	}
+	if loggingEnabled() {
+		httpClient = logHTTPClient(httpClient, logger)
+	}
+	cfg.HTTPClient = httpClient
+	cfg.BaseURL = viper.GetString("base_url")
+	cfg.PollInterval = viper.GetDuration("poll_interval")
+	cfg.Logger = logger
+	return cfg, nil
+}
+
+// ClientFactory creates the API client used by commands.
+type ClientFactory func(ctx context.Context) (gemini.API, error)
+
+var (
+	clientFactory     ClientFactory = defaultClientFactory
+	clientMiddlewares []gemini.Middleware
+)
+
+// SetClientFactory replaces the function commands use to create their API client,
+// e.g. to substitute a mock. A nil factory restores the default.
+func SetClientFactory(factory ClientFactory) {
+	if factory == nil {
+		factory = defaultClientFactory
+	}
+	clientFactory = factory
+}
+
+// UseClientMiddleware adds middleware that wraps every client commands create.
+// Middleware added first is outermost.
+func UseClientMiddleware(mws ...gemini.Middleware) {
+	clientMiddlewares = append(clientMiddlewares, mws...)
+}
+
+// defaultClientFactory creates a client from the flags, environment and config file.
+func defaultClientFactory(ctx context.Context) (gemini.API, error) {
+	cfg, err := getClientConfig()
 	if err != nil {
 		return nil, err
 	}
-	return gemini.NewClient(ctx, key, nil)
+	return gemini.NewClientWithConfig(ctx, cfg)
+}
+
+// getClient creates a client with the configured factory and middleware.
+func getClient(ctx context.Context) (gemini.API, error) {
+	if _, err := getLogger(); err != nil {
+		return nil, err
+	}
+	tracing, err := startTelemetry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	client, err := clientFactory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mws := clientMiddlewares
+	// The cache sits inside the audit log and outside the usage ledger, so
+	// cached answers record no token usage
+	for _, mw := range []gemini.Middleware{tracing, auditMiddleware(), queryCacheMiddleware(), usageMiddleware()} {
+		if mw != nil {
+			mws = append(mws[:len(mws):len(mws)], mw)
+		}
+	}
+	return gemini.Chain(client, mws...), nil
+}
+
+// batchProgress shows the progress of an upload or import batch. On a
+// terminal a single file gets a status line and several files get a live
+// line per worker; otherwise it prints one line as each file starts and ends.
+// With --format jsonl every state change is written as a JSON line instead.
+type batchProgress struct {
+	verb     string
+	reporter gemini.ProgressReporter
+	multi    *progress.MultiBar
+	stream   *progress.Stream
+}
+
+func newBatchProgress(verb string, files []string) *batchProgress {
+	p := &batchProgress{verb: verb}
+	if outputFormat == "jsonl" {
+		p.stream = progress.NewStream(os.Stdout)
+		for _, f := range files {
+			p.stream.Queued(f)
+		}
+		return p
+	}
+	if quiet || outputFormat != "text" || !progress.IsTerminal(os.Stdout) {
+		return p
+	}
+	if len(files) == 1 {
+		p.reporter = progress.NewBar(os.Stdout)
+	} else {
+		p.multi = progress.NewMultiBar(os.Stdout, len(files))
+		p.reporter = p.multi
+	}
+	return p
+}
+
+// Reporter returns the reporter to pass to UploadFile or ImportFile for
+// file, or nil.
+func (p *batchProgress) Reporter(file string) gemini.ProgressReporter {
+	if p.stream != nil {
+		return p.stream.Reporter(file)
+	}
+	return p.reporter
+}
+
+// Started announces that name is being processed.
+func (p *batchProgress) Started(name string) {
+	if quiet || p.multi != nil || p.stream != nil {
+		return
+	}
+	fmt.Printf("[+] Starting %s: %s\n", p.verb, name)
+}
+
+// Finished is the batch.Options OnProgress callback. label formats the file
+// for the line-mode output.
+func (p *batchProgress) Finished(label func(string) string) func(current, total int, file string, err error) {
+	return func(current, total int, file string, err error) {
+		if p.stream != nil {
+			p.stream.Finished(file, err)
+			return
+		}
+		if p.multi != nil {
+			p.multi.Complete(file, err)
+			return
+		}
+		if err != nil {
+			fmt.Printf("[%d/%d] ✗ Failed: %s (%v)\n", current, total, label(file), err)
+		} else {
+			fmt.Printf("[%d/%d] ✓ Finished: %s\n", current, total, label(file))
+		}
+	}
+}
+
+// Stop erases the live display once the batch is done.
+func (p *batchProgress) Stop() {
+	if p.multi != nil {
+		p.multi.Stop()
+	}
+}
+
+// Summary ends a jsonl stream with the batch totals. It reports whether the
+// stream is in use, in which case nothing else should be printed.
+func (p *batchProgress) Summary(r *batch.Result) bool {
+	if p.stream == nil {
+		return false
+	}
+	p.stream.Summary(r.Total, len(r.Succeeded), len(r.Failed))
+	return true
 }
 
 // printOutput handles formatting and printing of results
@@ -174,6 +417,19 @@ func printOutput(data interface{}, format string) error {
 		enc.SetIndent("", "  ")
 		return enc.Encode(data)
 	}
+	if format == "jsonl" {
+		// One compact object per line; lists are written one item per line
+		enc := json.NewEncoder(os.Stdout)
+		if v := reflect.ValueOf(data); v.Kind() == reflect.Slice {
+			for i := range v.Len() {
+				if err := enc.Encode(v.Index(i).Interface()); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return enc.Encode(data)
+	}
 
 	// Text formatting based on type
 	switch v := data.(type) {
@@ -222,104 +478,37 @@ func printOutput(data interface{}, format string) error {
 			}
 		}
 	case *genai.GenerateContentResponse:
-		for _, cand := range v.Candidates {
-			for _, part := range cand.Content.Parts {
-				fmt.Printf("%v\n", part.Text)
+		grounding.Render(os.Stdout, v, &grounding.RenderOptions{Verbose: verbose, Debug: debug})
+	case []audit.Entry:
+		for _, e := range v {
+			actor := e.Actor
+			if e.Client != "" {
+				actor += " (" + e.Client + ")"
 			}
-			if cand.GroundingMetadata != nil {
-				fmt.Printf("\n[Grounding Metadata]\n")
-
-				// Debug output: Print full metadata as JSON if --debug is set
-				if debug {
-					debugJSON, err := json.MarshalIndent(cand.GroundingMetadata, "", "  ")
-					if err == nil {
-						fmt.Println(string(debugJSON))
-					}
-				}
-
-				if len(cand.GroundingMetadata.GroundingChunks) > 0 {
-					fmt.Println("\nSources:")
-					for i, chunk := range cand.GroundingMetadata.GroundingChunks {
-						if chunk.Web != nil {
-							fmt.Printf("  %d. [Web] %s (%s)\n", i+1, chunk.Web.Title, chunk.Web.URI)
-						} else if chunk.RetrievedContext != nil {
-							title := chunk.RetrievedContext.Title
-							if title == "" {
-								title = "Unknown Document"
-							}
-
-							// Build location string (URI and/or Page)
-							var locParts []string
-							if chunk.RetrievedContext.URI != "" {
-								locParts = append(locParts, fmt.Sprintf("URI: %s", chunk.RetrievedContext.URI))
-							}
-
-							// Check for RAGChunk page numbers
-							if chunk.RetrievedContext.RAGChunk != nil && chunk.RetrievedContext.RAGChunk.PageSpan != nil {
-								span := chunk.RetrievedContext.RAGChunk.PageSpan
-								if span.FirstPage > 0 {
-									if span.FirstPage == span.LastPage || span.LastPage == 0 {
-										locParts = append(locParts, fmt.Sprintf("Page %d", span.FirstPage))
-									} else {
-										locParts = append(locParts, fmt.Sprintf("Pages %d-%d", span.FirstPage, span.LastPage))
-									}
-								}
-							}
-
-							// Fallback: Extract page number from text using regex
-							// Look for pattern like "--- PAGE 17 ---"
-							if chunk.RetrievedContext.Text != "" {
-								re := regexp.MustCompile(`--- PAGE (\d+) ---`)
-								matches := re.FindStringSubmatch(chunk.RetrievedContext.Text)
-								if len(matches) > 1 {
-									// Only add if we haven't already added a page number from RAGChunk
-									alreadyHasPage := false
-									for _, part := range locParts {
-										if strings.Contains(part, "Page") {
-											alreadyHasPage = true
-											break
-										}
-									}
-									if !alreadyHasPage {
-										locParts = append(locParts, fmt.Sprintf("Page %s", matches[1]))
-									}
-								}
-							}
-
-							locStr := ""
-							if len(locParts) > 0 {
-								locStr = fmt.Sprintf(" (%s)", strings.Join(locParts, ", "))
-							}
-
-							fmt.Printf("  %d. [Doc] %s%s\n", i+1, title, locStr)
-
-							if chunk.RetrievedContext.Text != "" {
-								text := chunk.RetrievedContext.Text
-
-								if verbose {
-									// Verbose mode: Print full text but collapse excessive newlines
-									// Replace 3+ newlines with 2
-									re := regexp.MustCompile(`\n{3,}`)
-									text = re.ReplaceAllString(text, "\n\n")
-									fmt.Printf("     Full Text:\n%s\n", text)
-								} else {
-									// Default mode: Clean up snippet (single line)
-									text = strings.ReplaceAll(text, "\n", " ")
-									text = strings.ReplaceAll(text, "\r", " ")
-									text = strings.Join(strings.Fields(text), " ") // Collapse multiple spaces
-
-									// Truncate text if too long
-									if len(text) > 200 {
-										text = text[:197] + "..."
-									}
-									// Indent the snippet
-									fmt.Printf("     Snippet: %s\n", text)
-								}
-							}
-						}
-					}
-				}
+			if e.Tool != "" {
+				actor += " via " + e.Tool
 			}
+			outcome := e.Outcome
+			if e.Error != "" {
+				outcome += ": " + e.Error
+			}
+			fmt.Printf("%s %s (%s) %s %s - %s\n", e.Time.Local().Format(time.RFC3339), actor, e.Source, e.Action, strings.Join(e.Resources, ", "), outcome)
+		}
+	case *usage.Report:
+		printUsageReport(v)
+	case []history.Entry:
+		printHistory(v)
+	case *history.Entry:
+		history.Render(os.Stdout, v)
+	case []mcp.ToolInfo:
+		for _, t := range v {
+			access := "read-only"
+			if t.Destructive {
+				access = "destructive"
+			} else if !t.ReadOnly {
+				access = "write"
+			}
+			fmt.Printf("%s - %s (%s; groups: %s)\n", t.Name, t.Title, access, strings.Join(t.Groups, ", "))
 		}
 	case *gemini.OperationStatus:
 		fmt.Printf("Operation: %s\n", v.Name)
@@ -355,5 +544,17 @@ func printOutput(data interface{}, format string) error {
 
 // Execute runs the root command
 func Execute(ctx context.Context) error {
+	defer closeLogger()
+	defer stopTelemetry()
 	return rootCmd.ExecuteContext(ctx)
 }
+
+// ErrorOutput returns where a failed command's error should be printed:
+// stdout, except when stdout carries a JSON Lines stream that the error
+// would corrupt.
+func ErrorOutput() io.Writer {
+	if outputFormat == "jsonl" {
+		return os.Stderr
+	}
+	return os.Stdout
+}
@@ -1,9 +1,14 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/mikesmitty/file-search/internal/gemini"
+	"github.com/mikesmitty/file-search/internal/gemini/fake"
 	"github.com/spf13/viper"
 )
 
@@ -168,7 +173,7 @@ func TestGetCompleterConfiguration(t *testing.T) {
 		viper.Set("completion_enabled", true)
 		viper.Set("completion_cache_ttl", 0)
 
-		// Note: Can't actually test getCompleter() without mocking getAPIKey()
+		// Note: getCompleter() builds its client through getClient; the e2e tests cover it
 		// This test verifies the configuration parsing behavior
 
 		ttl := viper.GetDuration("completion_cache_ttl")
@@ -188,3 +193,187 @@ func TestGetCompleterConfiguration(t *testing.T) {
 		}
 	})
 }
+
+func TestApplyProfile(t *testing.T) {
+	config := `
+backend: gemini
+vertex_location: us-central1
+profiles:
+  prod:
+    backend: vertex
+    vertex_project: prod-project
+`
+	load := func(t *testing.T) {
+		t.Helper()
+		viper.Reset()
+		viper.SetConfigType("yaml")
+		if err := viper.ReadConfig(strings.NewReader(config)); err != nil {
+			t.Fatalf("failed to read config: %v", err)
+		}
+	}
+
+	t.Run("profile overrides config file", func(t *testing.T) {
+		load(t)
+		if err := applyProfile("prod"); err != nil {
+			t.Fatalf("applyProfile failed: %v", err)
+		}
+		if got := viper.GetString("backend"); got != "vertex" {
+			t.Errorf("expected backend vertex, got %s", got)
+		}
+		if got := viper.GetString("vertex_project"); got != "prod-project" {
+			t.Errorf("expected vertex_project prod-project, got %s", got)
+		}
+		// Keys not set in the profile fall through to the top level
+		if got := viper.GetString("vertex_location"); got != "us-central1" {
+			t.Errorf("expected vertex_location us-central1, got %s", got)
+		}
+	})
+
+	t.Run("explicit values win over profile", func(t *testing.T) {
+		load(t)
+		viper.Set("backend", "gemini")
+		if err := applyProfile("prod"); err != nil {
+			t.Fatalf("applyProfile failed: %v", err)
+		}
+		if got := viper.GetString("backend"); got != "gemini" {
+			t.Errorf("expected backend gemini, got %s", got)
+		}
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		load(t)
+		if err := applyProfile("staging"); err == nil {
+			t.Error("expected error for unknown profile")
+		}
+	})
+
+	t.Run("no profile", func(t *testing.T) {
+		load(t)
+		if err := applyProfile(""); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestGetClientConfig(t *testing.T) {
+	t.Run("vertex", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("backend", "vertex")
+		viper.Set("vertex_project", "p")
+		viper.Set("vertex_location", "l")
+
+		cfg, err := getClientConfig()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cfg.Backend != gemini.BackendVertex || cfg.Project != "p" || cfg.Location != "l" {
+			t.Errorf("unexpected config: %+v", cfg)
+		}
+	})
+
+	t.Run("gemini without key", func(t *testing.T) {
+		viper.Reset()
+		_, err := getClientConfig()
+		if !errors.Is(err, errAPIKeyNotSet) {
+			t.Errorf("expected errAPIKeyNotSet, got %v", err)
+		}
+	})
+
+	t.Run("unknown backend", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("backend", "bedrock")
+		if _, err := getClientConfig(); err == nil {
+			t.Error("expected error for unknown backend")
+		}
+	})
+}
+
+func TestClientFactory(t *testing.T) {
+	srv := fake.NewServer(nil)
+	defer srv.Close()
+
+	rec := gemini.NewRecorder()
+	SetClientFactory(func(ctx context.Context) (gemini.API, error) {
+		return gemini.NewClientWithConfig(ctx, &gemini.ClientConfig{APIKey: "test-key", BaseURL: srv.URL})
+	})
+	UseClientMiddleware(rec.Middleware())
+	defer func() {
+		SetClientFactory(nil)
+		clientMiddlewares = nil
+	}()
+
+	// The factory is used even without an API key in the environment
+	t.Setenv("GOOGLE_API_KEY", "")
+	t.Setenv("GEMINI_API_KEY", "")
+	t.Setenv("HOME", t.TempDir())
+
+	resetCommandState()
+	rootCmd.SetArgs([]string{"store", "create", "Injected"})
+	defer rootCmd.SetArgs(nil)
+	stdout, _ := captureOutput(t, "", func() {
+		if err := Execute(context.Background()); err != nil {
+			t.Errorf("store create failed: %v", err)
+		}
+	})
+
+	if !strings.Contains(stdout, "Injected") {
+		t.Errorf("unexpected output: %s", stdout)
+	}
+	if got := rec.Methods(); len(got) != 1 || got[0] != "CreateStore" {
+		t.Errorf("recorded methods = %v, want [CreateStore]", got)
+	}
+}
+
+func TestStoreAccessConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   any
+		want    map[string][]string
+		wantErr string
+	}{
+		{name: "unset", value: nil, want: map[string][]string{}},
+		{
+			name:  "config file map",
+			value: map[string]any{"upload_file": []any{"scratch-*"}, "delete_*": "!prod-*"},
+			want:  map[string][]string{"upload_file": {"scratch-*"}, "delete_*": {"!prod-*"}},
+		},
+		{
+			name:  "flag entries",
+			value: []string{"upload_file=scratch-*, team-*", "delete_*=!prod-*"},
+			want:  map[string][]string{"upload_file": {"scratch-*", "team-*"}, "delete_*": {"!prod-*"}},
+		},
+		{
+			name:  "environment variable",
+			value: "upload_file=scratch-*;*=!prod-*",
+			want:  map[string][]string{"upload_file": {"scratch-*"}, "*": {"!prod-*"}},
+		},
+		{name: "missing tool", value: []string{"scratch-*"}, wantErr: "expected tool=pattern"},
+		{name: "bad pattern", value: []string{"upload_file=[a-"}, wantErr: "invalid store pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			if tt.value != nil {
+				viper.Set("mcp_store_access", tt.value)
+			}
+			got, err := storeAccessConfig()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("storeAccessConfig() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("storeAccessConfig() = %v, want %v", got, tt.want)
+			}
+			for tool, patterns := range tt.want {
+				if strings.Join(got[tool], "|") != strings.Join(patterns, "|") {
+					t.Errorf("%s: patterns = %v, want %v", tool, got[tool], patterns)
+				}
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/mikesmitty/file-search/internal/batch"
 	"github.com/mikesmitty/file-search/internal/constants"
 	"github.com/mikesmitty/file-search/internal/gemini"
 	"github.com/spf13/cobra"
@@ -95,8 +96,8 @@ func init() {
 			if err != nil {
 				return err
 			}
-			if outputFormat == "json" {
-				return printOutput(map[string]string{"status": "deleted", "name": args[0]}, "json")
+			if outputFormat != "text" {
+				return printOutput(map[string]string{"status": "deleted", "name": args[0]}, outputFormat)
 			}
 			fmt.Printf("Deleted store: %s\n", args[0])
 			return nil
@@ -122,8 +123,8 @@ func init() {
 			if err != nil {
 				return err
 			}
-			if outputFormat == "json" {
-				return printOutput(store, "json")
+			if outputFormat != "text" {
+				return printOutput(store, outputFormat)
 			}
 			fmt.Printf("Created store: %s (%s)\n", store.DisplayName, store.Name)
 			return nil
@@ -158,6 +159,8 @@ func init() {
 				}
 			}
 
+			display := newBatchProgress("import", args)
+
 			// Define the processor function for a single file ID/name
 			processor := func(ctx context.Context, fileIDOrName string) error {
 				// Resolve file name to ID
@@ -165,32 +168,33 @@ func init() {
 				if err != nil {
 					return err
 				}
-				
-				if !quiet {
-					fmt.Printf("[+] Starting import: %s\n", fileIDOrName)
-				}
 
-				err = client.ImportFile(ctx, fileID, storeID, &gemini.ImportFileOptions{
-					Quiet: true, // Force quiet for inner operation
-				})
+				display.Started(fileIDOrName)
+
+				err = client.ImportFile(ctx, fileID, storeID, &gemini.ImportFileOptions{Progress: display.Reporter(fileIDOrName)})
 				return err
 			}
 
-			// Define the progress callback
-			onProgress := func(current, total int, file string, err error) {
-				if err != nil {
-					fmt.Printf("[%d/%d] ✗ Failed: %s (%v)\n", current, total, file, err)
-				} else {
-					fmt.Printf("[%d/%d] ✓ Finished: %s\n", current, total, file)
-				}
+			logger, err := getLogger()
+			if err != nil {
+				return err
 			}
 
 			// Process files using the batch processor
-			batchResult := processBatch(ctx, args, processor, &BatchOptions{
+			batchResult := batch.Process(ctx, args, processor, &batch.Options{
 				Concurrency: importConcurrency,
+				Logger:      logger,
 				Quiet:       quiet,
-				OnProgress:  onProgress,
+				OnProgress:  display.Finished(func(file string) string { return file }),
 			})
+			display.Stop()
+
+			if display.Summary(batchResult) {
+				if len(batchResult.Failed) > 0 {
+					return fmt.Errorf("some files failed to import")
+				}
+				return nil
+			}
 
 			// Print summary
 			if !quiet {
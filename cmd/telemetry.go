@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/gemini"
+	"github.com/mikesmitty/file-search/internal/telemetry"
+	"github.com/spf13/viper"
+)
+
+// shutdownTelemetry flushes the exporters set up by startTelemetry, or is nil.
+var shutdownTelemetry func(context.Context) error
+
+// telemetryEnabled reports whether an exporter is configured.
+func telemetryEnabled() bool {
+	exporter, err := telemetry.ParseExporter(viper.GetString("telemetry"))
+	return err == nil && exporter != telemetry.ExporterNone
+}
+
+// startTelemetry installs the configured exporters once per run and returns
+// the client middleware that feeds them, or nil if telemetry is off.
+func startTelemetry(ctx context.Context) (gemini.Middleware, error) {
+	exporter, err := telemetry.ParseExporter(viper.GetString("telemetry"))
+	if err != nil || exporter == telemetry.ExporterNone {
+		return nil, err
+	}
+	if shutdownTelemetry == nil {
+		shutdown, err := telemetry.Setup(ctx, &telemetry.Options{
+			Exporter:       exporter,
+			Endpoint:       viper.GetString("telemetry_endpoint"),
+			ServiceVersion: Version,
+		})
+		if err != nil {
+			return nil, err
+		}
+		shutdownTelemetry = shutdown
+	}
+	return telemetry.Middleware(), nil
+}
+
+// stopTelemetry flushes buffered spans and metrics before the process exits.
+func stopTelemetry() {
+	if shutdownTelemetry == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTelemetry(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to flush telemetry: %v\n", err)
+	}
+	shutdownTelemetry = nil
+}
+
+// instrumentHTTPClient traces the HTTP requests made through client, creating
+// a default client if it is nil.
+func instrumentHTTPClient(client *http.Client) *http.Client {
+	if client == nil {
+		client = &http.Client{}
+	}
+	client.Transport = telemetry.Transport(client.Transport)
+	return client
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/gemini"
+	"github.com/mikesmitty/file-search/internal/usage"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Report the tokens used by queries and their estimated cost",
+}
+
+func init() {
+	rootCmd.AddCommand(usageCmd)
+
+	var since, until, month, by string
+	usageReportCmd := &cobra.Command{
+		Use:   "report",
+		Short: "Summarize query token usage and estimated cost",
+		Long: `Summarize the token usage recorded for every query made by the CLI and the
+MCP server, with cost estimates from the price table. Prices are in US
+dollars per million tokens and can be overridden with usage_prices in the
+config file.
+
+Examples:
+  # Last month's usage per model and store
+  file-search usage report --month 2026-09
+
+  # Daily usage for the last week, as JSON
+  file-search usage report --since 168h --by day,model --format json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := usageLedgerPath()
+			if path == "" {
+				return fmt.Errorf("the usage ledger is disabled (usage_ledger: off)")
+			}
+			groupBy, err := usage.ParseGroupBy(by)
+			if err != nil {
+				return err
+			}
+			start, end, err := reportRange(since, until, month, time.Now())
+			if err != nil {
+				return err
+			}
+			prices, err := priceTable()
+			if err != nil {
+				return err
+			}
+			records, err := usage.Read(path, start, end)
+			if err != nil {
+				return err
+			}
+			report := usage.Summarize(records, groupBy, prices, time.Local)
+			report.Since, report.Until = start, end
+			return printOutput(report, outputFormat)
+		},
+	}
+	usageReportCmd.Flags().StringVar(&since, "since", "", "Only count queries newer than a duration (e.g. 24h) or an RFC 3339 time")
+	usageReportCmd.Flags().StringVar(&until, "until", "", "Only count queries older than a duration (e.g. 24h) or an RFC 3339 time")
+	usageReportCmd.Flags().StringVar(&month, "month", "", "Only count queries in a calendar month (YYYY-MM, local time)")
+	usageReportCmd.Flags().StringVar(&by, "by", "model,store", "Comma-separated grouping: day, month, model and store")
+	usageCmd.AddCommand(usageReportCmd)
+}
+
+// reportRange turns the --since, --until and --month flags into a time range.
+// A zero end means no upper bound.
+func reportRange(since, until, month string, now time.Time) (time.Time, time.Time, error) {
+	if month != "" {
+		if since != "" || until != "" {
+			return time.Time{}, time.Time{}, fmt.Errorf("--month cannot be combined with --since or --until")
+		}
+		start, err := time.ParseInLocation("2006-01", month, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --month: %s (use YYYY-MM)", month)
+		}
+		return start, start.AddDate(0, 1, 0), nil
+	}
+	start, err := parseSince(since, now)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := parseSince(until, now)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid --until: %s (use a duration such as 24h or an RFC 3339 time)", until)
+	}
+	return start, end, nil
+}
+
+// priceTable returns the default prices overridden by usage_prices.
+func priceTable() (usage.PriceTable, error) {
+	var prices usage.PriceTable
+	if err := viper.UnmarshalKey("usage_prices", &prices); err != nil {
+		return nil, fmt.Errorf("invalid usage_prices: %w", err)
+	}
+	return usage.DefaultPrices.Merge(prices), nil
+}
+
+// usageLedgerPath returns the usage ledger's path, or "" if it is off.
+func usageLedgerPath() string {
+	return dataFilePath("usage_ledger", "usage.jsonl")
+}
+
+// usageMiddleware records the token usage of every query to the ledger.
+func usageMiddleware() gemini.Middleware {
+	path := usageLedgerPath()
+	if path == "" {
+		return nil
+	}
+	return usage.New(path).Middleware(&usage.Options{
+		OnError: func(err error) {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		},
+	})
+}
+
+// printUsageReport prints a report as a table with a totals row.
+func printUsageReport(r *usage.Report) {
+	if len(r.Rows) == 0 {
+		fmt.Println("No queries recorded in this period.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := make([]string, 0, len(r.GroupBy)+7)
+	for _, by := range r.GroupBy {
+		header = append(header, strings.ToUpper(by))
+	}
+	header = append(header, "QUERIES", "PROMPT", "CACHED", "TOOL USE", "RESPONSE", "THINKING", "TOTAL", "EST. COST")
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+
+	line := func(keys []string, row *usage.Row) {
+		cost := fmt.Sprintf("$%.4f", row.EstimatedCost)
+		if row.UnpricedQueries == row.Queries {
+			cost = "unpriced"
+		} else if row.UnpricedQueries > 0 {
+			cost += "*"
+		}
+		cells := append(keys, fmt.Sprint(row.Queries), fmt.Sprint(row.PromptTokens), fmt.Sprint(row.CachedTokens), fmt.Sprint(row.ToolUseTokens),
+			fmt.Sprint(row.ResponseTokens), fmt.Sprint(row.ThinkingTokens), fmt.Sprint(row.TotalTokens), cost)
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	for i := range r.Rows {
+		row := &r.Rows[i]
+		keys := make([]string, 0, len(r.GroupBy))
+		for _, by := range r.GroupBy {
+			switch by {
+			case usage.ByDay:
+				keys = append(keys, row.Day)
+			case usage.ByMonth:
+				keys = append(keys, row.Month)
+			case usage.ByModel:
+				keys = append(keys, row.Model)
+			case usage.ByStore:
+				keys = append(keys, cmp.Or(row.Store, "-"))
+			}
+		}
+		line(keys, row)
+	}
+	totalKeys := make([]string, len(r.GroupBy))
+	if len(totalKeys) > 0 {
+		totalKeys[0] = "TOTAL"
+		line(totalKeys, &r.Total)
+	}
+	w.Flush()
+
+	if len(r.UnpricedModels) > 0 {
+		fmt.Printf("\n* Excludes queries to models without a price: %s (set usage_prices in the config file)\n", strings.Join(r.UnpricedModels, ", "))
+	}
+}
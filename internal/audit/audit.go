@@ -0,0 +1,228 @@
+// Package audit records every action that changes stores, files or documents
+// to an append-only JSON Lines log.
+package audit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/gemini"
+	"github.com/mikesmitty/file-search/internal/jsonl"
+	"google.golang.org/genai"
+)
+
+// Outcomes recorded in Entry.Outcome.
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+	// OutcomeDenied is a call the MCP server refused, e.g. by its store access
+	// rules, so the API was never called.
+	OutcomeDenied = "denied"
+)
+
+// Entry is one line of the audit log.
+type Entry struct {
+	Time time.Time `json:"time"`
+	// Actor is who made the change: the OS user for the CLI, or for the MCP
+	// server the bearer token's fingerprint over HTTP, or else the client's name.
+	Actor string `json:"actor"`
+	// Client is the name the MCP client gave, when Actor is its bearer token.
+	Client string `json:"client,omitempty"`
+	Source string `json:"source"`
+	// Tool is the MCP tool that made the change, if any.
+	Tool   string `json:"tool,omitempty"`
+	Action string `json:"action"`
+	// Resources are the resolved names of the stores, files and documents involved.
+	Resources  []string       `json:"resources,omitempty"`
+	Args       map[string]any `json:"args,omitempty"`
+	Outcome    string         `json:"outcome"`
+	Error      string         `json:"error,omitempty"`
+	DurationMs int64          `json:"durationMs"`
+}
+
+// Actor identifies who is making API calls.
+type Actor struct {
+	Name   string
+	Client string
+	// Source is where the calls come from, e.g. "cli" or "mcp".
+	Source string
+	Tool   string
+}
+
+type actorKey struct{}
+
+// WithActor returns a context whose audited calls are attributed to actor.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor stored in ctx by WithActor.
+func ActorFrom(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	return actor, ok
+}
+
+// Log appends entries to a JSON Lines file. It is safe for concurrent use.
+type Log struct {
+	path string
+	mu   sync.Mutex
+}
+
+// New returns a Log that writes to path. The file and its directory are
+// created on the first write.
+func New(path string) *Log {
+	return &Log{path: path}
+}
+
+// Path returns the log file's path.
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append writes e as a single line.
+func (l *Log) Append(e *Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := jsonl.Append(l.path, e); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// Read returns the entries in the log at path recorded at or after since,
+// oldest first. A missing log has no entries, and an unparseable last line,
+// left by an interrupted write, is ignored.
+func Read(path string, since time.Time) ([]Entry, error) {
+	var entries []Entry
+	err := jsonl.Read(path, func(e Entry) {
+		if !e.Time.Before(since) {
+			entries = append(entries, e)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// Options configures Middleware.
+type Options struct {
+	// DefaultActor is used for calls whose context carries no Actor.
+	DefaultActor Actor
+	// OnError is called when an entry can't be written. The audited call
+	// has already run, so its result is returned regardless.
+	OnError func(error)
+	// Now returns the current time (default: time.Now).
+	Now func() time.Time
+}
+
+// Middleware returns a gemini.Middleware that records every create, delete,
+// upload and import to l, whether it succeeds or fails. Reads are not logged.
+func (l *Log) Middleware(opts *Options) gemini.Middleware {
+	if opts == nil {
+		opts = &Options{}
+	}
+	now := opts.Now
+	if now == nil {
+		now = time.Now
+	}
+	return gemini.Intercept(func(ctx context.Context, call *gemini.Call, next func(ctx context.Context) error) error {
+		if !mutating[call.Method] {
+			return next(ctx)
+		}
+		start := now()
+		err := next(ctx)
+
+		actor, ok := ActorFrom(ctx)
+		if !ok {
+			actor = opts.DefaultActor
+		}
+		e := &Entry{
+			Time:       start.UTC(),
+			Actor:      actor.Name,
+			Client:     actor.Client,
+			Source:     actor.Source,
+			Tool:       actor.Tool,
+			Action:     call.Method,
+			Outcome:    OutcomeSuccess,
+			DurationMs: now().Sub(start).Milliseconds(),
+		}
+		e.Resources, e.Args = describe(call)
+		if err != nil {
+			e.Outcome = OutcomeError
+			e.Error = err.Error()
+		}
+		if werr := l.Append(e); werr != nil && opts.OnError != nil {
+			opts.OnError(werr)
+		}
+		return err
+	})
+}
+
+// mutating lists the API methods that change stores, files or documents.
+var mutating = map[string]bool{
+	"CreateStore":    true,
+	"DeleteStore":    true,
+	"UploadFile":     true,
+	"ImportFile":     true,
+	"DeleteFile":     true,
+	"DeleteDocument": true,
+}
+
+// describe extracts the resources and arguments of a mutating call.
+func describe(call *gemini.Call) ([]string, map[string]any) {
+	arg := func(i int) any {
+		if i < len(call.Args) {
+			return call.Args[i]
+		}
+		return nil
+	}
+	str := func(i int) string {
+		s, _ := arg(i).(string)
+		return s
+	}
+
+	switch call.Method {
+	case "CreateStore":
+		var resources []string
+		if store, ok := call.Result.(*genai.FileSearchStore); ok && store != nil {
+			resources = append(resources, store.Name)
+		}
+		return resources, map[string]any{"displayName": str(0)}
+	case "DeleteStore", "DeleteDocument":
+		return []string{str(0)}, map[string]any{"name": str(0), "force": arg(1)}
+	case "DeleteFile":
+		return []string{str(0)}, map[string]any{"name": str(0)}
+	case "ImportFile":
+		return []string{str(0), str(1)}, map[string]any{"file": str(0), "store": str(1)}
+	case "UploadFile":
+		args := map[string]any{"path": str(0)}
+		var resources []string
+		if file, ok := call.Result.(*genai.File); ok && file != nil {
+			resources = append(resources, file.Name)
+		}
+		if opts, ok := arg(1).(*gemini.UploadFileOptions); ok && opts != nil {
+			if opts.StoreName != "" {
+				resources = append(resources, opts.StoreName)
+				args["store"] = opts.StoreName
+			}
+			if opts.DisplayName != "" {
+				args["displayName"] = opts.DisplayName
+			}
+			if opts.MIMEType != "" {
+				args["mimeType"] = opts.MIMEType
+			}
+			if len(opts.Metadata) > 0 {
+				args["metadata"] = opts.Metadata
+			}
+			if opts.MaxChunkTokens > 0 {
+				args["maxChunkTokens"] = opts.MaxChunkTokens
+				args["chunkOverlap"] = opts.ChunkOverlap
+			}
+		}
+		return resources, args
+	}
+	return nil, nil
+}
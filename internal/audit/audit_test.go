@@ -0,0 +1,145 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/gemini"
+	"google.golang.org/genai"
+)
+
+// stubAPI implements the calls the tests make; any other call panics.
+type stubAPI struct {
+	gemini.API
+}
+
+func (stubAPI) CreateStore(ctx context.Context, displayName string) (*genai.FileSearchStore, error) {
+	return &genai.FileSearchStore{Name: "fileSearchStores/kb-1", DisplayName: displayName}, nil
+}
+
+func (stubAPI) DeleteStore(ctx context.Context, name string, force bool) error {
+	if !force {
+		return errors.New("store is not empty")
+	}
+	return nil
+}
+
+func (stubAPI) UploadFile(ctx context.Context, path string, opts *gemini.UploadFileOptions) (*genai.File, error) {
+	return nil, nil
+}
+
+func (stubAPI) ListStores(ctx context.Context) ([]*genai.FileSearchStore, error) {
+	return nil, nil
+}
+
+func TestMiddleware(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "audit.jsonl")
+	clock := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	log := New(path)
+	api := gemini.Chain(stubAPI{}, log.Middleware(&Options{
+		DefaultActor: Actor{Name: "alice", Source: "cli"},
+		Now:          func() time.Time { return clock },
+	}))
+
+	ctx := context.Background()
+	if _, err := api.CreateStore(ctx, "Team KB"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.ListStores(ctx); err != nil {
+		t.Fatal(err)
+	}
+	agent := WithActor(ctx, Actor{Name: "claude-code", Source: "mcp", Tool: "delete_store"})
+	if err := api.DeleteStore(agent, "fileSearchStores/kb-1", false); err == nil {
+		t.Fatal("expected the delete to fail")
+	}
+	if err := api.DeleteStore(agent, "fileSearchStores/kb-1", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.UploadFile(ctx, "/docs/guide.md", &gemini.UploadFileOptions{StoreName: "fileSearchStores/kb-1", Metadata: map[string]string{"team": "infra"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := Read(path, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{
+		{Time: clock, Actor: "alice", Source: "cli", Action: "CreateStore", Resources: []string{"fileSearchStores/kb-1"}, Args: map[string]any{"displayName": "Team KB"}, Outcome: OutcomeSuccess},
+		{Time: clock, Actor: "claude-code", Source: "mcp", Tool: "delete_store", Action: "DeleteStore", Resources: []string{"fileSearchStores/kb-1"}, Args: map[string]any{"name": "fileSearchStores/kb-1", "force": false}, Outcome: OutcomeError, Error: "store is not empty"},
+		{Time: clock, Actor: "claude-code", Source: "mcp", Tool: "delete_store", Action: "DeleteStore", Resources: []string{"fileSearchStores/kb-1"}, Args: map[string]any{"name": "fileSearchStores/kb-1", "force": true}, Outcome: OutcomeSuccess},
+		{Time: clock, Actor: "alice", Source: "cli", Action: "UploadFile", Resources: []string{"fileSearchStores/kb-1"}, Args: map[string]any{"path": "/docs/guide.md", "store": "fileSearchStores/kb-1", "metadata": map[string]any{"team": "infra"}}, Outcome: OutcomeSuccess},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("entries =\n%+v\nwant\n%+v", entries, want)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("audit log mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	if entries, err := Read(path, time.Time{}); err != nil || entries != nil {
+		t.Fatalf("Read of a missing log = %v, %v", entries, err)
+	}
+
+	log := New(path)
+	base := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		if err := log.Append(&Entry{Time: base.Add(time.Duration(i) * time.Hour), Action: "DeleteFile"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := Read(path, base.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || !entries[0].Time.Equal(base.Add(time.Hour)) {
+		t.Errorf("Read since 01:00 = %+v", entries)
+	}
+
+	// A write cut short leaves a partial last line, which is skipped
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"time":"2026-01-02T03:00:00Z","act`)
+	f.Close()
+	if entries, err := Read(path, time.Time{}); err != nil || len(entries) != 3 {
+		t.Errorf("Read with a partial last line = %+v, %v", entries, err)
+	}
+
+	if err := os.WriteFile(path, []byte("{not json}\n{}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(path, time.Time{}); err == nil {
+		t.Error("expected an error for a corrupt entry")
+	}
+}
+
+func TestMiddlewareWriteError(t *testing.T) {
+	dir := t.TempDir()
+	// A directory where the log file should be makes every write fail
+	path := filepath.Join(dir, "audit.jsonl")
+	if err := os.Mkdir(path, 0700); err != nil {
+		t.Fatal(err)
+	}
+	var reported error
+	api := gemini.Chain(stubAPI{}, New(path).Middleware(&Options{OnError: func(err error) { reported = err }}))
+	if _, err := api.CreateStore(context.Background(), "Team KB"); err != nil {
+		t.Fatalf("a failed audit write must not fail the call: %v", err)
+	}
+	if reported == nil {
+		t.Error("OnError was not called")
+	}
+}
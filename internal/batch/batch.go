@@ -0,0 +1,100 @@
+// Package batch runs a function over a list of files with bounded concurrency.
+package batch
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Options provides configuration for batch processing.
+type Options struct {
+	Concurrency int // Number of parallel operations (default: 5)
+	Quiet       bool
+	OnProgress  func(current, total int, file string, err error)
+	// Logger receives a record of each file and the batch totals (default: discarded).
+	Logger *slog.Logger
+}
+
+// Result holds the outcome of a batch processing operation.
+type Result struct {
+	Succeeded []string
+	Failed    map[string]error
+	Total     int
+}
+
+// Process processes a slice of files concurrently.
+// It takes a context, a list of files, a processor function for each file, and batch options.
+// The processor function should return an error if the processing of a single file fails.
+// It returns a Result summarizing the operation.
+func Process(ctx context.Context, files []string, processor func(ctx context.Context, file string) error, opts *Options) *Result {
+	result := &Result{
+		Succeeded: make([]string, 0),
+		Failed:    make(map[string]error),
+		Total:     len(files),
+	}
+
+	if len(files) == 0 {
+		return result
+	}
+
+	if opts == nil {
+		opts = &Options{}
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 5 // Default concurrency
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	logger.Debug("batch started", "files", len(files), "concurrency", opts.Concurrency)
+	batchStart := time.Now()
+
+	var (
+		wg             sync.WaitGroup
+		mu             sync.Mutex // Protects result and processedCount
+		inProgress     = make(chan struct{}, opts.Concurrency)
+		processedCount int
+	)
+
+	for _, file := range files {
+		inProgress <- struct{}{} // Acquire a slot
+
+		wg.Add(1)
+		go func(f string) {
+			defer func() {
+				<-inProgress // Release the slot
+				wg.Done()
+			}()
+
+			logger.Debug("processing file", "file", f)
+			start := time.Now()
+			err := processor(ctx, f)
+			if err != nil {
+				logger.Warn("file failed", "file", f, "elapsed", time.Since(start), "error", err)
+			} else {
+				logger.Debug("file done", "file", f, "elapsed", time.Since(start))
+			}
+
+			mu.Lock()
+			processedCount++
+			current := processedCount
+			if err != nil {
+				result.Failed[f] = err
+			} else {
+				result.Succeeded = append(result.Succeeded, f)
+			}
+			mu.Unlock()
+
+			if opts.OnProgress != nil && !opts.Quiet {
+				opts.OnProgress(current, result.Total, f, err)
+			}
+		}(file)
+	}
+
+	wg.Wait()
+	logger.Info("batch finished", "succeeded", len(result.Succeeded), "failed", len(result.Failed), "elapsed", time.Since(batchStart))
+	return result
+}
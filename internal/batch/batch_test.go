@@ -1,9 +1,12 @@
-package cmd
+package batch
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"log/slog"
 	"sort"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -121,7 +124,7 @@ func TestProcessBatch(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			var progressCalls int32
-			opts := &BatchOptions{
+			opts := &Options{
 				Concurrency: tt.concurrency,
 				Quiet:       false,
 				OnProgress: func(current, total int, file string, err error) {
@@ -132,16 +135,16 @@ func TestProcessBatch(t *testing.T) {
 				},
 			}
 
-			result := processBatch(ctx, tt.files, tt.processor, opts)
+			result := Process(ctx, tt.files, tt.processor, opts)
 
 			if result.Total != len(tt.files) {
-				t.Errorf("processBatch() Total = %v, want %v", result.Total, len(tt.files))
+				t.Errorf("Process() Total = %v, want %v", result.Total, len(tt.files))
 			}
 			if len(result.Succeeded) != tt.wantSucceed {
-				t.Errorf("processBatch() Succeeded = %v, want %v", len(result.Succeeded), tt.wantSucceed)
+				t.Errorf("Process() Succeeded = %v, want %v", len(result.Succeeded), tt.wantSucceed)
 			}
 			if len(result.Failed) != tt.wantFailed {
-				t.Errorf("processBatch() Failed = %v, want %v", len(result.Failed), tt.wantFailed)
+				t.Errorf("Process() Failed = %v, want %v", len(result.Failed), tt.wantFailed)
 			}
 
 			// Check if OnProgress was called for each file if not quiet
@@ -167,7 +170,7 @@ func TestProcessBatch(t *testing.T) {
 			sort.Strings(expectedSucceeded)
 
 			if fmt.Sprintf("%v", sortedSucceeded) != fmt.Sprintf("%v", expectedSucceeded) {
-				t.Errorf("processBatch() Succeeded files = %v, want %v", sortedSucceeded, expectedSucceeded)
+				t.Errorf("Process() Succeeded files = %v, want %v", sortedSucceeded, expectedSucceeded)
 			}
 
 			var sortedFailed []string
@@ -185,7 +188,7 @@ func TestProcessBatch(t *testing.T) {
 			sort.Strings(expectedFailed)
 
 			if fmt.Sprintf("%v", sortedFailed) != fmt.Sprintf("%v", expectedFailed) {
-				t.Errorf("processBatch() Failed files = %v, want %v", sortedFailed, expectedFailed)
+				t.Errorf("Process() Failed files = %v, want %v", sortedFailed, expectedFailed)
 			}
 
 		})
@@ -207,7 +210,7 @@ func TestProcessBatch_ContextCancellation(t *testing.T) {
 	t.Run("cancellation stops pending tasks", func(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
 		var processedCount atomic.Int32
-		opts := &BatchOptions{
+		opts := &Options{
 			Concurrency: 1, // Ensure sequential processing for predictable cancellation
 			OnProgress: func(current, total int, file string, err error) {
 				processedCount.Add(1)
@@ -217,7 +220,7 @@ func TestProcessBatch_ContextCancellation(t *testing.T) {
 			},
 		}
 
-		result := processBatch(ctx, files, slowProcessor, opts)
+		result := Process(ctx, files, slowProcessor, opts)
 
 		// Expect f1 and f2 to be processed (f2 might be cancelled mid-way, or just before returning)
 		// It's hard to precisely predict how many will *succeed* when cancelled
@@ -235,14 +238,14 @@ func TestProcessBatch_ContextCancellation(t *testing.T) {
 		defer cancel() // Ensure context is cancelled eventually, but not prematurely
 
 		var processedCount int32
-		opts := &BatchOptions{
+		opts := &Options{
 			Concurrency: 1,
 			OnProgress: func(current, total int, file string, err error) {
 				atomic.AddInt32(&processedCount, 1)
 			},
 		}
 
-		result := processBatch(ctx, files, slowProcessor, opts)
+		result := Process(ctx, files, slowProcessor, opts)
 
 		if len(result.Succeeded) != len(files) {
 			t.Errorf("Expected all files to succeed, but got %d succeeded", len(result.Succeeded))
@@ -252,3 +255,29 @@ func TestProcessBatch_ContextCancellation(t *testing.T) {
 		}
 	})
 }
+
+func TestProcessBatch_Logger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	processor := func(ctx context.Context, file string) error {
+		if file == "bad.txt" {
+			return fmt.Errorf("upload refused")
+		}
+		return nil
+	}
+
+	Process(context.Background(), []string{"good.txt", "bad.txt"}, processor, &Options{Concurrency: 1, Logger: logger})
+
+	logs := buf.String()
+	for _, want := range []string{
+		`msg="batch started" files=2 concurrency=1`,
+		`msg="file done" file=good.txt`,
+		`level=WARN msg="file failed" file=bad.txt`,
+		`error="upload refused"`,
+		`msg="batch finished" succeeded=1 failed=1`,
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("logs missing %q:\n%s", want, logs)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package completion
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/mikesmitty/file-search/internal/constants"
@@ -9,31 +10,39 @@ import (
 	"github.com/mikesmitty/file-search/internal/gemini"
 )
 
+// ClientFunc creates the API client used for completion.
+type ClientFunc func(ctx context.Context) (gemini.API, error)
+
 // Completer provides completion suggestions for CLI arguments
 type Completer struct {
 	cache      *Cache
-	apiKey     string
+	newClient  ClientFunc
 	enabled    bool
-	client     *gemini.Client
+	client     gemini.API
 	clientInit bool
 }
 
-// NewCompleter creates a new Completer with the specified configuration
-func NewCompleter(apiKey string, enabled bool, cacheTTL time.Duration) *Completer {
+// NewCompleter creates a new Completer with the specified configuration.
+// newClient is called once, on the first lookup that needs the API, so it
+// gets the same backend, network settings and middleware as commands.
+func NewCompleter(newClient ClientFunc, enabled bool, cacheTTL time.Duration) *Completer {
 	return &Completer{
-		cache:   NewCache(cacheTTL),
-		apiKey:  apiKey,
-		enabled: enabled,
+		cache:     NewCache(cacheTTL),
+		newClient: newClient,
+		enabled:   enabled,
 	}
 }
 
 // ensureClient lazily initializes the gemini client
-func (c *Completer) ensureClient(ctx context.Context) (*gemini.Client, error) {
+func (c *Completer) ensureClient(ctx context.Context) (gemini.API, error) {
 	if c.clientInit {
 		return c.client, nil
 	}
 
-	client, err := gemini.NewClient(ctx, c.apiKey, nil)
+	if c.newClient == nil {
+		return nil, errors.New("no client available")
+	}
+	client, err := c.newClient(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -73,10 +82,14 @@ func (c *Completer) GetStoreNames() []string {
 	}
 
 	// Get store names from API
-	names, err := client.GetStoreNames(ctx)
+	stores, err := client.ListStores(ctx)
 	if err != nil {
 		return []string{} // Silent failure
 	}
+	names := make([]string, 0, len(stores))
+	for _, s := range stores {
+		names = append(names, s.DisplayName)
+	}
 
 	// Cache the results
 	c.cache.Set("stores", names)
@@ -107,10 +120,14 @@ func (c *Completer) GetFileNames() []string {
 	}
 
 	// Get file names from API
-	names, err := client.GetFileNames(ctx)
+	files, err := client.ListFiles(ctx)
 	if err != nil {
 		return []string{} // Silent failure
 	}
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, f.DisplayName)
+	}
 
 	// Cache the results
 	c.cache.Set("files", names)
@@ -150,10 +167,14 @@ func (c *Completer) GetDocumentNames(storeRef string) []string {
 	}
 
 	// Get document names from API
-	names, err := client.GetDocumentNames(ctx, storeID)
+	docs, err := client.ListDocuments(ctx, storeID)
 	if err != nil {
 		return []string{} // Silent failure
 	}
+	names := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		names = append(names, doc.DisplayName)
+	}
 
 	// Cache the results
 	c.cache.Set(cacheKey, names)
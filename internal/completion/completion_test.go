@@ -1,59 +1,115 @@
 package completion
 
 import (
+	"context"
+	"errors"
+	"reflect"
 	"testing"
 	"time"
+
+	"github.com/mikesmitty/file-search/internal/gemini"
+	"google.golang.org/genai"
 )
 
+// stubAPI serves fixed listings and counts the calls; any call it does not
+// implement panics.
+type stubAPI struct {
+	gemini.API
+	calls  int
+	closed bool
+}
+
+func (s *stubAPI) ListStores(ctx context.Context) ([]*genai.FileSearchStore, error) {
+	s.calls++
+	return []*genai.FileSearchStore{{Name: "fileSearchStores/a", DisplayName: "Alpha"}, {Name: "fileSearchStores/b", DisplayName: "Beta"}}, nil
+}
+
+func (s *stubAPI) ListFiles(ctx context.Context) ([]*genai.File, error) {
+	s.calls++
+	return []*genai.File{{Name: "files/abc", DisplayName: "notes.md"}}, nil
+}
+
+func (s *stubAPI) ResolveStoreName(ctx context.Context, nameOrID string) (string, error) {
+	if nameOrID != "Alpha" {
+		return "", errors.New("store not found: " + nameOrID)
+	}
+	return "fileSearchStores/a", nil
+}
+
+func (s *stubAPI) ListDocuments(ctx context.Context, storeName string) ([]*genai.Document, error) {
+	s.calls++
+	return []*genai.Document{{Name: storeName + "/documents/d1", DisplayName: "guide.pdf"}}, nil
+}
+
+func (s *stubAPI) Close() {
+	s.closed = true
+}
+
+// stubClient returns a ClientFunc handing out api and counting the calls.
+func stubClient(api gemini.API, calls *int) ClientFunc {
+	return func(ctx context.Context) (gemini.API, error) {
+		*calls++
+		return api, nil
+	}
+}
+
+// unusedClient returns a ClientFunc that fails the test if it is called.
+func unusedClient(t *testing.T) ClientFunc {
+	return func(ctx context.Context) (gemini.API, error) {
+		t.Error("client factory called")
+		return nil, errors.New("unexpected call")
+	}
+}
+
 func TestNewCompleter(t *testing.T) {
 	tests := []struct {
-		name     string
-		apiKey   string
-		enabled  bool
-		cacheTTL time.Duration
+		name      string
+		newClient ClientFunc
+		enabled   bool
+		cacheTTL  time.Duration
 	}{
 		{
-			name:     "with API key and enabled",
-			apiKey:   "test-api-key",
-			enabled:  true,
-			cacheTTL: 5 * time.Minute,
+			name:      "with client and enabled",
+			newClient: unusedClient(t),
+			enabled:   true,
+			cacheTTL:  5 * time.Minute,
 		},
 		{
-			name:     "with API key but disabled",
-			apiKey:   "test-api-key",
-			enabled:  false,
-			cacheTTL: 5 * time.Minute,
+			name:      "with client but disabled",
+			newClient: unusedClient(t),
+			enabled:   false,
+			cacheTTL:  5 * time.Minute,
 		},
 		{
-			name:     "without API key",
-			apiKey:   "",
-			enabled:  true,
-			cacheTTL: 5 * time.Minute,
+			name:      "without client",
+			newClient: nil,
+			enabled:   true,
+			cacheTTL:  5 * time.Minute,
 		},
 		{
-			name:     "custom cache TTL",
-			apiKey:   "test-api-key",
-			enabled:  true,
-			cacheTTL: 10 * time.Minute,
+			name:      "custom cache TTL",
+			newClient: unusedClient(t),
+			enabled:   true,
+			cacheTTL:  10 * time.Minute,
 		},
 		{
-			name:     "zero TTL (should use default)",
-			apiKey:   "test-api-key",
-			enabled:  true,
-			cacheTTL: 0,
+			name:      "zero TTL (should use default)",
+			newClient: unusedClient(t),
+			enabled:   true,
+			cacheTTL:  0,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			completer := NewCompleter(tt.apiKey, tt.enabled, tt.cacheTTL)
+			completer := NewCompleter(tt.newClient, tt.enabled, tt.cacheTTL)
 
 			if completer == nil {
 				t.Fatal("NewCompleter returned nil")
 			}
 
-			if completer.apiKey != tt.apiKey {
-				t.Errorf("Expected apiKey %q, got %q", tt.apiKey, completer.apiKey)
+			if (completer.newClient == nil) != (tt.newClient == nil) {
+				t.Errorf("Expected client factory set %v, got %v", tt.newClient != nil, completer.newClient != nil)
 			}
 
 			if completer.enabled != tt.enabled {
@@ -85,7 +141,7 @@ func TestNewCompleter(t *testing.T) {
 
 func TestCompleterGetModelNames(t *testing.T) {
 	t.Run("returns static list", func(t *testing.T) {
-		completer := NewCompleter("test-key", true, 5*time.Minute)
+		completer := NewCompleter(unusedClient(t), true, 5*time.Minute)
 		models := completer.GetModelNames()
 
 		if len(models) == 0 {
@@ -123,7 +179,7 @@ func TestCompleterGetModelNames(t *testing.T) {
 	})
 
 	t.Run("returns same list when disabled", func(t *testing.T) {
-		completer := NewCompleter("test-key", false, 5*time.Minute)
+		completer := NewCompleter(unusedClient(t), false, 5*time.Minute)
 		models := completer.GetModelNames()
 
 		if len(models) == 0 {
@@ -132,12 +188,12 @@ func TestCompleterGetModelNames(t *testing.T) {
 	})
 
 	t.Run("does not make API calls", func(t *testing.T) {
-		completer := NewCompleter("invalid-key", true, 5*time.Minute)
+		completer := NewCompleter(unusedClient(t), true, 5*time.Minute)
 		models := completer.GetModelNames()
 
-		// Should succeed even with invalid key since it's static
+		// Should succeed without a client since it's static
 		if len(models) == 0 {
-			t.Error("Expected static model list to work with invalid key")
+			t.Error("Expected static model list to work without a client")
 		}
 
 		// Client should not have been initialized
@@ -174,7 +230,7 @@ func TestCompleterDisabledBehavior(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name+" when disabled", func(t *testing.T) {
-			completer := NewCompleter("test-key", false, 5*time.Minute)
+			completer := NewCompleter(unusedClient(t), false, 5*time.Minute)
 			result := tt.method(completer)
 
 			if len(result) != 0 {
@@ -191,7 +247,7 @@ func TestCompleterDisabledBehavior(t *testing.T) {
 
 func TestCompleterGetDocumentNamesEmptyStore(t *testing.T) {
 	t.Run("returns empty when storeRef is empty", func(t *testing.T) {
-		completer := NewCompleter("test-key", true, 5*time.Minute)
+		completer := NewCompleter(unusedClient(t), true, 5*time.Minute)
 		result := completer.GetDocumentNames("")
 
 		if len(result) != 0 {
@@ -200,7 +256,7 @@ func TestCompleterGetDocumentNamesEmptyStore(t *testing.T) {
 	})
 
 	t.Run("returns empty when disabled and empty storeRef", func(t *testing.T) {
-		completer := NewCompleter("test-key", false, 5*time.Minute)
+		completer := NewCompleter(unusedClient(t), false, 5*time.Minute)
 		result := completer.GetDocumentNames("")
 
 		if len(result) != 0 {
@@ -211,7 +267,7 @@ func TestCompleterGetDocumentNamesEmptyStore(t *testing.T) {
 
 func TestCompleterCacheKeyIsolation(t *testing.T) {
 	t.Run("different resource types use different cache keys", func(t *testing.T) {
-		completer := NewCompleter("test-key", true, 5*time.Minute)
+		completer := NewCompleter(unusedClient(t), true, 5*time.Minute)
 
 		// Manually populate cache to test isolation
 		completer.cache.Set("stores", []string{"store1", "store2"})
@@ -236,7 +292,7 @@ func TestCompleterCacheKeyIsolation(t *testing.T) {
 	})
 
 	t.Run("document names cached per store", func(t *testing.T) {
-		completer := NewCompleter("test-key", true, 5*time.Minute)
+		completer := NewCompleter(unusedClient(t), true, 5*time.Minute)
 
 		// Manually populate cache with different stores
 		completer.cache.Set("docs:store1", []string{"doc1", "doc2"})
@@ -262,12 +318,12 @@ func TestCompleterCacheKeyIsolation(t *testing.T) {
 
 func TestCompleterClose(t *testing.T) {
 	t.Run("close when client not initialized", func(t *testing.T) {
-		completer := NewCompleter("test-key", true, 5*time.Minute)
+		completer := NewCompleter(unusedClient(t), true, 5*time.Minute)
 		completer.Close() // Should not panic
 	})
 
 	t.Run("close when client is nil", func(t *testing.T) {
-		completer := NewCompleter("test-key", true, 5*time.Minute)
+		completer := NewCompleter(unusedClient(t), true, 5*time.Minute)
 		completer.client = nil
 		completer.Close() // Should not panic
 	})
@@ -276,7 +332,7 @@ func TestCompleterClose(t *testing.T) {
 func TestCompleterCacheBehavior(t *testing.T) {
 	t.Run("cache respects TTL", func(t *testing.T) {
 		shortTTL := 100 * time.Millisecond
-		completer := NewCompleter("test-key", true, shortTTL)
+		completer := NewCompleter(unusedClient(t), true, shortTTL)
 
 		// Manually set cache entry
 		completer.cache.Set("stores", []string{"store1"})
@@ -296,7 +352,7 @@ func TestCompleterCacheBehavior(t *testing.T) {
 	})
 
 	t.Run("cache stores values correctly", func(t *testing.T) {
-		completer := NewCompleter("test-key", true, 5*time.Minute)
+		completer := NewCompleter(unusedClient(t), true, 5*time.Minute)
 
 		testData := []string{"value1", "value2", "value3"}
 		completer.cache.Set("test-key", testData)
@@ -318,9 +374,54 @@ func TestCompleterCacheBehavior(t *testing.T) {
 	})
 }
 
-// Note: Tests that require actual API calls (GetStoreNames, GetFileNames, GetDocumentNames
-// with real API) should be in integration tests with build tags and require API credentials.
-// These tests focus on the logic we can test without API access:
+func TestCompleterUsesClient(t *testing.T) {
+	api := &stubAPI{}
+	var created int
+	completer := NewCompleter(stubClient(api, &created), true, 5*time.Minute)
+
+	for range 2 {
+		if got, want := completer.GetStoreNames(), []string{"Alpha", "Beta"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("GetStoreNames = %v, want %v", got, want)
+		}
+	}
+	if got, want := completer.GetFileNames(), []string{"notes.md"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetFileNames = %v, want %v", got, want)
+	}
+	if got, want := completer.GetDocumentNames("Alpha"), []string{"guide.pdf"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetDocumentNames = %v, want %v", got, want)
+	}
+	if got := completer.GetDocumentNames("Nope"); len(got) != 0 {
+		t.Errorf("GetDocumentNames of an unknown store = %v, want none", got)
+	}
+
+	if created != 1 {
+		t.Errorf("client factory called %d times, want 1", created)
+	}
+	if api.calls != 3 {
+		t.Errorf("%d API calls, want 3 (store names should be cached)", api.calls)
+	}
+	completer.Close()
+	if !api.closed {
+		t.Error("Close did not close the client")
+	}
+}
+
+func TestCompleterClientError(t *testing.T) {
+	completer := NewCompleter(func(ctx context.Context) (gemini.API, error) {
+		return nil, errors.New("no API key")
+	}, true, 5*time.Minute)
+
+	if got := completer.GetStoreNames(); len(got) != 0 {
+		t.Errorf("GetStoreNames = %v, want none when the client fails", got)
+	}
+	if completer.clientInit {
+		t.Error("Client should not be initialized after a failure")
+	}
+}
+
+// Note: Tests against the real API (GetStoreNames, GetFileNames, GetDocumentNames)
+// should be in integration tests with build tags and require API credentials.
+// These tests use stub clients and focus on:
 // - Initialization
 // - Disabled behavior
 // - Cache interaction
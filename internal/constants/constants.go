@@ -9,6 +9,13 @@ const (
 	FileResourcePrefix      = "files/"
 	DocumentResourcePrefix  = "/documents/"
 	OperationResourcePrefix = "/operations/"
+
+	// Vertex AI Resource Name Segments
+	// On the Vertex backend, stores are RAG corpora named
+	// projects/{project}/locations/{location}/ragCorpora/{corpus}
+	VertexProjectPrefix   = "projects/"
+	VertexLocationSegment = "/locations/"
+	VertexCorpusSegment   = "/ragCorpora/"
 )
 
 // GetModelList returns the list of models known to support file search
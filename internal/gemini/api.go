@@ -0,0 +1,69 @@
+package gemini
+
+import (
+	"context"
+
+	"google.golang.org/genai"
+)
+
+// StoreAPI manages File Search Stores.
+type StoreAPI interface {
+	ListStores(ctx context.Context) ([]*genai.FileSearchStore, error)
+	GetStore(ctx context.Context, name string) (*genai.FileSearchStore, error)
+	CreateStore(ctx context.Context, displayName string) (*genai.FileSearchStore, error)
+	DeleteStore(ctx context.Context, name string, force bool) error
+	ResolveStoreName(ctx context.Context, nameOrID string) (string, error)
+	GetStoreNames(ctx context.Context) ([]string, error)
+}
+
+// FileAPI manages files in the Files API and indexes them into stores.
+type FileAPI interface {
+	ListFiles(ctx context.Context) ([]*genai.File, error)
+	GetFile(ctx context.Context, name string) (*genai.File, error)
+	UploadFile(ctx context.Context, path string, opts *UploadFileOptions) (*genai.File, error)
+	ImportFile(ctx context.Context, fileID, storeID string, opts *ImportFileOptions) error
+	DeleteFile(ctx context.Context, name string) error
+	ResolveFileName(ctx context.Context, nameOrID string) (string, error)
+	GetFileNames(ctx context.Context) ([]string, error)
+}
+
+// DocumentAPI manages the documents indexed in a store.
+type DocumentAPI interface {
+	ListDocuments(ctx context.Context, storeName string) ([]*genai.Document, error)
+	GetDocument(ctx context.Context, name string) (*genai.Document, error)
+	DeleteDocument(ctx context.Context, name string, force bool) error
+	ResolveDocumentName(ctx context.Context, storeNameOrID, docNameOrID string) (string, error)
+	GetDocumentNames(ctx context.Context, storeID string) ([]string, error)
+}
+
+// OperationAPI inspects long-running upload and import operations.
+type OperationAPI interface {
+	GetOperation(ctx context.Context, operationName string, operationType OperationType) (*OperationStatus, error)
+}
+
+// QueryAPI generates grounded answers from stores.
+type QueryAPI interface {
+	Query(ctx context.Context, text string, storeName string, modelName string, metadataFilter string) (*genai.GenerateContentResponse, error)
+}
+
+// ModelAPI lists the models available to the client.
+type ModelAPI interface {
+	ListModels(ctx context.Context) ([]*genai.Model, error)
+}
+
+// API is the complete set of File Search operations. *Client implements it;
+// callers that need caching, auditing or test doubles can supply their own
+// implementation or wrap a Client with Middleware.
+type API interface {
+	StoreAPI
+	FileAPI
+	DocumentAPI
+	OperationAPI
+	QueryAPI
+	ModelAPI
+
+	Backend() Backend
+	Close()
+}
+
+var _ API = (*Client)(nil)
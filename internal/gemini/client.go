@@ -2,8 +2,11 @@ package gemini
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 
@@ -31,27 +34,174 @@ type OperationStatus struct {
 	DocumentName string         `json:"documentName,omitempty"`
 }
 
-type Client struct {
-	client *genai.Client
+// Backend identifies the Google API that serves the client's requests
+type Backend string
+
+const (
+	// BackendGemini is the Gemini Developer API (generativelanguage.googleapis.com)
+	BackendGemini Backend = "gemini"
+	// BackendVertex is Vertex AI, authenticated with Application Default Credentials.
+	// File Search Stores are backed by RAG corpora and only querying is supported.
+	BackendVertex Backend = "vertex"
+)
+
+// ErrUnsupported is returned when the configured backend cannot perform an operation.
+var ErrUnsupported = errors.New("unsupported operation")
+
+// ErrNotFound is returned when a store, file or document name cannot be resolved.
+var ErrNotFound = errors.New("not found")
+
+// notFoundError reports a name that could not be resolved. It matches ErrNotFound.
+type notFoundError string
+
+func (e notFoundError) Error() string        { return string(e) }
+func (e notFoundError) Is(target error) bool { return target == ErrNotFound }
+
+// OperationError is returned when a long-running operation completes with an error,
+// e.g. a document that failed to index.
+type OperationError struct {
+	Name    string
+	Message string
 }
 
-func NewClient(ctx context.Context, apiKey string, httpClient *http.Client) (*Client, error) {
-	if apiKey == "" {
-		return nil, fmt.Errorf("GEMINI_API_KEY not set")
+func (e *OperationError) Error() string {
+	return fmt.Sprintf("operation %s failed: %s", e.Name, e.Message)
+}
+
+// OperationCanceledError is returned when the context is canceled while
+// waiting for a long-running operation. The operation itself keeps running
+// on the server and can be checked later by name.
+type OperationCanceledError struct {
+	Name string
+	Err  error
+}
+
+func (e *OperationCanceledError) Error() string {
+	return fmt.Sprintf("stopped waiting for operation %s: %v (the operation is still running)", e.Name, e.Err)
+}
+
+func (e *OperationCanceledError) Unwrap() error { return e.Err }
+
+// operationErrorMessage extracts the message from an operation's error status.
+func operationErrorMessage(status map[string]any) string {
+	if msg, ok := status["message"].(string); ok {
+		return msg
 	}
+	return fmt.Sprintf("%v", status)
+}
+
+// ClientConfig holds the settings used to construct a Client.
+type ClientConfig struct {
+	Backend  Backend
+	APIKey   string // Gemini API only
+	Project  string // Vertex AI only
+	Location string // Vertex AI only
+	// BaseURL overrides the API endpoint, e.g. a regional endpoint or a local mock server.
+	BaseURL    string
+	HTTPClient *http.Client
+	// PollInterval is how often long-running operations are polled (default: 2s).
+	PollInterval time.Duration
+	// Logger receives upload and import lifecycle logs (default: discarded).
+	Logger *slog.Logger
+}
+
+// defaultPollInterval is the delay between operation status checks.
+const defaultPollInterval = 2 * time.Second
 
-	cfg := &genai.ClientConfig{
+type Client struct {
+	client       *genai.Client
+	backend      Backend
+	project      string
+	location     string
+	pollInterval time.Duration
+	logger       *slog.Logger
+}
+
+// NewClient creates a client for the Gemini Developer API.
+func NewClient(ctx context.Context, apiKey string, httpClient *http.Client) (*Client, error) {
+	return NewClientWithConfig(ctx, &ClientConfig{
+		Backend:    BackendGemini,
 		APIKey:     apiKey,
-		Backend:    genai.BackendGeminiAPI,
 		HTTPClient: httpClient,
+	})
+}
+
+// NewClientWithConfig creates a client for the backend selected in cfg.
+// An empty Backend defaults to the Gemini Developer API.
+func NewClientWithConfig(ctx context.Context, cfg *ClientConfig) (*Client, error) {
+	if cfg == nil {
+		cfg = &ClientConfig{}
+	}
+
+	genaiCfg := &genai.ClientConfig{
+		HTTPClient:  cfg.HTTPClient,
+		HTTPOptions: genai.HTTPOptions{BaseURL: cfg.BaseURL},
+	}
+
+	backend := cfg.Backend
+	switch backend {
+	case "", BackendGemini:
+		backend = BackendGemini
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("GEMINI_API_KEY not set")
+		}
+		genaiCfg.Backend = genai.BackendGeminiAPI
+		genaiCfg.APIKey = cfg.APIKey
+	case BackendVertex:
+		if cfg.Project == "" || cfg.Location == "" {
+			return nil, fmt.Errorf("vertex backend requires a project and location (vertex_project, vertex_location)")
+		}
+		// Credentials come from Application Default Credentials
+		genaiCfg.Backend = genai.BackendVertexAI
+		genaiCfg.Project = cfg.Project
+		genaiCfg.Location = cfg.Location
+		// The SDK only attaches credentials to the HTTP client it creates itself.
+		if cfg.HTTPClient != nil {
+			if err := genaiCfg.UseDefaultCredentials(); err != nil {
+				return nil, fmt.Errorf("failed to load application default credentials: %w", err)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown backend: %s (must be '%s' or '%s')", cfg.Backend, BackendGemini, BackendVertex)
 	}
 
-	client, err := genai.NewClient(ctx, cfg)
+	client, err := genai.NewClient(ctx, genaiCfg)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Client{client: client}, nil
+	pollInterval := cfg.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+
+	return &Client{
+		client:       client,
+		backend:      backend,
+		project:      cfg.Project,
+		location:     cfg.Location,
+		pollInterval: pollInterval,
+		logger:       logger,
+	}, nil
+}
+
+// Backend returns the backend this client talks to.
+func (c *Client) Backend() Backend {
+	return c.backend
+}
+
+// requireGemini returns an ErrUnsupported error if the client is not using the Gemini API.
+// what describes the unavailable feature, e.g. "file uploads".
+func (c *Client) requireGemini(what string) error {
+	if c.backend == BackendGemini {
+		return nil
+	}
+	return fmt.Errorf("%w: %s are not available on the %s backend", ErrUnsupported, what, c.backend)
 }
 
 func (c *Client) Close() {
@@ -59,6 +209,9 @@ func (c *Client) Close() {
 }
 
 func (c *Client) ListStores(ctx context.Context) ([]*genai.FileSearchStore, error) {
+	if err := c.requireGemini("file search stores"); err != nil {
+		return nil, err
+	}
 	resp, err := c.client.FileSearchStores.List(ctx, nil)
 	if err != nil {
 		return nil, err
@@ -98,7 +251,12 @@ func (c *Client) ListModels(ctx context.Context) ([]*genai.Model, error) {
 
 // ResolveStoreName resolves a display name or partial name to a full store resource name.
 // If the input is already a resource name (starts with "fileSearchStores/"), returns it as-is.
+// On the Vertex backend, stores are RAG corpora and are resolved by resolveCorpusName instead.
 func (c *Client) ResolveStoreName(ctx context.Context, nameOrID string) (string, error) {
+	if c.backend == BackendVertex {
+		return c.resolveCorpusName(nameOrID)
+	}
+
 	// If already a resource name, return as-is
 	if strings.HasPrefix(nameOrID, constants.StoreResourcePrefix) {
 		return nameOrID, nil
@@ -116,7 +274,22 @@ func (c *Client) ResolveStoreName(ctx context.Context, nameOrID string) (string,
 		}
 	}
 
-	return "", fmt.Errorf("store not found: %s", nameOrID)
+	return "", notFoundError(fmt.Sprintf("store not found: %s", nameOrID))
+}
+
+// resolveCorpusName maps a RAG corpus reference to its full resource name.
+// Full names ("projects/.../ragCorpora/...") are returned as-is and bare corpus IDs are
+// expanded using the client's project and location. Display names cannot be resolved
+// because the SDK does not expose corpus listing.
+func (c *Client) resolveCorpusName(nameOrID string) (string, error) {
+	if strings.HasPrefix(nameOrID, constants.VertexProjectPrefix) && strings.Contains(nameOrID, constants.VertexCorpusSegment) {
+		return nameOrID, nil
+	}
+	if nameOrID == "" || strings.ContainsAny(nameOrID, "/ ") {
+		return "", fmt.Errorf("%w: resolving corpus display names is not available on the %s backend; use a corpus ID or %s{project}%s{location}%s{corpus}",
+			ErrUnsupported, c.backend, constants.VertexProjectPrefix, constants.VertexLocationSegment, constants.VertexCorpusSegment)
+	}
+	return constants.VertexProjectPrefix + c.project + constants.VertexLocationSegment + c.location + constants.VertexCorpusSegment + nameOrID, nil
 }
 
 // ResolveFileName resolves a file display name to a full file resource name.
@@ -139,7 +312,7 @@ func (c *Client) ResolveFileName(ctx context.Context, nameOrID string) (string,
 		}
 	}
 
-	return "", fmt.Errorf("file not found: %s", nameOrID)
+	return "", notFoundError(fmt.Sprintf("file not found: %s", nameOrID))
 }
 
 // ResolveDocumentName resolves a document display name to a full document resource name.
@@ -169,7 +342,7 @@ func (c *Client) ResolveDocumentName(ctx context.Context, storeNameOrID, docName
 		}
 	}
 
-	return "", fmt.Errorf("document not found in store %s: %s", storeID, docNameOrID)
+	return "", notFoundError(fmt.Sprintf("document not found in store %s: %s", storeID, docNameOrID))
 }
 
 // GetStoreNames returns a list of all store display names for completion.
@@ -215,10 +388,16 @@ func (c *Client) GetDocumentNames(ctx context.Context, storeID string) ([]string
 }
 
 func (c *Client) GetStore(ctx context.Context, name string) (*genai.FileSearchStore, error) {
+	if err := c.requireGemini("file search stores"); err != nil {
+		return nil, err
+	}
 	return c.client.FileSearchStores.Get(ctx, name, nil)
 }
 
 func (c *Client) DeleteStore(ctx context.Context, name string, force bool) error {
+	if err := c.requireGemini("file search stores"); err != nil {
+		return err
+	}
 	// Build optional delete config
 	cfg := &genai.DeleteFileSearchStoreConfig{}
 	if force {
@@ -229,6 +408,9 @@ func (c *Client) DeleteStore(ctx context.Context, name string, force bool) error
 }
 
 func (c *Client) CreateStore(ctx context.Context, displayName string) (*genai.FileSearchStore, error) {
+	if err := c.requireGemini("file search stores"); err != nil {
+		return nil, err
+	}
 	return c.client.FileSearchStores.Create(ctx, &genai.CreateFileSearchStoreConfig{
 		DisplayName: displayName,
 	})
@@ -241,32 +423,44 @@ type UploadFileOptions struct {
 	MaxChunkTokens int
 	ChunkOverlap   int
 	Metadata       map[string]string
-	Quiet          bool
+	// Progress receives upload and indexing events. It may be nil.
+	Progress ProgressReporter
+	// File, if set, is uploaded instead of opening path, which then only
+	// names the upload. UploadFile reads it from the start and does not
+	// close it.
+	File *os.File
 }
 
 type ImportFileOptions struct {
-	Quiet bool
+	// Progress receives import and indexing events. It may be nil.
+	Progress ProgressReporter
 }
 
 // UploadFile uploads a file and optionally indexes it in a store.
 // It returns the created File (if no store) or nil (if store upload, as operation handles it).
 // For store uploads, it polls until completion.
 func (c *Client) UploadFile(ctx context.Context, path string, opts *UploadFileOptions) (*genai.File, error) {
+	if err := c.requireGemini("file uploads"); err != nil {
+		return nil, err
+	}
 	if opts == nil {
 		opts = &UploadFileOptions{}
 	}
 
-	// If storeName is provided, use UploadToFileSearchStoreFromPath (direct)
-	// If not, just UploadFromPath (Files API only)
+	tracker := newProgressTracker(ctx, c.logger, opts.Progress, OperationTypeUpload, path, opts.StoreName)
+	closeFile, reader, mimeType, httpOptions, err := openUpload(path, opts.File, opts.MIMEType, tracker)
+	if err != nil {
+		return nil, tracker.fail(err)
+	}
+	defer closeFile()
+	tracker.report(ProgressEvent{Type: ProgressUploadStarted})
 
+	// With a store, upload and index directly into it; otherwise upload to the Files API only
 	if opts.StoreName != "" {
-		if !opts.Quiet {
-			fmt.Printf("Uploading %s to store %s...\n", path, opts.StoreName)
-		}
-
 		config := &genai.UploadToFileSearchStoreConfig{
 			DisplayName: opts.DisplayName,
-			MIMEType:    opts.MIMEType,
+			MIMEType:    mimeType,
+			HTTPOptions: httpOptions,
 		}
 
 		// Add chunking config if specified
@@ -295,49 +489,46 @@ func (c *Client) UploadFile(ctx context.Context, path string, opts *UploadFileOp
 			}
 		}
 
-		op, err := c.client.FileSearchStores.UploadToFileSearchStoreFromPath(ctx, path, opts.StoreName, config)
+		op, err := c.client.FileSearchStores.UploadToFileSearchStore(ctx, reader, opts.StoreName, config)
 		if err != nil {
-			return nil, err
+			return nil, tracker.fail(err)
 		}
+		tracker.operation = op.Name
+		tracker.report(ProgressEvent{Type: ProgressOperationCreated})
 
-		// Poll with optional progress indicator
-		startTime := time.Now()
-		if !opts.Quiet {
-			fmt.Print("Indexing...")
-		}
 		for !op.Done {
-			if !opts.Quiet {
-				elapsed := time.Since(startTime)
-				fmt.Printf("\rIndexing... (%s elapsed)", elapsed.Round(time.Second))
+			tracker.report(ProgressEvent{Type: ProgressPolling})
+			if err := c.waitPoll(ctx, op.Name); err != nil {
+				return nil, tracker.fail(err)
 			}
-
-			time.Sleep(2 * time.Second)
+			name := op.Name
 			op, err = c.client.Operations.GetUploadToFileSearchStoreOperation(ctx, op, nil)
 			if err != nil {
-				if !opts.Quiet {
-					fmt.Println() // New line before error
-				}
-				return nil, err
+				return nil, tracker.fail(pollError(ctx, name, err))
 			}
 		}
-		if !opts.Quiet {
-			fmt.Println("\n✓ Upload and index complete.")
+		if op.Error != nil {
+			return nil, tracker.fail(&OperationError{Name: op.Name, Message: operationErrorMessage(op.Error)})
+		}
+		done := ProgressEvent{Type: ProgressDone}
+		if op.Response != nil {
+			done.Document = op.Response.DocumentName
 		}
+		tracker.report(done)
 		return nil, nil
 	}
 
-	// Just upload to Files API
+	// Note: metadata and chunking config only apply to store uploads
 	config := &genai.UploadFileConfig{
 		DisplayName: opts.DisplayName,
-		MIMEType:    opts.MIMEType,
+		MIMEType:    mimeType,
+		HTTPOptions: httpOptions,
 	}
-	// Note: metadata might not be supported for Files API uploads
-	// Only chunking config is for store uploads
-
-	res, err := c.client.Files.UploadFromPath(ctx, path, config)
+	res, err := c.client.Files.Upload(ctx, reader, config)
 	if err != nil {
-		return nil, err
+		return nil, tracker.fail(err)
 	}
+	tracker.report(ProgressEvent{Type: ProgressDone, File: res.Name})
 	return res, nil
 }
 
@@ -345,47 +536,71 @@ func (c *Client) UploadFile(ctx context.Context, path string, opts *UploadFileOp
 // fileID should be a file resource name (e.g., "files/abc123").
 // storeID should be a store resource name (e.g., "fileSearchStores/xyz789").
 func (c *Client) ImportFile(ctx context.Context, fileID, storeID string, opts *ImportFileOptions) error {
+	if err := c.requireGemini("file imports"); err != nil {
+		return err
+	}
 	if opts == nil {
 		opts = &ImportFileOptions{}
 	}
 
-	if !opts.Quiet {
-		fmt.Printf("Importing file %s into store %s...\n", fileID, storeID)
-	}
+	tracker := newProgressTracker(ctx, c.logger, opts.Progress, OperationTypeImport, fileID, storeID)
+	tracker.report(ProgressEvent{Type: ProgressUploadStarted, File: fileID})
 
 	op, err := c.client.FileSearchStores.ImportFile(ctx, storeID, fileID, &genai.ImportFileConfig{})
 	if err != nil {
-		return err
+		return tracker.fail(err)
 	}
+	tracker.operation = op.Name
+	tracker.report(ProgressEvent{Type: ProgressOperationCreated, File: fileID})
 
-	// Poll operation until complete with optional progress indicator
-	startTime := time.Now()
-	if !opts.Quiet {
-		fmt.Printf("Operation ID: %s\n", op.Name)
-		fmt.Print("Importing...")
-	}
 	for !op.Done {
-		if !opts.Quiet {
-			elapsed := time.Since(startTime)
-			fmt.Printf("\rImporting... (%s elapsed)", elapsed.Round(time.Second))
+		tracker.report(ProgressEvent{Type: ProgressPolling, File: fileID})
+		if err := c.waitPoll(ctx, op.Name); err != nil {
+			return tracker.fail(err)
 		}
-
-		time.Sleep(2 * time.Second)
+		name := op.Name
 		op, err = c.client.Operations.GetImportFileOperation(ctx, op, nil)
 		if err != nil {
-			if !opts.Quiet {
-				fmt.Println() // New line before error
-			}
-			return err
+			return tracker.fail(pollError(ctx, name, err))
 		}
 	}
-	if !opts.Quiet {
-		fmt.Println("\n✓ Import complete.")
+	if op.Error != nil {
+		return tracker.fail(&OperationError{Name: op.Name, Message: operationErrorMessage(op.Error)})
+	}
+	done := ProgressEvent{Type: ProgressDone, File: fileID}
+	if op.Response != nil {
+		done.Document = op.Response.DocumentName
 	}
+	tracker.report(done)
 	return nil
 }
 
+// waitPoll sleeps for the poll interval, returning early with an
+// OperationCanceledError if ctx is done first.
+func (c *Client) waitPoll(ctx context.Context, operation string) error {
+	t := time.NewTimer(c.pollInterval)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return &OperationCanceledError{Name: operation, Err: ctx.Err()}
+	case <-t.C:
+		return nil
+	}
+}
+
+// pollError reports a failed poll caused by cancellation as an
+// OperationCanceledError, so callers still learn the operation name.
+func pollError(ctx context.Context, operation string, err error) error {
+	if ctx.Err() != nil {
+		return &OperationCanceledError{Name: operation, Err: ctx.Err()}
+	}
+	return err
+}
+
 func (c *Client) ListFiles(ctx context.Context) ([]*genai.File, error) {
+	if err := c.requireGemini("files"); err != nil {
+		return nil, err
+	}
 	resp, err := c.client.Files.List(ctx, nil)
 	if err != nil {
 		return nil, err
@@ -405,10 +620,16 @@ func (c *Client) ListFiles(ctx context.Context) ([]*genai.File, error) {
 }
 
 func (c *Client) GetFile(ctx context.Context, name string) (*genai.File, error) {
+	if err := c.requireGemini("files"); err != nil {
+		return nil, err
+	}
 	return c.client.Files.Get(ctx, name, nil)
 }
 
 func (c *Client) ListDocuments(ctx context.Context, storeName string) ([]*genai.Document, error) {
+	if err := c.requireGemini("documents"); err != nil {
+		return nil, err
+	}
 	resp, err := c.client.FileSearchStores.Documents.List(ctx, storeName, nil)
 	if err != nil {
 		return nil, err
@@ -428,10 +649,16 @@ func (c *Client) ListDocuments(ctx context.Context, storeName string) ([]*genai.
 }
 
 func (c *Client) GetDocument(ctx context.Context, name string) (*genai.Document, error) {
+	if err := c.requireGemini("documents"); err != nil {
+		return nil, err
+	}
 	return c.client.FileSearchStores.Documents.Get(ctx, name, nil)
 }
 
 func (c *Client) DeleteDocument(ctx context.Context, name string, force bool) error {
+	if err := c.requireGemini("documents"); err != nil {
+		return err
+	}
 	cfg := &genai.DeleteDocumentConfig{}
 	if force {
 		cfg.Force = new(bool)
@@ -441,6 +668,9 @@ func (c *Client) DeleteDocument(ctx context.Context, name string, force bool) er
 }
 
 func (c *Client) DeleteFile(ctx context.Context, name string) error {
+	if err := c.requireGemini("files"); err != nil {
+		return err
+	}
 	_, err := c.client.Files.Delete(ctx, name, nil)
 	return err
 }
@@ -448,7 +678,17 @@ func (c *Client) DeleteFile(ctx context.Context, name string) error {
 func (c *Client) Query(ctx context.Context, text string, storeName string, modelName string, metadataFilter string) (*genai.GenerateContentResponse, error) {
 	var config *genai.GenerateContentConfig
 
-	if storeName != "" {
+	if storeName != "" && c.backend == BackendVertex {
+		rag := &genai.VertexRAGStore{
+			RAGResources: []*genai.VertexRAGStoreRAGResource{{RAGCorpus: storeName}},
+		}
+		if metadataFilter != "" {
+			rag.RAGRetrievalConfig = &genai.RAGRetrievalConfig{
+				Filter: &genai.RAGRetrievalConfigFilter{MetadataFilter: metadataFilter},
+			}
+		}
+		config = &genai.GenerateContentConfig{Tools: []*genai.Tool{{Retrieval: &genai.Retrieval{VertexRAGStore: rag}}}}
+	} else if storeName != "" {
 		fs := &genai.FileSearch{FileSearchStoreNames: []string{storeName}}
 		if metadataFilter != "" {
 			fs.MetadataFilter = metadataFilter
@@ -462,6 +702,10 @@ func (c *Client) Query(ctx context.Context, text string, storeName string, model
 // GetOperation retrieves the status of a long-running operation.
 // If operationType is empty, it will try both import and upload types.
 func (c *Client) GetOperation(ctx context.Context, operationName string, operationType OperationType) (*OperationStatus, error) {
+	if err := c.requireGemini("file search operations"); err != nil {
+		return nil, err
+	}
+
 	// Validate operation name format
 	if !strings.HasPrefix(operationName, constants.StoreResourcePrefix) {
 		return nil, fmt.Errorf("invalid operation name: must start with '%s'", constants.StoreResourcePrefix)
@@ -503,11 +747,7 @@ func (c *Client) getImportOperation(ctx context.Context, operationName string) (
 
 	if result.Error != nil {
 		status.Failed = true
-		if msg, ok := result.Error["message"].(string); ok {
-			status.ErrorMessage = msg
-		} else {
-			status.ErrorMessage = fmt.Sprintf("%v", result.Error)
-		}
+		status.ErrorMessage = operationErrorMessage(result.Error)
 	}
 
 	if result.Response != nil {
@@ -534,11 +774,7 @@ func (c *Client) getUploadOperation(ctx context.Context, operationName string) (
 
 	if result.Error != nil {
 		status.Failed = true
-		if msg, ok := result.Error["message"].(string); ok {
-			status.ErrorMessage = msg
-		} else {
-			status.ErrorMessage = fmt.Sprintf("%v", result.Error)
-		}
+		status.ErrorMessage = operationErrorMessage(result.Error)
 	}
 
 	if result.Response != nil {
@@ -1,7 +1,18 @@
 package gemini
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -655,3 +666,204 @@ func TestOperationStatusFailedState(t *testing.T) {
 		})
 	}
 }
+
+func TestNewClientWithConfigValidation(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		cfg     *ClientConfig
+		wantErr string
+	}{
+		{
+			name:    "gemini without api key",
+			cfg:     &ClientConfig{Backend: BackendGemini},
+			wantErr: "GEMINI_API_KEY not set",
+		},
+		{
+			name:    "default backend without api key",
+			cfg:     &ClientConfig{},
+			wantErr: "GEMINI_API_KEY not set",
+		},
+		{
+			name:    "vertex without project",
+			cfg:     &ClientConfig{Backend: BackendVertex, Location: "us-central1"},
+			wantErr: "requires a project and location",
+		},
+		{
+			name:    "unknown backend",
+			cfg:     &ClientConfig{Backend: "bedrock"},
+			wantErr: "unknown backend",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClientWithConfig(ctx, tt.cfg)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %q", tt.wantErr, err.Error())
+			}
+		})
+	}
+}
+
+// fakeCredentials points Application Default Credentials at a service account
+// whose tokens come from tokenURL.
+func fakeCredentials(t *testing.T, tokenURL string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds, err := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"project_id":   "my-project",
+		"client_email": "test@my-project.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		"token_uri":    tokenURL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	if err := os.WriteFile(path, creds, 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", path)
+}
+
+// newVertexTestClient creates a Vertex client with credentials that are never used.
+func newVertexTestClient(t *testing.T) *Client {
+	t.Helper()
+	fakeCredentials(t, "http://127.0.0.1:0/token")
+	client, err := NewClientWithConfig(context.Background(), &ClientConfig{
+		Backend:    BackendVertex,
+		Project:    "my-project",
+		Location:   "europe-west4",
+		BaseURL:    "http://127.0.0.1:0/",
+		HTTPClient: &http.Client{},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create vertex client: %v", err)
+	}
+	return client
+}
+
+func TestVertexUnsupportedOperations(t *testing.T) {
+	client := newVertexTestClient(t)
+	ctx := context.Background()
+
+	if client.Backend() != BackendVertex {
+		t.Fatalf("expected backend %s, got %s", BackendVertex, client.Backend())
+	}
+
+	calls := map[string]func() error{
+		"ListStores": func() error { _, err := client.ListStores(ctx); return err },
+		"CreateStore": func() error {
+			_, err := client.CreateStore(ctx, "test")
+			return err
+		},
+		"DeleteStore":    func() error { return client.DeleteStore(ctx, "x", false) },
+		"ListFiles":      func() error { _, err := client.ListFiles(ctx); return err },
+		"DeleteFile":     func() error { return client.DeleteFile(ctx, "files/x") },
+		"ListDocuments":  func() error { _, err := client.ListDocuments(ctx, "x"); return err },
+		"DeleteDocument": func() error { return client.DeleteDocument(ctx, "x", false) },
+		"UploadFile": func() error {
+			_, err := client.UploadFile(ctx, "/tmp/x", nil)
+			return err
+		},
+		"ImportFile": func() error { return client.ImportFile(ctx, "files/x", "y", nil) },
+		"GetOperation": func() error {
+			_, err := client.GetOperation(ctx, "fileSearchStores/a/operations/b", "")
+			return err
+		},
+	}
+
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			err := call()
+			if !errors.Is(err, ErrUnsupported) {
+				t.Errorf("expected ErrUnsupported, got %v", err)
+			}
+			if err != nil && !strings.Contains(err.Error(), "vertex") {
+				t.Errorf("expected error to name the backend, got %q", err.Error())
+			}
+		})
+	}
+}
+
+func TestVertexResolveCorpusName(t *testing.T) {
+	client := newVertexTestClient(t)
+	ctx := context.Background()
+
+	full := "projects/other/locations/us-east1/ragCorpora/123"
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "full resource name", input: full, want: full},
+		{name: "bare corpus id", input: "456", want: "projects/my-project/locations/europe-west4/ragCorpora/456"},
+		{name: "display name", input: "My Research Store", wantErr: true},
+		{name: "gemini store name", input: "fileSearchStores/abc", wantErr: true},
+		{name: "empty", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.ResolveStoreName(ctx, tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsupported) {
+					t.Errorf("expected ErrUnsupported, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVertexCustomHTTPClientSendsCredentials(t *testing.T) {
+	var gotAuth string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"access_token":"adc-token","token_type":"Bearer","expires_in":3600}`)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]}}]}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	fakeCredentials(t, srv.URL+"/token")
+
+	// A base URL override (a proxy, say) still needs the credentials attached
+	client, err := NewClientWithConfig(context.Background(), &ClientConfig{
+		Backend:    BackendVertex,
+		Project:    "my-project",
+		Location:   "europe-west4",
+		BaseURL:    srv.URL + "/",
+		HTTPClient: &http.Client{},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create vertex client: %v", err)
+	}
+	if _, err := client.Query(context.Background(), "hello", "", "gemini-2.5-flash", ""); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if gotAuth != "Bearer adc-token" {
+		t.Errorf("Authorization = %q, want %q", gotAuth, "Bearer adc-token")
+	}
+}
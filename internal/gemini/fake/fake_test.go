@@ -0,0 +1,382 @@
+package fake_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/gemini"
+	"github.com/mikesmitty/file-search/internal/gemini/fake"
+	"google.golang.org/genai"
+)
+
+func newTestClient(t *testing.T, opts *fake.Options) (*gemini.Client, *fake.Server) {
+	t.Helper()
+	srv := fake.NewServer(opts)
+	t.Cleanup(srv.Close)
+
+	client, err := gemini.NewClientWithConfig(context.Background(), &gemini.ClientConfig{
+		APIKey:       "test-key",
+		BaseURL:      srv.URL,
+		PollInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return client, srv
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestStoreLifecycle(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestClient(t, &fake.Options{PageSize: 2})
+
+	for _, name := range []string{"Alpha", "Beta", "Gamma"} {
+		if _, err := client.CreateStore(ctx, name); err != nil {
+			t.Fatalf("CreateStore(%s) failed: %v", name, err)
+		}
+	}
+
+	// Page size 2 forces the client to follow nextPageToken
+	stores, err := client.ListStores(ctx)
+	if err != nil {
+		t.Fatalf("ListStores failed: %v", err)
+	}
+	if len(stores) != 3 {
+		t.Fatalf("expected 3 stores, got %d", len(stores))
+	}
+
+	name, err := client.ResolveStoreName(ctx, "Beta")
+	if err != nil {
+		t.Fatalf("ResolveStoreName failed: %v", err)
+	}
+	store, err := client.GetStore(ctx, name)
+	if err != nil {
+		t.Fatalf("GetStore failed: %v", err)
+	}
+	if store.DisplayName != "Beta" || store.CreateTime.IsZero() {
+		t.Errorf("unexpected store: %+v", store)
+	}
+
+	if err := client.DeleteStore(ctx, name, false); err != nil {
+		t.Fatalf("DeleteStore failed: %v", err)
+	}
+	_, err = client.GetStore(ctx, name)
+	var apiErr genai.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != 404 {
+		t.Errorf("expected 404 after delete, got %v", err)
+	}
+}
+
+func TestUploadAndQuery(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestClient(t, &fake.Options{IndexingDelay: 30 * time.Millisecond})
+
+	store, err := client.CreateStore(ctx, "Handbook")
+	if err != nil {
+		t.Fatalf("CreateStore failed: %v", err)
+	}
+
+	path := writeFile(t, "vacation.txt", "Employees accrue vacation days monthly. Unused vacation rolls over once per year.")
+	_, err = client.UploadFile(ctx, path, &gemini.UploadFileOptions{
+		StoreName:   store.Name,
+		DisplayName: "vacation.txt",
+		Metadata:    map[string]string{"team": "hr"},
+	})
+	if err != nil {
+		t.Fatalf("UploadFile failed: %v", err)
+	}
+	path = writeFile(t, "deploy.md", "Deployments run every Tuesday after the release train is cut.")
+	_, err = client.UploadFile(ctx, path, &gemini.UploadFileOptions{
+		StoreName: store.Name,
+		Metadata:  map[string]string{"team": "eng"},
+	})
+	if err != nil {
+		t.Fatalf("UploadFile failed: %v", err)
+	}
+
+	got, err := client.GetStore(ctx, store.Name)
+	if err != nil {
+		t.Fatalf("GetStore failed: %v", err)
+	}
+	if got.ActiveDocumentsCount != 2 || got.SizeBytes == 0 {
+		t.Errorf("expected 2 active documents with size, got %+v", got)
+	}
+
+	docs, err := client.ListDocuments(ctx, store.Name)
+	if err != nil {
+		t.Fatalf("ListDocuments failed: %v", err)
+	}
+	if len(docs) != 2 || docs[0].DisplayName != "vacation.txt" || docs[0].State != genai.DocumentStateActive {
+		t.Fatalf("unexpected documents: %+v", docs)
+	}
+	if len(docs[0].CustomMetadata) != 1 || docs[0].CustomMetadata[0].StringValue != "hr" {
+		t.Errorf("expected custom metadata to round-trip, got %+v", docs[0].CustomMetadata)
+	}
+
+	resp, err := client.Query(ctx, "How do vacation days work?", store.Name, "gemini-2.5-flash", "")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	gm := resp.Candidates[0].GroundingMetadata
+	if gm == nil || len(gm.GroundingChunks) != 1 {
+		t.Fatalf("expected one grounding chunk, got %+v", gm)
+	}
+	if title := gm.GroundingChunks[0].RetrievedContext.Title; title != "vacation.txt" {
+		t.Errorf("expected chunk from vacation.txt, got %s", title)
+	}
+	if len(gm.GroundingSupports) != 1 || gm.GroundingSupports[0].Segment.EndIndex != int32(len(resp.Text())) {
+		t.Errorf("expected a support spanning the answer, got %+v", gm.GroundingSupports)
+	}
+	if resp.UsageMetadata == nil || resp.UsageMetadata.TotalTokenCount == 0 {
+		t.Errorf("expected usage metadata, got %+v", resp.UsageMetadata)
+	}
+
+	t.Run("metadata filter", func(t *testing.T) {
+		resp, err := client.Query(ctx, "vacation deployments", store.Name, "gemini-2.5-flash", `team = "eng"`)
+		if err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		chunks := resp.Candidates[0].GroundingMetadata.GroundingChunks
+		if len(chunks) != 1 || chunks[0].RetrievedContext.Title != "deploy.md" {
+			t.Errorf("expected only deploy.md, got %+v", chunks)
+		}
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		_, err := client.Query(ctx, "vacation", store.Name, "gemini-2.5-flash", `team ~ eng`)
+		if err == nil || !strings.Contains(err.Error(), "invalid metadata filter") {
+			t.Errorf("expected invalid filter error, got %v", err)
+		}
+	})
+
+	t.Run("delete document requires force", func(t *testing.T) {
+		if err := client.DeleteDocument(ctx, docs[0].Name, false); err == nil {
+			t.Fatal("expected non-forced delete of an indexed document to fail")
+		}
+		if err := client.DeleteDocument(ctx, docs[0].Name, true); err != nil {
+			t.Fatalf("forced DeleteDocument failed: %v", err)
+		}
+		if err := client.DeleteStore(ctx, store.Name, false); err == nil {
+			t.Fatal("expected non-forced delete of a non-empty store to fail")
+		}
+		if err := client.DeleteStore(ctx, store.Name, true); err != nil {
+			t.Fatalf("forced DeleteStore failed: %v", err)
+		}
+	})
+}
+
+func TestFilesAndImport(t *testing.T) {
+	ctx := context.Background()
+	client, srv := newTestClient(t, nil)
+
+	path := writeFile(t, "notes.txt", "Quarterly planning notes")
+	file, err := client.UploadFile(ctx, path, &gemini.UploadFileOptions{DisplayName: "notes.txt"})
+	if err != nil {
+		t.Fatalf("UploadFile failed: %v", err)
+	}
+	if file.SizeBytes == nil || *file.SizeBytes != int64(len("Quarterly planning notes")) || file.State != genai.FileStateActive {
+		t.Fatalf("unexpected file: %+v", file)
+	}
+
+	resolved, err := client.ResolveFileName(ctx, "notes.txt")
+	if err != nil || resolved != file.Name {
+		t.Fatalf("ResolveFileName = %s, %v; want %s", resolved, err, file.Name)
+	}
+
+	store, err := client.CreateStore(ctx, "Imports")
+	if err != nil {
+		t.Fatalf("CreateStore failed: %v", err)
+	}
+	if err := client.ImportFile(ctx, file.Name, store.Name, nil); err != nil {
+		t.Fatalf("ImportFile failed: %v", err)
+	}
+	srv.WaitIdle()
+
+	docs, err := client.ListDocuments(ctx, store.Name)
+	if err != nil || len(docs) != 1 || docs[0].DisplayName != "notes.txt" {
+		t.Fatalf("expected imported document, got %+v, %v", docs, err)
+	}
+
+	if err := client.DeleteFile(ctx, file.Name); err != nil {
+		t.Fatalf("DeleteFile failed: %v", err)
+	}
+	if _, err := client.GetFile(ctx, file.Name); err == nil {
+		t.Error("expected GetFile to fail after delete")
+	}
+}
+
+func TestOperations(t *testing.T) {
+	ctx := context.Background()
+	client, srv := newTestClient(t, &fake.Options{
+		FailIndexing: func(displayName string) bool { return displayName == "broken.txt" },
+	})
+
+	store, err := client.CreateStore(ctx, "Ops")
+	if err != nil {
+		t.Fatalf("CreateStore failed: %v", err)
+	}
+	path := writeFile(t, "broken.txt", "this document fails to index")
+	file, err := client.UploadFile(ctx, path, &gemini.UploadFileOptions{DisplayName: "broken.txt"})
+	if err != nil {
+		t.Fatalf("UploadFile failed: %v", err)
+	}
+	err = client.ImportFile(ctx, file.Name, store.Name, nil)
+	var opErr *gemini.OperationError
+	if !errors.As(err, &opErr) || !strings.Contains(opErr.Message, "broken.txt") {
+		t.Fatalf("expected an OperationError for the failed import, got %v", err)
+	}
+	srv.WaitIdle()
+
+	docs, err := client.ListDocuments(ctx, store.Name)
+	if err != nil || len(docs) != 1 {
+		t.Fatalf("expected one document, got %+v, %v", docs, err)
+	}
+	if docs[0].State != genai.DocumentStateFailed {
+		t.Errorf("expected failed document, got %s", docs[0].State)
+	}
+
+	ops := srv.Operations()
+	if len(ops) != 1 {
+		t.Fatalf("expected one operation, got %v", ops)
+	}
+	status, err := client.GetOperation(ctx, ops[0], "")
+	if err != nil {
+		t.Fatalf("GetOperation failed: %v", err)
+	}
+	if !status.Done || !status.Failed || !strings.Contains(status.ErrorMessage, "broken.txt") {
+		t.Errorf("expected failed operation, got %+v", status)
+	}
+
+	if _, err := client.GetOperation(ctx, store.Name+"/operations/missing", ""); err == nil {
+		t.Error("expected error for missing operation")
+	}
+}
+
+func TestUploadOperation(t *testing.T) {
+	ctx := context.Background()
+	client, srv := newTestClient(t, nil)
+
+	store, err := client.CreateStore(ctx, "Uploads")
+	if err != nil {
+		t.Fatalf("CreateStore failed: %v", err)
+	}
+	path := writeFile(t, "guide.md", "# Guide")
+	if _, err := client.UploadFile(ctx, path, &gemini.UploadFileOptions{StoreName: store.Name}); err != nil {
+		t.Fatalf("UploadFile failed: %v", err)
+	}
+
+	ops := srv.Operations()
+	if len(ops) != 1 || !strings.Contains(ops[0], "/upload/operations/") {
+		t.Fatalf("expected one upload operation, got %v", ops)
+	}
+	status, err := client.GetOperation(ctx, ops[0], gemini.OperationTypeUpload)
+	if err != nil {
+		t.Fatalf("GetOperation failed: %v", err)
+	}
+	if !status.Done || status.Failed || status.Parent != store.Name || !strings.HasPrefix(status.DocumentName, store.Name+"/documents/") {
+		t.Errorf("unexpected operation status: %+v", status)
+	}
+}
+
+func TestAPIKeyRequired(t *testing.T) {
+	srv := fake.NewServer(&fake.Options{APIKey: "expected"})
+	defer srv.Close()
+
+	client, err := gemini.NewClientWithConfig(context.Background(), &gemini.ClientConfig{APIKey: "wrong", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	_, err = client.ListStores(context.Background())
+	var apiErr genai.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != 401 {
+		t.Errorf("expected 401, got %v", err)
+	}
+}
+
+func TestListModels(t *testing.T) {
+	client, _ := newTestClient(t, nil)
+	models, err := client.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+	if len(models) == 0 || !strings.HasPrefix(models[0].Name, "models/") {
+		t.Fatalf("unexpected models: %+v", models)
+	}
+	if len(models[0].SupportedActions) == 0 {
+		t.Errorf("expected supported actions, got %+v", models[0])
+	}
+}
+
+func TestConcurrentUploadChunks(t *testing.T) {
+	srv := fake.NewServer(nil)
+	t.Cleanup(srv.Close)
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/upload/v1beta/files", strings.NewReader(`{"file":{"displayName":"race.txt"}}`))
+	req.Header.Set("X-Goog-Api-Key", "test-key")
+	req.Header.Set("X-Goog-Upload-Command", "start")
+	req.Header.Set("X-Goog-Upload-Header-Content-Type", "text/plain")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	sessionURL := resp.Header.Get("X-Goog-Upload-Url")
+	if sessionURL == "" {
+		t.Fatalf("no upload URL in start response (status %d)", resp.StatusCode)
+	}
+
+	// Chunks racing for the same offset: exactly one may be accepted. Their
+	// bodies are held back so the requests overlap on the server.
+	const chunks = 8
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	var accepted atomic.Int32
+	for range chunks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body, w := io.Pipe()
+			go func() {
+				<-release
+				w.Write([]byte("chunk"))
+				w.Close()
+			}()
+			req, _ := http.NewRequest(http.MethodPost, sessionURL, body)
+			req.Header.Set("X-Goog-Api-Key", "test-key")
+			req.Header.Set("X-Goog-Upload-Command", "upload")
+			req.Header.Set("X-Goog-Upload-Offset", "0")
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				accepted.Add(1)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := accepted.Load(); n != 1 {
+		t.Errorf("%d of %d racing chunks accepted, want 1", n, chunks)
+	}
+}
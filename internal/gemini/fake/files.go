@@ -0,0 +1,258 @@
+package fake
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/constants"
+	"google.golang.org/genai"
+)
+
+// fileRetention mirrors the Files API's 48 hour expiry.
+const fileRetention = 48 * time.Hour
+
+// file is an uploaded Files API file.
+type file struct {
+	seq     int
+	info    genai.File
+	content []byte
+}
+
+// uploadSession tracks a resumable upload between its start and finalize requests.
+type uploadSession struct {
+	// store is the target File Search Store, or "" for a Files API upload.
+	store       string
+	fileName    string
+	displayName string
+	mimeType    string
+	metadata    []*genai.CustomMetadata
+
+	// mu serializes the session's chunks, so each offset check and write
+	// happen together and only one request finalizes it.
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	finalized bool
+}
+
+func (s *Server) listFiles(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := sortedKeys(s.files, func(f *file) int { return f.seq })
+	start, end, next, err := s.paginate(r, len(keys))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", err.Error())
+		return
+	}
+
+	items := make([]*genai.File, 0, end-start)
+	for _, k := range keys[start:end] {
+		info := s.files[k].info
+		items = append(items, &info)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"files":         items,
+		"nextPageToken": next,
+	})
+}
+
+func (s *Server) getFile(w http.ResponseWriter, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.files[name]
+	if !ok {
+		notFound(w, name)
+		return
+	}
+	info := f.info
+	writeJSON(w, http.StatusOK, &info)
+}
+
+func (s *Server) deleteFile(w http.ResponseWriter, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.files[name]; !ok {
+		notFound(w, name)
+		return
+	}
+	delete(s.files, name)
+	writeJSON(w, http.StatusOK, map[string]any{})
+}
+
+// handleUploadStart begins a resumable upload for either the Files API
+// ("files") or a File Search Store ("fileSearchStores/{id}:uploadToFileSearchStore").
+func (s *Server) handleUploadStart(w http.ResponseWriter, r *http.Request, path string) {
+	if r.Method != http.MethodPost || r.Header.Get("X-Goog-Upload-Command") != "start" {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "expected a resumable upload start request")
+		return
+	}
+
+	session := &uploadSession{
+		fileName: r.Header.Get("X-Goog-Upload-File-Name"),
+		mimeType: r.Header.Get("X-Goog-Upload-Header-Content-Type"),
+	}
+
+	switch {
+	case path == "files":
+		var req struct {
+			File struct {
+				DisplayName string `json:"displayName"`
+				MIMEType    string `json:"mimeType"`
+			} `json:"file"`
+		}
+		if err := decodeBody(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "invalid request body: "+err.Error())
+			return
+		}
+		session.displayName = req.File.DisplayName
+		if req.File.MIMEType != "" {
+			session.mimeType = req.File.MIMEType
+		}
+	case strings.HasSuffix(path, ":uploadToFileSearchStore"):
+		var req struct {
+			DisplayName    string                  `json:"displayName"`
+			MIMEType       string                  `json:"mimeType"`
+			CustomMetadata []*genai.CustomMetadata `json:"customMetadata"`
+		}
+		if err := decodeBody(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "invalid request body: "+err.Error())
+			return
+		}
+		session.store = strings.TrimSuffix(path, ":uploadToFileSearchStore")
+		session.displayName = req.DisplayName
+		session.metadata = req.CustomMetadata
+		if req.MIMEType != "" {
+			session.mimeType = req.MIMEType
+		}
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "unknown upload path: "+path)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session.store != "" {
+		if _, ok := s.stores[session.store]; !ok {
+			notFound(w, session.store)
+			return
+		}
+	}
+
+	s.seq++
+	id := strconv.Itoa(s.seq)
+	s.uploads[id] = session
+
+	w.Header().Set("X-Goog-Upload-Url", "http://"+r.Host+"/upload/sessions/"+id)
+	w.Header().Set("X-Goog-Upload-Status", "active")
+	writeJSON(w, http.StatusOK, map[string]any{})
+}
+
+// handleUploadChunk appends a chunk to an upload session and, on finalize,
+// creates the file or starts the indexing operation.
+func (s *Server) handleUploadChunk(w http.ResponseWriter, r *http.Request, id string) {
+	// The SDK retries chunks that come back without an upload status, so every
+	// response (including errors) carries one.
+	command := r.Header.Get("X-Goog-Upload-Command")
+	finalize := strings.Contains(command, "finalize")
+
+	s.mu.Lock()
+	session, ok := s.uploads[id]
+	s.mu.Unlock()
+	if ok {
+		session.mu.Lock()
+		defer session.mu.Unlock()
+		ok = !session.finalized
+	}
+	if !ok {
+		w.Header().Set("X-Goog-Upload-Status", "cancelled")
+		notFound(w, "upload session "+id)
+		return
+	}
+
+	offset, err := strconv.Atoi(r.Header.Get("X-Goog-Upload-Offset"))
+	if err != nil || offset != session.buf.Len() {
+		w.Header().Set("X-Goog-Upload-Status", "cancelled")
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", fmt.Sprintf("unexpected upload offset %q, want %d", r.Header.Get("X-Goog-Upload-Offset"), session.buf.Len()))
+		return
+	}
+	if _, err := io.Copy(&session.buf, r.Body); err != nil {
+		w.Header().Set("X-Goog-Upload-Status", "cancelled")
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "failed to read upload chunk: "+err.Error())
+		return
+	}
+
+	if !finalize {
+		w.Header().Set("X-Goog-Upload-Status", "active")
+		writeJSON(w, http.StatusOK, map[string]any{})
+		return
+	}
+
+	session.finalized = true
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.uploads, id)
+	w.Header().Set("X-Goog-Upload-Status", "final")
+
+	if session.store == "" {
+		f := s.addFile(session)
+		info := f.info
+		writeJSON(w, http.StatusOK, map[string]any{"file": &info})
+		return
+	}
+
+	st, ok := s.stores[session.store]
+	if !ok {
+		notFound(w, session.store)
+		return
+	}
+	// Documents uploaded without a display name are named after the uploaded file
+	displayName := session.displayName
+	if displayName == "" {
+		displayName = session.fileName
+	}
+	doc := s.addDocument(st, displayName, session.mimeType, session.metadata, session.buf.Bytes())
+	op := s.startOperation(st.info.Name+"/upload"+constants.OperationResourcePrefix, uploadResponseType, st, doc)
+	writeJSON(w, http.StatusOK, op)
+}
+
+// addFile stores a finished Files API upload. The caller must hold s.mu.
+func (s *Server) addFile(session *uploadSession) *file {
+	content := bytes.Clone(session.buf.Bytes())
+	size := int64(len(content))
+	sum := sha256.Sum256(content)
+	now := timestamp()
+
+	s.seq++
+	id := fmt.Sprintf("%012d", s.seq)
+	name := constants.FileResourcePrefix + id
+	f := &file{
+		seq: s.seq,
+		info: genai.File{
+			Name:           name,
+			DisplayName:    session.displayName,
+			MIMEType:       session.mimeType,
+			SizeBytes:      &size,
+			CreateTime:     now,
+			UpdateTime:     now,
+			ExpirationTime: now.Add(fileRetention),
+			Sha256Hash:     base64.StdEncoding.EncodeToString(sum[:]),
+			URI:            s.URL + "/" + apiVersion + "/" + name,
+			State:          genai.FileStateActive,
+			Source:         genai.FileSourceUploaded,
+		},
+		content: content,
+	}
+	s.files[name] = f
+	return f
+}
@@ -0,0 +1,433 @@
+package fake
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"google.golang.org/genai"
+)
+
+const (
+	// defaultChunkWords is the number of words per retrieval chunk.
+	defaultChunkWords = 100
+	// maxGroundingChunks caps the chunks returned per query, like the API's top-k.
+	maxGroundingChunks = 5
+	// maxCitedChunks is how many retrieved chunks the fake answer quotes.
+	maxCitedChunks = 3
+	// snippetWords is the length of each quoted snippet in the answer.
+	snippetWords = 20
+)
+
+// stopWords are ignored when matching query terms against chunks.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"by": true, "does": true, "for": true, "from": true, "how": true, "in": true, "is": true,
+	"it": true, "of": true, "on": true, "or": true, "the": true, "to": true, "what": true,
+	"when": true, "where": true, "which": true, "who": true, "why": true, "with": true,
+}
+
+type generateRequest struct {
+	Contents []*genai.Content `json:"contents"`
+	Tools    []struct {
+		FileSearch *struct {
+			FileSearchStoreNames []string `json:"fileSearchStoreNames"`
+			MetadataFilter       string   `json:"metadataFilter"`
+		} `json:"fileSearch"`
+	} `json:"tools"`
+}
+
+// retrievedContext mirrors the API's retrievedContext, which carries the source
+// store name in addition to the fields exposed by the SDK.
+type retrievedContext struct {
+	Title           string `json:"title,omitempty"`
+	Text            string `json:"text,omitempty"`
+	FileSearchStore string `json:"fileSearchStore,omitempty"`
+}
+
+type groundingChunk struct {
+	RetrievedContext *retrievedContext `json:"retrievedContext"`
+}
+
+type hit struct {
+	store string
+	doc   *document
+	chunk string
+	score int
+}
+
+func (s *Server) listModels(w http.ResponseWriter, r *http.Request) {
+	models := modelList()
+	start, end, next, err := s.paginate(r, len(models))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"models":        models[start:end],
+		"nextPageToken": next,
+	})
+}
+
+func (s *Server) getModel(w http.ResponseWriter, name string) {
+	for _, m := range modelList() {
+		if m["name"] == name {
+			writeJSON(w, http.StatusOK, m)
+			return
+		}
+	}
+	notFound(w, name)
+}
+
+// generateContent answers a query by quoting the best keyword matches from the
+// requested stores, with grounding metadata shaped like the real API's.
+func (s *Server) generateContent(w http.ResponseWriter, r *http.Request, model string) {
+	var req generateRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "invalid request body: "+err.Error())
+		return
+	}
+
+	query := requestText(req.Contents)
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "contents must not be empty")
+		return
+	}
+
+	var stores []string
+	var filter []filterClause
+	for _, tool := range req.Tools {
+		if tool.FileSearch == nil {
+			continue
+		}
+		stores = append(stores, tool.FileSearch.FileSearchStoreNames...)
+		if tool.FileSearch.MetadataFilter != "" {
+			clauses, err := parseFilter(tool.FileSearch.MetadataFilter)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "invalid metadata filter: "+err.Error())
+				return
+			}
+			filter = append(filter, clauses...)
+		}
+	}
+
+	s.mu.Lock()
+	for _, name := range stores {
+		if _, ok := s.stores[name]; !ok {
+			s.mu.Unlock()
+			notFound(w, name)
+			return
+		}
+	}
+	hits := s.retrieve(stores, filter, query)
+	s.mu.Unlock()
+
+	text, chunks, supports := answer(query, stores, hits)
+
+	candidate := map[string]any{
+		"content": map[string]any{
+			"role":  "model",
+			"parts": []map[string]any{{"text": text}},
+		},
+		"finishReason": "STOP",
+		"index":        0,
+	}
+	if len(stores) > 0 {
+		candidate["groundingMetadata"] = map[string]any{
+			"groundingChunks":   chunks,
+			"groundingSupports": supports,
+		}
+	}
+
+	promptTokens := countTokens(query)
+	toolTokens := 0
+	for _, c := range chunks {
+		toolTokens += countTokens(c.RetrievedContext.Text)
+	}
+	candidateTokens := countTokens(text)
+	usage := map[string]any{
+		"promptTokenCount":     promptTokens,
+		"candidatesTokenCount": candidateTokens,
+		"totalTokenCount":      promptTokens + toolTokens + candidateTokens,
+	}
+	if toolTokens > 0 {
+		usage["toolUsePromptTokenCount"] = toolTokens
+	}
+
+	s.mu.Lock()
+	s.seq++
+	responseID := fmt.Sprintf("fake-response-%06d", s.seq)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"candidates":    []any{candidate},
+		"usageMetadata": usage,
+		"modelVersion":  strings.TrimPrefix(model, "models/"),
+		"responseId":    responseID,
+	})
+}
+
+// retrieve scores every active document chunk in stores against the query.
+// The caller must hold s.mu.
+func (s *Server) retrieve(stores []string, filter []filterClause, query string) []hit {
+	terms := queryTerms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var hits []hit
+	for _, name := range stores {
+		st := s.stores[name]
+		for _, key := range sortedKeys(st.documents, func(d *document) int { return d.seq }) {
+			doc := st.documents[key]
+			if doc.info.State != genai.DocumentStateActive || !matchFilter(filter, doc.info.CustomMetadata) {
+				continue
+			}
+			for _, chunk := range doc.chunks {
+				if score := scoreChunk(terms, chunk); score > 0 {
+					hits = append(hits, hit{store: name, doc: doc, chunk: chunk, score: score})
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].score > hits[j].score })
+	if len(hits) > maxGroundingChunks {
+		hits = hits[:maxGroundingChunks]
+	}
+	return hits
+}
+
+// answer composes the response text from the top hits and the grounding
+// supports linking each sentence to its chunk.
+func answer(query string, stores []string, hits []hit) (string, []groundingChunk, []map[string]any) {
+	chunks := make([]groundingChunk, 0, len(hits))
+	for _, h := range hits {
+		chunks = append(chunks, groundingChunk{RetrievedContext: &retrievedContext{
+			Title:           h.doc.info.DisplayName,
+			Text:            h.chunk,
+			FileSearchStore: h.store,
+		}})
+	}
+
+	if len(stores) == 0 {
+		return fmt.Sprintf("This is a fake response to: %s", query), chunks, nil
+	}
+	if len(hits) == 0 {
+		return "I could not find any information about that in the provided documents.", chunks, []map[string]any{}
+	}
+
+	var b strings.Builder
+	supports := make([]map[string]any, 0, maxCitedChunks)
+	for i, h := range hits {
+		if i == maxCitedChunks {
+			break
+		}
+		if b.Len() > 0 {
+			b.WriteString(" ")
+		}
+		sentence := fmt.Sprintf("According to %s, %s.", h.doc.info.DisplayName, snippet(h.chunk, snippetWords))
+		start := b.Len()
+		b.WriteString(sentence)
+		supports = append(supports, map[string]any{
+			"segment": map[string]any{
+				"startIndex": start,
+				"endIndex":   b.Len(),
+				"text":       sentence,
+			},
+			"groundingChunkIndices": []int{i},
+		})
+	}
+	return b.String(), chunks, supports
+}
+
+func requestText(contents []*genai.Content) string {
+	var parts []string
+	for _, c := range contents {
+		if c == nil {
+			continue
+		}
+		for _, p := range c.Parts {
+			if p != nil && p.Text != "" {
+				parts = append(parts, p.Text)
+			}
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// chunkText splits text into chunks of at most size words.
+func chunkText(text string, size int) []string {
+	words := strings.Fields(text)
+	var chunks []string
+	for len(words) > 0 {
+		n := min(size, len(words))
+		chunks = append(chunks, strings.Join(words[:n], " "))
+		words = words[n:]
+	}
+	return chunks
+}
+
+func snippet(chunk string, words int) string {
+	fields := strings.Fields(chunk)
+	if len(fields) > words {
+		fields = fields[:words]
+	}
+	return strings.TrimRight(strings.Join(fields, " "), ".,;:")
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func queryTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, t := range tokenize(query) {
+		if stopWords[t] || seen[t] {
+			continue
+		}
+		seen[t] = true
+		terms = append(terms, t)
+	}
+	return terms
+}
+
+// scoreChunk weights distinct matched terms above repeated occurrences.
+func scoreChunk(terms []string, chunk string) int {
+	counts := make(map[string]int)
+	for _, t := range tokenize(chunk) {
+		counts[t]++
+	}
+	score := 0
+	for _, t := range terms {
+		if n := counts[t]; n > 0 {
+			score += 10 + n
+		}
+	}
+	return score
+}
+
+// countTokens approximates token usage as one token per word.
+func countTokens(text string) int {
+	return len(strings.Fields(text))
+}
+
+// filterClause is one comparison in a metadata filter, e.g. author = "Jane".
+type filterClause struct {
+	key    string
+	op     string
+	str    string
+	num    float64
+	isNum  bool
+	orNext []filterClause
+}
+
+var filterOps = []string{"!=", "<=", ">=", "=", "<", ">"}
+
+// parseFilter parses the subset of AIP-160 filters supported by the fake:
+// comparisons joined by AND, where each comparison may be a group of
+// alternatives joined by OR, e.g. `genre = "fiction" AND year >= 2000`.
+func parseFilter(filter string) ([]filterClause, error) {
+	var clauses []filterClause
+	for _, term := range strings.Split(filter, " AND ") {
+		var alternatives []filterClause
+		for _, part := range strings.Split(strings.Trim(strings.TrimSpace(term), "()"), " OR ") {
+			c, err := parseClause(strings.TrimSpace(part))
+			if err != nil {
+				return nil, err
+			}
+			alternatives = append(alternatives, c)
+		}
+		first := alternatives[0]
+		first.orNext = alternatives[1:]
+		clauses = append(clauses, first)
+	}
+	return clauses, nil
+}
+
+func parseClause(expr string) (filterClause, error) {
+	for _, op := range filterOps {
+		key, value, ok := strings.Cut(expr, op)
+		if !ok {
+			continue
+		}
+		c := filterClause{key: strings.TrimSpace(key), op: op}
+		value = strings.TrimSpace(value)
+		if c.key == "" || value == "" {
+			return c, fmt.Errorf("incomplete comparison %q", expr)
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			c.str = unquoted
+		} else if n, err := strconv.ParseFloat(value, 64); err == nil {
+			c.num, c.isNum = n, true
+		} else {
+			return c, fmt.Errorf("value must be a quoted string or a number: %s", value)
+		}
+		if !c.isNum && op != "=" && op != "!=" {
+			return c, fmt.Errorf("operator %s requires a numeric value", op)
+		}
+		return c, nil
+	}
+	return filterClause{}, fmt.Errorf("unsupported expression %q", expr)
+}
+
+func matchFilter(clauses []filterClause, metadata []*genai.CustomMetadata) bool {
+	for _, c := range clauses {
+		matched := c.match(metadata)
+		for _, alt := range c.orNext {
+			matched = matched || alt.match(metadata)
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func (c filterClause) match(metadata []*genai.CustomMetadata) bool {
+	for _, m := range metadata {
+		if m == nil || m.Key != c.key {
+			continue
+		}
+		if c.isNum {
+			if m.NumericValue == nil {
+				return false
+			}
+			v := float64(*m.NumericValue)
+			switch c.op {
+			case "=":
+				return v == c.num
+			case "!=":
+				return v != c.num
+			case "<":
+				return v < c.num
+			case "<=":
+				return v <= c.num
+			case ">":
+				return v > c.num
+			case ">=":
+				return v >= c.num
+			}
+			return false
+		}
+		values := []string{m.StringValue}
+		if m.StringListValue != nil {
+			values = m.StringListValue.Values
+		}
+		found := false
+		for _, v := range values {
+			if v == c.str {
+				found = true
+			}
+		}
+		return found == (c.op == "=")
+	}
+	// A missing key only satisfies inequality
+	return c.op == "!="
+}
@@ -0,0 +1,320 @@
+// Package fake provides an in-process stand-in for the Gemini File Search API.
+//
+// The server implements the File Search Store, Document, File, Operation and
+// Model endpoints used by the genai SDK. State is kept in memory, upload and
+// import operations complete asynchronously, and GenerateContent answers with a
+// simple keyword retrieval over the stored documents. Point a client at it with
+// gemini.ClientConfig.BaseURL:
+//
+//	srv := fake.NewServer(nil)
+//	defer srv.Close()
+//	client, _ := gemini.NewClientWithConfig(ctx, &gemini.ClientConfig{APIKey: "test", BaseURL: srv.URL})
+package fake
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/constants"
+)
+
+const (
+	apiVersion      = "v1beta"
+	defaultPageSize = 10
+	maxPageSize     = 100
+)
+
+// Options configures a fake Server.
+type Options struct {
+	// APIKey, if set, must be sent in the x-goog-api-key header (or key query param).
+	// If empty, any non-empty key is accepted.
+	APIKey string
+	// IndexingDelay is how long upload and import operations stay pending.
+	IndexingDelay time.Duration
+	// PageSize is the default page size for list endpoints (default: 10).
+	PageSize int
+	// FailIndexing, if set, is called with each document's display name.
+	// Returning true makes its operation fail and the document end up FAILED.
+	FailIndexing func(displayName string) bool
+}
+
+// Server is an in-memory Gemini File Search API served over HTTP.
+type Server struct {
+	// URL is the base URL of the running server, suitable for ClientConfig.BaseURL.
+	URL string
+
+	opts Options
+	http *httptest.Server
+
+	mu         sync.Mutex
+	seq        int
+	stores     map[string]*store
+	files      map[string]*file
+	operations map[string]*operation
+	uploads    map[string]*uploadSession
+	wg         sync.WaitGroup
+}
+
+// NewServer starts a fake API server on a loopback port.
+// The caller must call Close when done.
+func NewServer(opts *Options) *Server {
+	s := newServer(opts)
+	s.http = httptest.NewServer(s)
+	s.URL = s.http.URL
+	return s
+}
+
+func newServer(opts *Options) *Server {
+	s := &Server{
+		stores:     make(map[string]*store),
+		files:      make(map[string]*file),
+		operations: make(map[string]*operation),
+		uploads:    make(map[string]*uploadSession),
+	}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.PageSize <= 0 {
+		s.opts.PageSize = defaultPageSize
+	}
+	return s
+}
+
+// Close shuts down the server and waits for pending operations to settle.
+func (s *Server) Close() {
+	if s.http != nil {
+		s.http.Close()
+	}
+	s.wg.Wait()
+}
+
+// WaitIdle blocks until all pending operations have completed.
+func (s *Server) WaitIdle() {
+	s.wg.Wait()
+}
+
+// nextID returns a resource ID derived from a display name, mimicking the API's
+// lowercase slug plus random suffix with a deterministic counter instead.
+func (s *Server) nextID(displayName string) string {
+	s.seq++
+	slug := strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(displayName), ""), "-")
+	if len(slug) > 40 {
+		slug = slug[:40]
+	}
+	if slug == "" {
+		slug = "id"
+	}
+	return fmt.Sprintf("%s-%06d", slug, s.seq)
+}
+
+// Operations returns the names of all operations started so far, oldest first.
+func (s *Server) Operations() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sortedKeys(s.operations, func(op *operation) int { return op.seq })
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// ServeHTTP routes API requests to their handlers.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "UNAUTHENTICATED", "API key not valid. Please pass a valid API key.")
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	switch {
+	case strings.HasPrefix(path, "upload/sessions/"):
+		s.handleUploadChunk(w, r, strings.TrimPrefix(path, "upload/sessions/"))
+		return
+	case strings.HasPrefix(path, "upload/"+apiVersion+"/"):
+		s.handleUploadStart(w, r, strings.TrimPrefix(path, "upload/"+apiVersion+"/"))
+		return
+	case strings.HasPrefix(path, apiVersion+"/"):
+		path = strings.TrimPrefix(path, apiVersion+"/")
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "unknown path: "+r.URL.Path)
+		return
+	}
+
+	resource, method, _ := strings.Cut(path, ":")
+	segments := strings.Split(resource, "/")
+
+	switch {
+	case segments[0] == "fileSearchStores":
+		s.routeStores(w, r, resource, method, segments)
+	case segments[0] == "files":
+		s.routeFiles(w, r, resource, segments)
+	case segments[0] == "models":
+		s.routeModels(w, r, resource, method, segments)
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "unknown resource: "+resource)
+	}
+}
+
+func (s *Server) routeStores(w http.ResponseWriter, r *http.Request, resource, method string, segments []string) {
+	switch {
+	case len(segments) == 1 && r.Method == http.MethodGet:
+		s.listStores(w, r)
+	case len(segments) == 1 && r.Method == http.MethodPost:
+		s.createStore(w, r)
+	case len(segments) == 2 && method == "importFile" && r.Method == http.MethodPost:
+		s.importFile(w, r, resource)
+	case len(segments) == 2 && r.Method == http.MethodGet:
+		s.getStore(w, resource)
+	case len(segments) == 2 && r.Method == http.MethodDelete:
+		s.deleteStore(w, r, resource)
+	case len(segments) == 3 && segments[2] == "documents" && r.Method == http.MethodGet:
+		s.listDocuments(w, r, strings.Join(segments[:2], "/"))
+	case len(segments) == 4 && segments[2] == "documents" && r.Method == http.MethodGet:
+		s.getDocument(w, resource)
+	case len(segments) == 4 && segments[2] == "documents" && r.Method == http.MethodDelete:
+		s.deleteDocument(w, r, resource)
+	case strings.Contains(resource, constants.OperationResourcePrefix) && r.Method == http.MethodGet:
+		s.getOperation(w, resource)
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "unknown resource: "+resource)
+	}
+}
+
+func (s *Server) routeFiles(w http.ResponseWriter, r *http.Request, resource string, segments []string) {
+	switch {
+	case len(segments) == 1 && r.Method == http.MethodGet:
+		s.listFiles(w, r)
+	case len(segments) == 2 && r.Method == http.MethodGet:
+		s.getFile(w, resource)
+	case len(segments) == 2 && r.Method == http.MethodDelete:
+		s.deleteFile(w, resource)
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "unknown resource: "+resource)
+	}
+}
+
+func (s *Server) routeModels(w http.ResponseWriter, r *http.Request, resource, method string, segments []string) {
+	switch {
+	case len(segments) == 1 && r.Method == http.MethodGet:
+		s.listModels(w, r)
+	case len(segments) == 2 && method == "generateContent" && r.Method == http.MethodPost:
+		s.generateContent(w, r, resource)
+	case len(segments) == 2 && r.Method == http.MethodGet:
+		s.getModel(w, resource)
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "unknown resource: "+resource)
+	}
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	key := r.Header.Get("x-goog-api-key")
+	if key == "" {
+		key = r.URL.Query().Get("key")
+	}
+	if s.opts.APIKey != "" {
+		return key == s.opts.APIKey
+	}
+	return key != ""
+}
+
+// paginate returns the page of n items selected by the request's pageSize and
+// pageToken parameters, and the token for the next page ("" on the last page).
+func (s *Server) paginate(r *http.Request, n int) (start, end int, next string, err error) {
+	size := s.opts.PageSize
+	if v := r.URL.Query().Get("pageSize"); v != "" {
+		size, err = strconv.Atoi(v)
+		if err != nil || size <= 0 {
+			return 0, 0, "", fmt.Errorf("invalid pageSize: %s", v)
+		}
+		if size > maxPageSize {
+			size = maxPageSize
+		}
+	}
+	if token := r.URL.Query().Get("pageToken"); token != "" {
+		start, err = strconv.Atoi(strings.TrimPrefix(token, "page-"))
+		if err != nil || start < 0 || start > n {
+			return 0, 0, "", fmt.Errorf("invalid pageToken: %s", token)
+		}
+	}
+	end = start + size
+	if end >= n {
+		return start, n, "", nil
+	}
+	return start, end, fmt.Sprintf("page-%d", end), nil
+}
+
+// sortedKeys returns map keys in creation order, which matches the IDs' counter suffix.
+func sortedKeys[T any](m map[string]T, created func(T) int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return created(m[keys[i]]) < created(m[keys[j]]) })
+	return keys
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a Google API error body, which the SDK surfaces as genai.APIError.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]any{
+		"error": map[string]any{
+			"code":    status,
+			"message": message,
+			"status":  code,
+		},
+	})
+}
+
+func notFound(w http.ResponseWriter, name string) {
+	writeError(w, http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("Requested entity was not found: %s", name))
+}
+
+func decodeBody(r *http.Request, v any) error {
+	if r.Body == nil {
+		return nil
+	}
+	dec := json.NewDecoder(r.Body)
+	if err := dec.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+func forceParam(r *http.Request) bool {
+	force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+	return force
+}
+
+// timestamp truncates to microseconds like the real API.
+func timestamp() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// modelList returns the models the fake reports, in the API's wire format.
+func modelList() []map[string]any {
+	names := constants.GetModelList()
+	models := make([]map[string]any, 0, len(names))
+	for _, n := range names {
+		models = append(models, map[string]any{
+			"name":                       "models/" + n,
+			"displayName":                n,
+			"inputTokenLimit":            1048576,
+			"outputTokenLimit":           65536,
+			"supportedGenerationMethods": []string{"generateContent", "countTokens"},
+		})
+	}
+	return models
+}
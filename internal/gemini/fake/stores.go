@@ -0,0 +1,321 @@
+package fake
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/constants"
+	"google.golang.org/genai"
+)
+
+// store is a File Search Store and its documents.
+type store struct {
+	seq       int
+	info      genai.FileSearchStore
+	documents map[string]*document
+}
+
+// document is an indexed (or indexing) document and the text used for retrieval.
+type document struct {
+	seq    int
+	info   genai.Document
+	chunks []string
+}
+
+// operation is a long-running upload or import operation.
+type operation struct {
+	seq int
+
+	Name     string         `json:"name"`
+	Done     bool           `json:"done,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+	Error    map[string]any `json:"error,omitempty"`
+	Response map[string]any `json:"response,omitempty"`
+}
+
+const (
+	uploadResponseType = "type.googleapis.com/google.ai.generativelanguage.v1main.UploadToFileSearchStoreResponse"
+	importResponseType = "type.googleapis.com/google.ai.generativelanguage.v1main.ImportFileResponse"
+)
+
+// storeView returns a copy of the store with its document counters filled in.
+func (st *store) view() *genai.FileSearchStore {
+	info := st.info
+	for _, doc := range st.documents {
+		switch doc.info.State {
+		case genai.DocumentStateActive:
+			info.ActiveDocumentsCount++
+		case genai.DocumentStatePending:
+			info.PendingDocumentsCount++
+		case genai.DocumentStateFailed:
+			info.FailedDocumentsCount++
+		}
+		info.SizeBytes += doc.info.SizeBytes
+	}
+	return &info
+}
+
+func (s *Server) listStores(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := sortedKeys(s.stores, func(st *store) int { return st.seq })
+	start, end, next, err := s.paginate(r, len(keys))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", err.Error())
+		return
+	}
+
+	items := make([]*genai.FileSearchStore, 0, end-start)
+	for _, k := range keys[start:end] {
+		items = append(items, s.stores[k].view())
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"fileSearchStores": items,
+		"nextPageToken":    next,
+	})
+}
+
+func (s *Server) createStore(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		DisplayName string `json:"displayName"`
+	}
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "invalid request body: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := timestamp()
+	st := &store{
+		seq: s.seq + 1,
+		info: genai.FileSearchStore{
+			Name:        constants.StoreResourcePrefix + s.nextID(req.DisplayName),
+			DisplayName: req.DisplayName,
+			CreateTime:  now,
+			UpdateTime:  now,
+		},
+		documents: make(map[string]*document),
+	}
+	s.stores[st.info.Name] = st
+	writeJSON(w, http.StatusOK, st.view())
+}
+
+func (s *Server) getStore(w http.ResponseWriter, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.stores[name]
+	if !ok {
+		notFound(w, name)
+		return
+	}
+	writeJSON(w, http.StatusOK, st.view())
+}
+
+func (s *Server) deleteStore(w http.ResponseWriter, r *http.Request, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.stores[name]
+	if !ok {
+		notFound(w, name)
+		return
+	}
+	if len(st.documents) > 0 && !forceParam(r) {
+		writeError(w, http.StatusBadRequest, "FAILED_PRECONDITION",
+			fmt.Sprintf("Cannot delete non-empty FileSearchStore %s. Set force to true to delete its Documents.", name))
+		return
+	}
+	delete(s.stores, name)
+	writeJSON(w, http.StatusOK, map[string]any{})
+}
+
+func (s *Server) listDocuments(w http.ResponseWriter, r *http.Request, storeName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.stores[storeName]
+	if !ok {
+		notFound(w, storeName)
+		return
+	}
+
+	keys := sortedKeys(st.documents, func(d *document) int { return d.seq })
+	start, end, next, err := s.paginate(r, len(keys))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", err.Error())
+		return
+	}
+
+	items := make([]*genai.Document, 0, end-start)
+	for _, k := range keys[start:end] {
+		info := st.documents[k].info
+		items = append(items, &info)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"documents":     items,
+		"nextPageToken": next,
+	})
+}
+
+// lookupDocument finds a document by its full resource name. The caller must hold s.mu.
+func (s *Server) lookupDocument(name string) (*store, *document) {
+	storeName, _, ok := strings.Cut(name, constants.DocumentResourcePrefix)
+	if !ok {
+		return nil, nil
+	}
+	st, ok := s.stores[storeName]
+	if !ok {
+		return nil, nil
+	}
+	return st, st.documents[name]
+}
+
+func (s *Server) getDocument(w http.ResponseWriter, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, doc := s.lookupDocument(name)
+	if doc == nil {
+		notFound(w, name)
+		return
+	}
+	info := doc.info
+	writeJSON(w, http.StatusOK, &info)
+}
+
+func (s *Server) deleteDocument(w http.ResponseWriter, r *http.Request, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, doc := s.lookupDocument(name)
+	if doc == nil {
+		notFound(w, name)
+		return
+	}
+	if len(doc.chunks) > 0 && !forceParam(r) {
+		writeError(w, http.StatusBadRequest, "FAILED_PRECONDITION",
+			fmt.Sprintf("Cannot delete Document %s because it has Chunks. Set force to true to delete them.", name))
+		return
+	}
+	delete(st.documents, name)
+	writeJSON(w, http.StatusOK, map[string]any{})
+}
+
+func (s *Server) importFile(w http.ResponseWriter, r *http.Request, storeName string) {
+	var req struct {
+		FileName       string                  `json:"fileName"`
+		CustomMetadata []*genai.CustomMetadata `json:"customMetadata"`
+	}
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "invalid request body: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.stores[storeName]
+	if !ok {
+		notFound(w, storeName)
+		return
+	}
+	f, ok := s.files[req.FileName]
+	if !ok {
+		notFound(w, req.FileName)
+		return
+	}
+
+	displayName := f.info.DisplayName
+	if displayName == "" {
+		displayName = strings.TrimPrefix(f.info.Name, constants.FileResourcePrefix)
+	}
+	doc := s.addDocument(st, displayName, f.info.MIMEType, req.CustomMetadata, f.content)
+	op := s.startOperation(storeName+constants.OperationResourcePrefix, importResponseType, st, doc)
+	writeJSON(w, http.StatusOK, op)
+}
+
+// addDocument creates a pending document in st. The caller must hold s.mu.
+func (s *Server) addDocument(st *store, displayName, mimeType string, metadata []*genai.CustomMetadata, content []byte) *document {
+	now := timestamp()
+	doc := &document{
+		seq: s.seq + 1,
+		info: genai.Document{
+			Name:           st.info.Name + constants.DocumentResourcePrefix + s.nextID(displayName),
+			DisplayName:    displayName,
+			State:          genai.DocumentStatePending,
+			SizeBytes:      int64(len(content)),
+			MIMEType:       mimeType,
+			CreateTime:     now,
+			UpdateTime:     now,
+			CustomMetadata: metadata,
+		},
+	}
+	doc.chunks = chunkText(string(content), defaultChunkWords)
+	st.documents[doc.info.Name] = doc
+	return doc
+}
+
+// startOperation registers a pending operation for doc and completes it after the
+// configured indexing delay. The caller must hold s.mu.
+func (s *Server) startOperation(prefix, responseType string, st *store, doc *document) *operation {
+	s.seq++
+	op := &operation{
+		seq:  s.seq,
+		Name: fmt.Sprintf("%s%s-%06d", prefix, strings.TrimPrefix(doc.info.Name, st.info.Name+constants.DocumentResourcePrefix), s.seq),
+	}
+	s.operations[op.Name] = op
+	snapshot := *op
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if s.opts.IndexingDelay > 0 {
+			time.Sleep(s.opts.IndexingDelay)
+		}
+		s.finishOperation(op, responseType, st, doc)
+	}()
+	return &snapshot
+}
+
+func (s *Server) finishOperation(op *operation, responseType string, st *store, doc *document) {
+	failed := s.opts.FailIndexing != nil && s.opts.FailIndexing(doc.info.DisplayName)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	op.Done = true
+	doc.info.UpdateTime = timestamp()
+	if failed {
+		doc.info.State = genai.DocumentStateFailed
+		doc.chunks = nil
+		op.Error = map[string]any{
+			"code":    13,
+			"message": fmt.Sprintf("Failed to index document %s", doc.info.DisplayName),
+		}
+		return
+	}
+	doc.info.State = genai.DocumentStateActive
+	op.Response = map[string]any{
+		"@type":        responseType,
+		"parent":       st.info.Name,
+		"documentName": doc.info.Name,
+	}
+}
+
+func (s *Server) getOperation(w http.ResponseWriter, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	op, ok := s.operations[name]
+	if !ok {
+		notFound(w, name)
+		return
+	}
+	writeJSON(w, http.StatusOK, op)
+}
@@ -0,0 +1,311 @@
+package gemini
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// Middleware wraps an API to add behaviour such as caching, auditing or logging.
+type Middleware func(API) API
+
+// Chain wraps api with the given middleware. The first middleware is the
+// outermost, so it sees each call first.
+func Chain(api API, mws ...Middleware) API {
+	for i := len(mws) - 1; i >= 0; i-- {
+		api = mws[i](api)
+	}
+	return api
+}
+
+// Call describes a single API method invocation seen by an Interceptor.
+type Call struct {
+	Method string
+	Args   []any
+	// Result is the method's non-error return value. It is set once next returns.
+	Result any
+}
+
+// Interceptor runs around every API method. It must call next to invoke the
+// wrapped method (optionally with a different context) and return its error.
+// Backend and Close are not intercepted.
+type Interceptor func(ctx context.Context, call *Call, next func(ctx context.Context) error) error
+
+// Intercept returns a Middleware that runs fn around every method of the wrapped API.
+func Intercept(fn Interceptor) Middleware {
+	return func(next API) API {
+		return &interceptedAPI{next: next, fn: fn}
+	}
+}
+
+type interceptedAPI struct {
+	next API
+	fn   Interceptor
+}
+
+func (a *interceptedAPI) Backend() Backend { return a.next.Backend() }
+func (a *interceptedAPI) Close()           { a.next.Close() }
+
+func (a *interceptedAPI) ListStores(ctx context.Context) (stores []*genai.FileSearchStore, err error) {
+	call := &Call{Method: "ListStores"}
+	err = a.fn(ctx, call, func(ctx context.Context) error {
+		stores, err = a.next.ListStores(ctx)
+		call.Result = stores
+		return err
+	})
+	return stores, err
+}
+
+func (a *interceptedAPI) GetStore(ctx context.Context, name string) (store *genai.FileSearchStore, err error) {
+	call := &Call{Method: "GetStore", Args: []any{name}}
+	err = a.fn(ctx, call, func(ctx context.Context) error {
+		store, err = a.next.GetStore(ctx, name)
+		call.Result = store
+		return err
+	})
+	return store, err
+}
+
+func (a *interceptedAPI) CreateStore(ctx context.Context, displayName string) (store *genai.FileSearchStore, err error) {
+	call := &Call{Method: "CreateStore", Args: []any{displayName}}
+	err = a.fn(ctx, call, func(ctx context.Context) error {
+		store, err = a.next.CreateStore(ctx, displayName)
+		call.Result = store
+		return err
+	})
+	return store, err
+}
+
+func (a *interceptedAPI) DeleteStore(ctx context.Context, name string, force bool) error {
+	call := &Call{Method: "DeleteStore", Args: []any{name, force}}
+	return a.fn(ctx, call, func(ctx context.Context) error {
+		return a.next.DeleteStore(ctx, name, force)
+	})
+}
+
+func (a *interceptedAPI) ResolveStoreName(ctx context.Context, nameOrID string) (name string, err error) {
+	call := &Call{Method: "ResolveStoreName", Args: []any{nameOrID}}
+	err = a.fn(ctx, call, func(ctx context.Context) error {
+		name, err = a.next.ResolveStoreName(ctx, nameOrID)
+		call.Result = name
+		return err
+	})
+	return name, err
+}
+
+func (a *interceptedAPI) GetStoreNames(ctx context.Context) (names []string, err error) {
+	call := &Call{Method: "GetStoreNames"}
+	err = a.fn(ctx, call, func(ctx context.Context) error {
+		names, err = a.next.GetStoreNames(ctx)
+		call.Result = names
+		return err
+	})
+	return names, err
+}
+
+func (a *interceptedAPI) ListFiles(ctx context.Context) (files []*genai.File, err error) {
+	call := &Call{Method: "ListFiles"}
+	err = a.fn(ctx, call, func(ctx context.Context) error {
+		files, err = a.next.ListFiles(ctx)
+		call.Result = files
+		return err
+	})
+	return files, err
+}
+
+func (a *interceptedAPI) GetFile(ctx context.Context, name string) (file *genai.File, err error) {
+	call := &Call{Method: "GetFile", Args: []any{name}}
+	err = a.fn(ctx, call, func(ctx context.Context) error {
+		file, err = a.next.GetFile(ctx, name)
+		call.Result = file
+		return err
+	})
+	return file, err
+}
+
+func (a *interceptedAPI) UploadFile(ctx context.Context, path string, opts *UploadFileOptions) (file *genai.File, err error) {
+	call := &Call{Method: "UploadFile", Args: []any{path, opts}}
+	err = a.fn(ctx, call, func(ctx context.Context) error {
+		file, err = a.next.UploadFile(ctx, path, opts)
+		call.Result = file
+		return err
+	})
+	return file, err
+}
+
+func (a *interceptedAPI) ImportFile(ctx context.Context, fileID, storeID string, opts *ImportFileOptions) error {
+	call := &Call{Method: "ImportFile", Args: []any{fileID, storeID, opts}}
+	return a.fn(ctx, call, func(ctx context.Context) error {
+		return a.next.ImportFile(ctx, fileID, storeID, opts)
+	})
+}
+
+func (a *interceptedAPI) DeleteFile(ctx context.Context, name string) error {
+	call := &Call{Method: "DeleteFile", Args: []any{name}}
+	return a.fn(ctx, call, func(ctx context.Context) error {
+		return a.next.DeleteFile(ctx, name)
+	})
+}
+
+func (a *interceptedAPI) ResolveFileName(ctx context.Context, nameOrID string) (name string, err error) {
+	call := &Call{Method: "ResolveFileName", Args: []any{nameOrID}}
+	err = a.fn(ctx, call, func(ctx context.Context) error {
+		name, err = a.next.ResolveFileName(ctx, nameOrID)
+		call.Result = name
+		return err
+	})
+	return name, err
+}
+
+func (a *interceptedAPI) GetFileNames(ctx context.Context) (names []string, err error) {
+	call := &Call{Method: "GetFileNames"}
+	err = a.fn(ctx, call, func(ctx context.Context) error {
+		names, err = a.next.GetFileNames(ctx)
+		call.Result = names
+		return err
+	})
+	return names, err
+}
+
+func (a *interceptedAPI) ListDocuments(ctx context.Context, storeName string) (docs []*genai.Document, err error) {
+	call := &Call{Method: "ListDocuments", Args: []any{storeName}}
+	err = a.fn(ctx, call, func(ctx context.Context) error {
+		docs, err = a.next.ListDocuments(ctx, storeName)
+		call.Result = docs
+		return err
+	})
+	return docs, err
+}
+
+func (a *interceptedAPI) GetDocument(ctx context.Context, name string) (doc *genai.Document, err error) {
+	call := &Call{Method: "GetDocument", Args: []any{name}}
+	err = a.fn(ctx, call, func(ctx context.Context) error {
+		doc, err = a.next.GetDocument(ctx, name)
+		call.Result = doc
+		return err
+	})
+	return doc, err
+}
+
+func (a *interceptedAPI) DeleteDocument(ctx context.Context, name string, force bool) error {
+	call := &Call{Method: "DeleteDocument", Args: []any{name, force}}
+	return a.fn(ctx, call, func(ctx context.Context) error {
+		return a.next.DeleteDocument(ctx, name, force)
+	})
+}
+
+func (a *interceptedAPI) ResolveDocumentName(ctx context.Context, storeNameOrID, docNameOrID string) (name string, err error) {
+	call := &Call{Method: "ResolveDocumentName", Args: []any{storeNameOrID, docNameOrID}}
+	err = a.fn(ctx, call, func(ctx context.Context) error {
+		name, err = a.next.ResolveDocumentName(ctx, storeNameOrID, docNameOrID)
+		call.Result = name
+		return err
+	})
+	return name, err
+}
+
+func (a *interceptedAPI) GetDocumentNames(ctx context.Context, storeID string) (names []string, err error) {
+	call := &Call{Method: "GetDocumentNames", Args: []any{storeID}}
+	err = a.fn(ctx, call, func(ctx context.Context) error {
+		names, err = a.next.GetDocumentNames(ctx, storeID)
+		call.Result = names
+		return err
+	})
+	return names, err
+}
+
+func (a *interceptedAPI) GetOperation(ctx context.Context, operationName string, operationType OperationType) (status *OperationStatus, err error) {
+	call := &Call{Method: "GetOperation", Args: []any{operationName, operationType}}
+	err = a.fn(ctx, call, func(ctx context.Context) error {
+		status, err = a.next.GetOperation(ctx, operationName, operationType)
+		call.Result = status
+		return err
+	})
+	return status, err
+}
+
+func (a *interceptedAPI) Query(ctx context.Context, text string, storeName string, modelName string, metadataFilter string) (resp *genai.GenerateContentResponse, err error) {
+	call := &Call{Method: "Query", Args: []any{text, storeName, modelName, metadataFilter}}
+	err = a.fn(ctx, call, func(ctx context.Context) error {
+		resp, err = a.next.Query(ctx, text, storeName, modelName, metadataFilter)
+		call.Result = resp
+		return err
+	})
+	return resp, err
+}
+
+func (a *interceptedAPI) ListModels(ctx context.Context) (models []*genai.Model, err error) {
+	call := &Call{Method: "ListModels"}
+	err = a.fn(ctx, call, func(ctx context.Context) error {
+		models, err = a.next.ListModels(ctx)
+		call.Result = models
+		return err
+	})
+	return models, err
+}
+
+// RecordedCall is an API call captured by a Recorder.
+type RecordedCall struct {
+	Method   string
+	Args     []any
+	Result   any
+	Err      error
+	Duration time.Duration
+}
+
+// Recorder captures every call made through the APIs it wraps, e.g. to assert
+// on the requests a command makes in tests. It is safe for concurrent use.
+type Recorder struct {
+	mu    sync.Mutex
+	calls []RecordedCall
+}
+
+// NewRecorder creates an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Middleware returns a Middleware that records calls into r.
+func (r *Recorder) Middleware() Middleware {
+	return Intercept(func(ctx context.Context, call *Call, next func(ctx context.Context) error) error {
+		start := time.Now()
+		err := next(ctx)
+		r.mu.Lock()
+		r.calls = append(r.calls, RecordedCall{
+			Method:   call.Method,
+			Args:     call.Args,
+			Result:   call.Result,
+			Err:      err,
+			Duration: time.Since(start),
+		})
+		r.mu.Unlock()
+		return err
+	})
+}
+
+// Calls returns the recorded calls in the order they completed.
+func (r *Recorder) Calls() []RecordedCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]RecordedCall(nil), r.calls...)
+}
+
+// Methods returns the names of the recorded calls in the order they completed.
+func (r *Recorder) Methods() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	methods := make([]string, len(r.calls))
+	for i, c := range r.calls {
+		methods[i] = c.Method
+	}
+	return methods
+}
+
+// Reset discards all recorded calls.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
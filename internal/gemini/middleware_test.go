@@ -0,0 +1,119 @@
+package gemini
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mikesmitty/file-search/internal/gemini/fake"
+)
+
+func newFakeClient(t *testing.T) *Client {
+	t.Helper()
+	srv := fake.NewServer(nil)
+	t.Cleanup(srv.Close)
+
+	client, err := NewClientWithConfig(context.Background(), &ClientConfig{
+		APIKey:  "test-key",
+		BaseURL: srv.URL,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return client
+}
+
+func TestChainOrder(t *testing.T) {
+	var order []string
+	tag := func(name string) Middleware {
+		return Intercept(func(ctx context.Context, call *Call, next func(ctx context.Context) error) error {
+			order = append(order, name+">"+call.Method)
+			err := next(ctx)
+			order = append(order, name+"<"+call.Method)
+			return err
+		})
+	}
+
+	api := Chain(newFakeClient(t), tag("outer"), tag("inner"))
+	if _, err := api.ListStores(context.Background()); err != nil {
+		t.Fatalf("ListStores failed: %v", err)
+	}
+
+	want := []string{"outer>ListStores", "inner>ListStores", "inner<ListStores", "outer<ListStores"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+}
+
+func TestInterceptShortCircuit(t *testing.T) {
+	api := Chain(newFakeClient(t), Intercept(func(ctx context.Context, call *Call, next func(ctx context.Context) error) error {
+		if strings.HasPrefix(call.Method, "Delete") {
+			return ErrUnsupported
+		}
+		return next(ctx)
+	}))
+
+	if err := api.DeleteStore(context.Background(), "fileSearchStores/x", true); err != ErrUnsupported {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+	if _, err := api.ListStores(context.Background()); err != nil {
+		t.Errorf("ListStores should pass through, got %v", err)
+	}
+	if api.Backend() != BackendGemini {
+		t.Errorf("Backend() = %s, want %s", api.Backend(), BackendGemini)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+	rec := NewRecorder()
+	api := Chain(newFakeClient(t), rec.Middleware())
+
+	store, err := api.CreateStore(ctx, "Recorded")
+	if err != nil {
+		t.Fatalf("CreateStore failed: %v", err)
+	}
+	if _, err := api.ResolveStoreName(ctx, "Recorded"); err != nil {
+		t.Fatalf("ResolveStoreName failed: %v", err)
+	}
+	if _, err := api.GetStore(ctx, "fileSearchStores/missing"); err == nil {
+		t.Fatal("expected error for missing store")
+	}
+
+	wantMethods := []string{"CreateStore", "ResolveStoreName", "GetStore"}
+	if got := rec.Methods(); !reflect.DeepEqual(got, wantMethods) {
+		t.Fatalf("Methods() = %v, want %v", got, wantMethods)
+	}
+
+	calls := rec.Calls()
+	tests := []struct {
+		name    string
+		call    RecordedCall
+		args    []any
+		result  any
+		wantErr bool
+	}{
+		{name: "create", call: calls[0], args: []any{"Recorded"}, result: store},
+		{name: "resolve", call: calls[1], args: []any{"Recorded"}, result: store.Name},
+		{name: "missing", call: calls[2], args: []any{"fileSearchStores/missing"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.call.Args, tt.args) {
+				t.Errorf("Args = %v, want %v", tt.call.Args, tt.args)
+			}
+			if tt.result != nil && !reflect.DeepEqual(tt.call.Result, tt.result) {
+				t.Errorf("Result = %v, want %v", tt.call.Result, tt.result)
+			}
+			if (tt.call.Err != nil) != tt.wantErr {
+				t.Errorf("Err = %v, wantErr %v", tt.call.Err, tt.wantErr)
+			}
+		})
+	}
+
+	rec.Reset()
+	if len(rec.Calls()) != 0 {
+		t.Error("expected no calls after Reset")
+	}
+}
@@ -0,0 +1,229 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// ProgressEventType identifies a stage of an upload or import.
+type ProgressEventType string
+
+const (
+	// ProgressUploadStarted is reported before a file's bytes are sent (or an import is requested).
+	ProgressUploadStarted ProgressEventType = "upload_started"
+	// ProgressBytesSent is reported as the SDK reads the file, with the running total.
+	ProgressBytesSent ProgressEventType = "bytes_sent"
+	// ProgressOperationCreated is reported once the indexing operation has a name.
+	ProgressOperationCreated ProgressEventType = "operation_created"
+	// ProgressPolling is reported each time an operation is polled while it is still running.
+	ProgressPolling ProgressEventType = "polling"
+	// ProgressDone is reported when the file is uploaded and, for stores, indexed.
+	ProgressDone ProgressEventType = "done"
+	// ProgressFailed is reported when the upload, import or indexing fails.
+	ProgressFailed ProgressEventType = "failed"
+)
+
+// ProgressEvent describes a state change of one upload or import.
+type ProgressEvent struct {
+	Type ProgressEventType
+	Time time.Time
+	// Kind says whether the event belongs to an upload or an import.
+	Kind OperationType
+	// Source is the local path for uploads or the file resource name for imports.
+	Source string
+	// Store is the target store resource name, or "" for Files API uploads.
+	Store     string
+	Operation string
+	// BytesSent and TotalBytes track upload progress. TotalBytes is 0 for imports.
+	BytesSent  int64
+	TotalBytes int64
+	// Elapsed is the time since the upload or import started.
+	Elapsed time.Duration
+	// File is the uploaded Files API file and Document the indexed document, when known.
+	File     string
+	Document string
+	Err      error
+}
+
+// ProgressReporter receives progress events from UploadFile and ImportFile.
+// Report is called synchronously from the uploading goroutine, so it should
+// return quickly; a reporter shared between concurrent uploads must be safe
+// for concurrent use.
+type ProgressReporter interface {
+	Report(ProgressEvent)
+}
+
+// ProgressFunc adapts a function to a ProgressReporter.
+type ProgressFunc func(ProgressEvent)
+
+// Report calls f(e).
+func (f ProgressFunc) Report(e ProgressEvent) {
+	f(e)
+}
+
+type progressObserversKey struct{}
+
+// WithProgressObserver returns a context whose uploads and imports also
+// report to r, alongside the Progress reporter in their options. Middleware
+// uses it to watch a call without replacing the caller's reporter.
+func WithProgressObserver(ctx context.Context, r ProgressReporter) context.Context {
+	observers, _ := ctx.Value(progressObserversKey{}).([]ProgressReporter)
+	observers = append(observers[:len(observers):len(observers)], r)
+	return context.WithValue(ctx, progressObserversKey{}, observers)
+}
+
+// progressTracker fills in the common event fields, sends each event to the
+// options' reporter and any context observers, and logs the state changes.
+type progressTracker struct {
+	logger    *slog.Logger
+	reporters []ProgressReporter
+	kind      OperationType
+	source    string
+	store     string
+	operation string
+	total     int64
+	start     time.Time
+}
+
+func newProgressTracker(ctx context.Context, logger *slog.Logger, reporter ProgressReporter, kind OperationType, source, store string) *progressTracker {
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	reporters, _ := ctx.Value(progressObserversKey{}).([]ProgressReporter)
+	if reporter != nil {
+		reporters = append(reporters[:len(reporters):len(reporters)], reporter)
+	}
+	return &progressTracker{logger: logger, reporters: reporters, kind: kind, source: source, store: store, start: time.Now()}
+}
+
+// progressLogLevel is the level each event is logged at. Bytes sent are
+// reported on every read, so they are left to the HTTP request logs.
+func progressLogLevel(t ProgressEventType) (slog.Level, bool) {
+	switch t {
+	case ProgressBytesSent:
+		return 0, false
+	case ProgressUploadStarted, ProgressDone:
+		return slog.LevelInfo, true
+	case ProgressFailed:
+		return slog.LevelWarn, true
+	}
+	return slog.LevelDebug, true
+}
+
+func (t *progressTracker) report(e ProgressEvent) {
+	level, loggable := progressLogLevel(e.Type)
+	logged := loggable && t.logger.Enabled(context.Background(), level)
+	if len(t.reporters) == 0 && !logged {
+		return
+	}
+	e.Time = time.Now()
+	e.Kind = t.kind
+	e.Source = t.source
+	e.Store = t.store
+	if e.Operation == "" {
+		e.Operation = t.operation
+	}
+	if e.TotalBytes == 0 {
+		e.TotalBytes = t.total
+	}
+	e.Elapsed = e.Time.Sub(t.start)
+	if logged {
+		t.log(level, e)
+	}
+	for _, r := range t.reporters {
+		r.Report(e)
+	}
+}
+
+func (t *progressTracker) log(level slog.Level, e ProgressEvent) {
+	attrs := []slog.Attr{slog.String("event", string(e.Type)), slog.String("source", e.Source)}
+	for _, a := range []slog.Attr{
+		slog.String("store", e.Store),
+		slog.String("operation", e.Operation),
+		slog.String("file", e.File),
+		slog.String("document", e.Document),
+	} {
+		if a.Value.String() != "" {
+			attrs = append(attrs, a)
+		}
+	}
+	if e.TotalBytes > 0 {
+		attrs = append(attrs, slog.Int64("bytes", e.TotalBytes))
+	}
+	attrs = append(attrs, slog.Duration("elapsed", e.Elapsed))
+	if e.Err != nil {
+		attrs = append(attrs, slog.Any("error", e.Err))
+	}
+	t.logger.LogAttrs(context.Background(), level, "upload progress", attrs...)
+}
+
+// fail reports err and returns it.
+func (t *progressTracker) fail(err error) error {
+	t.report(ProgressEvent{Type: ProgressFailed, Err: err})
+	return err
+}
+
+// progressReader reports the bytes read through it.
+type progressReader struct {
+	r       io.Reader
+	sent    int64
+	tracker *progressTracker
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.sent += int64(n)
+		p.tracker.report(ProgressEvent{Type: ProgressBytesSent, BytesSent: p.sent})
+	}
+	return n, err
+}
+
+// openUpload opens path for a resumable upload the way the SDK's *FromPath
+// helpers do, but through a progressReader. When f is not nil it is read
+// instead, and left open. It returns a function that closes what it opened,
+// the MIME type (detected from the extension unless given) and the upload
+// headers the SDK expects.
+func openUpload(path string, f *os.File, mimeType string, tracker *progressTracker) (func() error, io.Reader, string, *genai.HTTPOptions, error) {
+	var info os.FileInfo
+	var err error
+	if f != nil {
+		info, err = f.Stat()
+	} else {
+		info, err = os.Stat(path)
+	}
+	if err != nil || info.IsDir() {
+		return nil, nil, "", nil, fmt.Errorf("%s is not a valid file path.", path)
+	}
+	if mimeType == "" {
+		mimeType = mime.TypeByExtension(filepath.Ext(path))
+		if mimeType == "" {
+			return nil, nil, "", nil, fmt.Errorf("Unknown mime type: Could not determine the mimetype for your file please set the `MIMEType` argument")
+		}
+	}
+	closeFile := func() error { return nil }
+	if f == nil {
+		if f, err = os.Open(path); err != nil {
+			return nil, nil, "", nil, err
+		}
+		closeFile = f.Close
+	} else if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, "", nil, err
+	}
+
+	tracker.total = info.Size()
+	headers := http.Header{}
+	headers.Add("X-Goog-Upload-Header-Content-Length", strconv.FormatInt(info.Size(), 10))
+	headers.Add("X-Goog-Upload-File-Name", filepath.Base(path))
+	return closeFile, &progressReader{r: f, tracker: tracker}, mimeType, &genai.HTTPOptions{Headers: headers}, nil
+}
@@ -0,0 +1,304 @@
+package gemini
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/gemini/fake"
+)
+
+// eventRecorder collects progress events.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []ProgressEvent
+}
+
+func (r *eventRecorder) Report(e ProgressEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+// types returns the event types with consecutive duplicates collapsed.
+func (r *eventRecorder) types() []ProgressEventType {
+	var types []ProgressEventType
+	for _, e := range r.events {
+		if len(types) == 0 || types[len(types)-1] != e.Type {
+			types = append(types, e.Type)
+		}
+	}
+	return types
+}
+
+func (r *eventRecorder) last() ProgressEvent {
+	return r.events[len(r.events)-1]
+}
+
+func TestUploadFileProgress(t *testing.T) {
+	ctx := context.Background()
+	srv := fake.NewServer(&fake.Options{
+		IndexingDelay: 30 * time.Millisecond,
+		FailIndexing:  func(displayName string) bool { return displayName == "broken.txt" },
+	})
+	defer srv.Close()
+	client, err := NewClientWithConfig(ctx, &ClientConfig{APIKey: "test-key", BaseURL: srv.URL, PollInterval: 5 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	store, err := client.CreateStore(ctx, "Progress")
+	if err != nil {
+		t.Fatalf("CreateStore failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(path, []byte("some notes about progress reporting"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		path      string
+		opts      *UploadFileOptions
+		wantTypes []ProgressEventType
+		wantErr   bool
+		check     func(t *testing.T, last ProgressEvent)
+	}{
+		{
+			name:      "store upload",
+			path:      path,
+			opts:      &UploadFileOptions{StoreName: store.Name},
+			wantTypes: []ProgressEventType{ProgressUploadStarted, ProgressBytesSent, ProgressOperationCreated, ProgressPolling, ProgressDone},
+			check: func(t *testing.T, last ProgressEvent) {
+				if last.Document == "" || last.Operation == "" || last.Store != store.Name || last.Kind != OperationTypeUpload {
+					t.Errorf("expected document, operation and store on done event: %+v", last)
+				}
+			},
+		},
+		{
+			name:      "files upload",
+			path:      path,
+			opts:      &UploadFileOptions{},
+			wantTypes: []ProgressEventType{ProgressUploadStarted, ProgressBytesSent, ProgressDone},
+			check: func(t *testing.T, last ProgressEvent) {
+				if last.File == "" || last.BytesSent != 0 || last.TotalBytes == 0 {
+					t.Errorf("unexpected done event: %+v", last)
+				}
+			},
+		},
+		{
+			name:      "indexing fails",
+			path:      path,
+			opts:      &UploadFileOptions{StoreName: store.Name, DisplayName: "broken.txt"},
+			wantTypes: []ProgressEventType{ProgressUploadStarted, ProgressBytesSent, ProgressOperationCreated, ProgressPolling, ProgressFailed},
+			wantErr:   true,
+			check: func(t *testing.T, last ProgressEvent) {
+				var opErr *OperationError
+				if !errors.As(last.Err, &opErr) {
+					t.Errorf("expected an OperationError, got %v", last.Err)
+				}
+			},
+		},
+		{
+			name:      "missing file",
+			path:      filepath.Join(dir, "missing.txt"),
+			opts:      &UploadFileOptions{StoreName: store.Name},
+			wantTypes: []ProgressEventType{ProgressFailed},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &eventRecorder{}
+			tt.opts.Progress = rec
+			_, err := client.UploadFile(ctx, tt.path, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UploadFile error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got := rec.types()
+			if len(got) != len(tt.wantTypes) {
+				t.Fatalf("event types = %v, want %v", got, tt.wantTypes)
+			}
+			for i := range got {
+				if got[i] != tt.wantTypes[i] {
+					t.Fatalf("event types = %v, want %v", got, tt.wantTypes)
+				}
+			}
+			for _, e := range rec.events {
+				if e.Source != tt.path || e.Time.IsZero() {
+					t.Errorf("event missing source or time: %+v", e)
+				}
+			}
+			if tt.check != nil {
+				tt.check(t, rec.last())
+			}
+		})
+	}
+}
+
+func TestUploadFileFromHandle(t *testing.T) {
+	ctx := context.Background()
+	srv := fake.NewServer(nil)
+	defer srv.Close()
+	client, err := NewClientWithConfig(ctx, &ClientConfig{APIKey: "test-key", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "opened.txt")
+	if err := os.WriteFile(path, []byte("opened content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	// The path only names the upload once the file is open
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := client.UploadFile(ctx, path, &UploadFileOptions{File: f})
+	if err != nil {
+		t.Fatalf("UploadFile failed: %v", err)
+	}
+	if file.SizeBytes == nil || *file.SizeBytes != int64(len("opened content")) {
+		t.Errorf("uploaded size = %v, want %d", file.SizeBytes, len("opened content"))
+	}
+	if _, err := f.Stat(); err != nil {
+		t.Errorf("UploadFile closed the caller's file: %v", err)
+	}
+}
+
+func TestImportFileProgress(t *testing.T) {
+	ctx := context.Background()
+	srv := fake.NewServer(&fake.Options{IndexingDelay: 20 * time.Millisecond})
+	defer srv.Close()
+	client, err := NewClientWithConfig(ctx, &ClientConfig{APIKey: "test-key", BaseURL: srv.URL, PollInterval: 5 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	store, err := client.CreateStore(ctx, "Imports")
+	if err != nil {
+		t.Fatalf("CreateStore failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(path, []byte("imported data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := client.UploadFile(ctx, path, nil)
+	if err != nil {
+		t.Fatalf("UploadFile failed: %v", err)
+	}
+
+	rec := &eventRecorder{}
+	if err := client.ImportFile(ctx, file.Name, store.Name, &ImportFileOptions{Progress: rec}); err != nil {
+		t.Fatalf("ImportFile failed: %v", err)
+	}
+	want := []ProgressEventType{ProgressUploadStarted, ProgressOperationCreated, ProgressPolling, ProgressDone}
+	got := rec.types()
+	if len(got) != len(want) {
+		t.Fatalf("event types = %v, want %v", got, want)
+	}
+	if last := rec.last(); last.Source != file.Name || last.Document == "" || last.Kind != OperationTypeImport {
+		t.Errorf("unexpected done event: %+v", last)
+	}
+}
+
+// cancelOnPoll cancels a context the first time it sees a polling event.
+type cancelOnPoll struct {
+	eventRecorder
+	cancel context.CancelFunc
+}
+
+func (r *cancelOnPoll) Report(e ProgressEvent) {
+	r.eventRecorder.Report(e)
+	if e.Type == ProgressPolling {
+		r.cancel()
+	}
+}
+
+func TestUploadFileCanceled(t *testing.T) {
+	srv := fake.NewServer(&fake.Options{IndexingDelay: 500 * time.Millisecond})
+	defer srv.Close()
+	client, err := NewClientWithConfig(context.Background(), &ClientConfig{APIKey: "test-key", BaseURL: srv.URL, PollInterval: time.Minute})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	store, err := client.CreateStore(context.Background(), "Canceled")
+	if err != nil {
+		t.Fatalf("CreateStore failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "slow.txt")
+	if err := os.WriteFile(path, []byte("slow to index"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rec := &cancelOnPoll{cancel: cancel}
+	start := time.Now()
+	_, err = client.UploadFile(ctx, path, &UploadFileOptions{StoreName: store.Name, Progress: rec})
+	if time.Since(start) > 10*time.Second {
+		t.Fatal("UploadFile kept polling after cancellation")
+	}
+	var canceled *OperationCanceledError
+	if !errors.As(err, &canceled) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected an OperationCanceledError, got %v", err)
+	}
+	if canceled.Name == "" || canceled.Name != rec.last().Operation {
+		t.Errorf("canceled operation = %q, want %q", canceled.Name, rec.last().Operation)
+	}
+	if last := rec.last(); last.Type != ProgressFailed {
+		t.Errorf("last event = %+v, want failed", last)
+	}
+}
+
+func TestUploadFileLogging(t *testing.T) {
+	srv := fake.NewServer(&fake.Options{IndexingDelay: 20 * time.Millisecond})
+	defer srv.Close()
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := context.Background()
+	client, err := NewClientWithConfig(ctx, &ClientConfig{APIKey: "test-key", BaseURL: srv.URL, PollInterval: 5 * time.Millisecond, Logger: logger})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	store, err := client.CreateStore(ctx, "Logged")
+	if err != nil {
+		t.Fatalf("CreateStore failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("notes about logging"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// No Progress reporter: the logs alone must show where an upload is
+	if _, err := client.UploadFile(ctx, path, &UploadFileOptions{StoreName: store.Name}); err != nil {
+		t.Fatalf("UploadFile failed: %v", err)
+	}
+
+	logs := buf.String()
+	for _, want := range []string{
+		"level=INFO msg=\"upload progress\" event=upload_started source=" + path,
+		"level=DEBUG msg=\"upload progress\" event=operation_created",
+		"level=DEBUG msg=\"upload progress\" event=polling",
+		"level=INFO msg=\"upload progress\" event=done",
+		"store=" + store.Name,
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("logs missing %q:\n%s", want, logs)
+		}
+	}
+	if strings.Contains(logs, "bytes_sent") {
+		t.Errorf("bytes_sent events should not be logged:\n%s", logs)
+	}
+}
@@ -0,0 +1,89 @@
+package gemini
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// TransportOptions configures the HTTP client used to reach the API.
+// All fields are optional; the zero value yields the SDK's default client.
+type TransportOptions struct {
+	// ProxyURL routes all requests through this proxy. If empty, the standard
+	// HTTPS_PROXY/HTTP_PROXY/NO_PROXY environment variables are honoured.
+	ProxyURL string
+	// CACertFile is a PEM bundle of additional trusted root certificates,
+	// e.g. for a TLS-inspecting corporate proxy. System roots are kept.
+	CACertFile string
+	// ClientCertFile and ClientKeyFile enable mutual TLS. Both must be set.
+	ClientCertFile string
+	ClientKeyFile  string
+	// Timeout limits each HTTP request, including upload bodies. Zero means no limit.
+	Timeout time.Duration
+}
+
+func (o *TransportOptions) isZero() bool {
+	return o == nil || *o == TransportOptions{}
+}
+
+// NewHTTPClient builds an HTTP client from the transport options.
+// It returns nil if no options are set so the SDK can use its default client.
+func NewHTTPClient(opts *TransportOptions) (*http.Client, error) {
+	if opts.isZero() {
+		return nil, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if opts.ProxyURL != "" {
+		proxyURL, err := url.Parse(opts.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL %q: %w", opts.ProxyURL, err)
+		}
+		if proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q: must include scheme and host", opts.ProxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if opts.CACertFile != "" || opts.ClientCertFile != "" || opts.ClientKeyFile != "" {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+		if opts.CACertFile != "" {
+			pem, err := os.ReadFile(opts.CACertFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+			}
+			pool, err := x509.SystemCertPool()
+			if err != nil || pool == nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in CA bundle: %s", opts.CACertFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+
+		if opts.ClientCertFile != "" || opts.ClientKeyFile != "" {
+			if opts.ClientCertFile == "" || opts.ClientKeyFile == "" {
+				return nil, fmt.Errorf("mTLS requires both a client certificate and a client key")
+			}
+			cert, err := tls.LoadX509KeyPair(opts.ClientCertFile, opts.ClientKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load client certificate: %w", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   opts.Timeout,
+	}, nil
+}
@@ -0,0 +1,155 @@
+package gemini
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPClientDefaults(t *testing.T) {
+	for _, opts := range []*TransportOptions{nil, {}} {
+		client, err := NewHTTPClient(opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if client != nil {
+			t.Errorf("expected nil client for empty options, got %v", client)
+		}
+	}
+}
+
+func TestNewHTTPClientOptions(t *testing.T) {
+	t.Run("proxy", func(t *testing.T) {
+		client, err := NewHTTPClient(&TransportOptions{ProxyURL: "http://proxy.internal:3128"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		transport := client.Transport.(*http.Transport)
+		req, _ := http.NewRequest(http.MethodGet, "https://generativelanguage.googleapis.com/", nil)
+		proxyURL, err := transport.Proxy(req)
+		if err != nil {
+			t.Fatalf("proxy func failed: %v", err)
+		}
+		if proxyURL == nil || proxyURL.Host != "proxy.internal:3128" {
+			t.Errorf("expected proxy.internal:3128, got %v", proxyURL)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		client, err := NewHTTPClient(&TransportOptions{Timeout: 30 * time.Second})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if client.Timeout != 30*time.Second {
+			t.Errorf("expected 30s timeout, got %v", client.Timeout)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		opts    *TransportOptions
+		wantErr string
+	}{
+		{
+			name:    "proxy without scheme",
+			opts:    &TransportOptions{ProxyURL: "proxy.internal:3128"},
+			wantErr: "invalid proxy URL",
+		},
+		{
+			name:    "missing CA bundle",
+			opts:    &TransportOptions{CACertFile: filepath.Join(t.TempDir(), "missing.pem")},
+			wantErr: "failed to read CA bundle",
+		},
+		{
+			name:    "client cert without key",
+			opts:    &TransportOptions{ClientCertFile: "cert.pem"},
+			wantErr: "requires both",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewHTTPClient(tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	t.Run("CA bundle without certificates", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "empty.pem")
+		if err := os.WriteFile(path, []byte("not a certificate"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := NewHTTPClient(&TransportOptions{CACertFile: path})
+		if err == nil || !strings.Contains(err.Error(), "no certificates") {
+			t.Errorf("expected no certificates error, got %v", err)
+		}
+	})
+}
+
+func TestNewHTTPClientCustomCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	// Without the server's CA, the request must fail verification
+	resp, err := http.Get(server.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("expected TLS verification failure without custom CA")
+	}
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caPath, caPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewHTTPClient(&TransportOptions{CACertFile: caPath})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err = client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request with custom CA failed: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestNewClientWithConfigBaseURL(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"fileSearchStores": [{"name": "fileSearchStores/abc", "displayName": "Local"}]}`)
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithConfig(context.Background(), &ClientConfig{
+		APIKey:  "test-key",
+		BaseURL: server.URL,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	stores, err := client.ListStores(context.Background())
+	if err != nil {
+		t.Fatalf("ListStores failed: %v", err)
+	}
+	if len(stores) != 1 || stores[0].DisplayName != "Local" {
+		t.Errorf("unexpected stores: %+v", stores)
+	}
+	if !strings.HasSuffix(gotPath, "/fileSearchStores") {
+		t.Errorf("expected request to the fileSearchStores endpoint, got %s", gotPath)
+	}
+}
@@ -0,0 +1,209 @@
+// Package grounding extracts and renders the sources cited by grounded
+// (File Search) responses.
+package grounding
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// SnippetLength is the maximum length of a source snippet in the default text output.
+const SnippetLength = 200
+
+var (
+	// pageMarker matches the page markers some document converters insert, e.g. "--- PAGE 17 ---"
+	pageMarker = regexp.MustCompile(`--- PAGE (\d+) ---`)
+	blankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// Source is a grounding chunk cited by a response.
+type Source struct {
+	// Index is the 1-based position of the chunk in the response's grounding chunks.
+	Index int
+	// Web is true for web search results and false for retrieved documents.
+	Web          bool
+	Title        string
+	URI          string
+	DocumentName string
+	// FirstPage and LastPage are the pages the chunk spans, or 0 if unknown.
+	FirstPage int
+	LastPage  int
+	Text      string
+}
+
+// Pages formats the page span, e.g. "Page 3" or "Pages 3-5". It returns "" if unknown.
+func (s *Source) Pages() string {
+	switch {
+	case s.FirstPage <= 0:
+		return ""
+	case s.LastPage <= s.FirstPage:
+		return fmt.Sprintf("Page %d", s.FirstPage)
+	default:
+		return fmt.Sprintf("Pages %d-%d", s.FirstPage, s.LastPage)
+	}
+}
+
+// Location formats the URI and page span, e.g. "URI: gs://a/b.pdf, Page 3".
+func (s *Source) Location() string {
+	var parts []string
+	if s.URI != "" {
+		parts = append(parts, fmt.Sprintf("URI: %s", s.URI))
+	}
+	if pages := s.Pages(); pages != "" {
+		parts = append(parts, pages)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Sources returns the web and document sources in meta, in citation order.
+// Document page numbers come from the RAG chunk page span when present and
+// otherwise from a "--- PAGE N ---" marker in the chunk text.
+func Sources(meta *genai.GroundingMetadata) []Source {
+	if meta == nil {
+		return nil
+	}
+	var sources []Source
+	for i, chunk := range meta.GroundingChunks {
+		switch {
+		case chunk.Web != nil:
+			sources = append(sources, Source{
+				Index: i + 1,
+				Web:   true,
+				Title: chunk.Web.Title,
+				URI:   chunk.Web.URI,
+			})
+		case chunk.RetrievedContext != nil:
+			ctx := chunk.RetrievedContext
+			src := Source{
+				Index:        i + 1,
+				Title:        ctx.Title,
+				URI:          ctx.URI,
+				DocumentName: ctx.DocumentName,
+				Text:         ctx.Text,
+			}
+			if src.Title == "" {
+				src.Title = "Unknown Document"
+			}
+			if ctx.RAGChunk != nil && ctx.RAGChunk.PageSpan != nil && ctx.RAGChunk.PageSpan.FirstPage > 0 {
+				src.FirstPage = int(ctx.RAGChunk.PageSpan.FirstPage)
+				src.LastPage = int(ctx.RAGChunk.PageSpan.LastPage)
+			} else if m := pageMarker.FindStringSubmatch(ctx.Text); m != nil {
+				page, _ := strconv.Atoi(m[1])
+				src.FirstPage, src.LastPage = page, page
+			}
+			if src.LastPage < src.FirstPage {
+				src.LastPage = src.FirstPage
+			}
+			sources = append(sources, src)
+		}
+	}
+	return sources
+}
+
+// Snippet collapses text onto a single line and truncates it to max bytes.
+func Snippet(text string, max int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if max > 3 && len(text) > max {
+		text = text[:max-3] + "..."
+	}
+	return text
+}
+
+// FullText collapses runs of three or more newlines into a single blank line.
+func FullText(text string) string {
+	return blankLines.ReplaceAllString(text, "\n\n")
+}
+
+// Text returns the text parts of the response's candidates joined by newlines.
+func Text(resp *genai.GenerateContentResponse) string {
+	var parts []string
+	for _, cand := range resp.Candidates {
+		if cand.Content == nil {
+			continue
+		}
+		for _, part := range cand.Content.Parts {
+			parts = append(parts, part.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// RenderOptions controls Render's output.
+type RenderOptions struct {
+	// Verbose prints the full text of each source instead of a one-line snippet.
+	Verbose bool
+	// Debug prints the raw grounding metadata as JSON.
+	Debug bool
+}
+
+// Render writes the response text followed by its cited sources, in the CLI's text format.
+func Render(w io.Writer, resp *genai.GenerateContentResponse, opts *RenderOptions) {
+	if opts == nil {
+		opts = &RenderOptions{}
+	}
+	for _, cand := range resp.Candidates {
+		if cand.Content != nil {
+			for _, part := range cand.Content.Parts {
+				fmt.Fprintf(w, "%v\n", part.Text)
+			}
+		}
+		if cand.GroundingMetadata == nil {
+			continue
+		}
+		fmt.Fprintf(w, "\n[Grounding Metadata]\n")
+
+		if opts.Debug {
+			debugJSON, err := json.MarshalIndent(cand.GroundingMetadata, "", "  ")
+			if err == nil {
+				fmt.Fprintln(w, string(debugJSON))
+			}
+		}
+
+		if len(cand.GroundingMetadata.GroundingChunks) == 0 {
+			continue
+		}
+		fmt.Fprintln(w, "\nSources:")
+		for _, src := range Sources(cand.GroundingMetadata) {
+			if src.Web {
+				fmt.Fprintf(w, "  %d. [Web] %s (%s)\n", src.Index, src.Title, src.URI)
+				continue
+			}
+
+			loc := ""
+			if l := src.Location(); l != "" {
+				loc = fmt.Sprintf(" (%s)", l)
+			}
+			fmt.Fprintf(w, "  %d. [Doc] %s%s\n", src.Index, src.Title, loc)
+
+			if src.Text == "" {
+				continue
+			}
+			if opts.Verbose {
+				fmt.Fprintf(w, "     Full Text:\n%s\n", FullText(src.Text))
+			} else {
+				fmt.Fprintf(w, "     Snippet: %s\n", Snippet(src.Text, SnippetLength))
+			}
+		}
+	}
+	if u := resp.UsageMetadata; u != nil {
+		fmt.Fprintf(w, "\n[Usage]\n%s\n", UsageLine(u))
+	}
+}
+
+// UsageLine summarizes token usage on one line. Tool use tokens, the store
+// chunks retrieved for the answer, are only shown when present.
+func UsageLine(u *genai.GenerateContentResponseUsageMetadata) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Tokens: %d prompt (%d cached), ", u.PromptTokenCount, u.CachedContentTokenCount)
+	if u.ToolUsePromptTokenCount > 0 {
+		fmt.Fprintf(&b, "%d tool use, ", u.ToolUsePromptTokenCount)
+	}
+	fmt.Fprintf(&b, "%d response, %d thinking, %d total", u.CandidatesTokenCount, u.ThoughtsTokenCount, u.TotalTokenCount)
+	return b.String()
+}
@@ -0,0 +1,166 @@
+package grounding
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+func TestSources(t *testing.T) {
+	tests := []struct {
+		name      string
+		chunk     *genai.GroundingChunk
+		wantTitle string
+		wantLoc   string
+		wantWeb   bool
+	}{
+		{
+			name:      "web",
+			chunk:     &genai.GroundingChunk{Web: &genai.GroundingChunkWeb{Title: "Go", URI: "https://go.dev"}},
+			wantTitle: "Go",
+			wantWeb:   true,
+		},
+		{
+			name:      "untitled document",
+			chunk:     &genai.GroundingChunk{RetrievedContext: &genai.GroundingChunkRetrievedContext{Text: "text"}},
+			wantTitle: "Unknown Document",
+		},
+		{
+			name: "page span",
+			chunk: &genai.GroundingChunk{RetrievedContext: &genai.GroundingChunkRetrievedContext{
+				Title:    "manual.pdf",
+				URI:      "gs://bucket/manual.pdf",
+				RAGChunk: &genai.RAGChunk{PageSpan: &genai.RAGChunkPageSpan{FirstPage: 3, LastPage: 5}},
+			}},
+			wantTitle: "manual.pdf",
+			wantLoc:   "URI: gs://bucket/manual.pdf, Pages 3-5",
+		},
+		{
+			name: "single page span",
+			chunk: &genai.GroundingChunk{RetrievedContext: &genai.GroundingChunkRetrievedContext{
+				Title:    "manual.pdf",
+				RAGChunk: &genai.RAGChunk{PageSpan: &genai.RAGChunkPageSpan{FirstPage: 7}},
+			}},
+			wantTitle: "manual.pdf",
+			wantLoc:   "Page 7",
+		},
+		{
+			name: "page marker",
+			chunk: &genai.GroundingChunk{RetrievedContext: &genai.GroundingChunkRetrievedContext{
+				Title: "report.pdf",
+				Text:  "intro\n--- PAGE 17 ---\nbody",
+			}},
+			wantTitle: "report.pdf",
+			wantLoc:   "Page 17",
+		},
+		{
+			name: "page span wins over marker",
+			chunk: &genai.GroundingChunk{RetrievedContext: &genai.GroundingChunkRetrievedContext{
+				Title:    "report.pdf",
+				Text:     "--- PAGE 17 ---",
+				RAGChunk: &genai.RAGChunk{PageSpan: &genai.RAGChunkPageSpan{FirstPage: 2, LastPage: 2}},
+			}},
+			wantTitle: "report.pdf",
+			wantLoc:   "Page 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sources := Sources(&genai.GroundingMetadata{GroundingChunks: []*genai.GroundingChunk{{}, tt.chunk}})
+			if len(sources) != 1 {
+				t.Fatalf("expected one source, got %+v", sources)
+			}
+			src := sources[0]
+			if src.Index != 2 {
+				t.Errorf("Index = %d, want 2", src.Index)
+			}
+			if src.Title != tt.wantTitle || src.Web != tt.wantWeb {
+				t.Errorf("got title %q web %v, want %q %v", src.Title, src.Web, tt.wantTitle, tt.wantWeb)
+			}
+			if !tt.wantWeb && src.Location() != tt.wantLoc {
+				t.Errorf("Location() = %q, want %q", src.Location(), tt.wantLoc)
+			}
+		})
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		max  int
+		want string
+	}{
+		{"collapses whitespace", "a\n b\r\n\tc", 200, "a b c"},
+		{"truncates", strings.Repeat("x", 10), 8, "xxxxx..."},
+		{"fits", "short", 5, "short"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Snippet(tt.text, tt.max); got != tt.want {
+				t.Errorf("Snippet() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	resp := &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{
+		Content: genai.NewContentFromText("The answer.", genai.RoleModel),
+		GroundingMetadata: &genai.GroundingMetadata{GroundingChunks: []*genai.GroundingChunk{
+			{RetrievedContext: &genai.GroundingChunkRetrievedContext{Title: "doc.txt", Text: "line one\n\n\n\nline two"}},
+		}},
+	}}}
+
+	tests := []struct {
+		name string
+		opts *RenderOptions
+		want string
+	}{
+		{"default", nil, "The answer.\n\n[Grounding Metadata]\n\nSources:\n  1. [Doc] doc.txt\n     Snippet: line one line two\n"},
+		{"verbose", &RenderOptions{Verbose: true}, "The answer.\n\n[Grounding Metadata]\n\nSources:\n  1. [Doc] doc.txt\n     Full Text:\nline one\n\nline two\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			Render(&b, resp, tt.opts)
+			if b.String() != tt.want {
+				t.Errorf("Render() =\n%q\nwant\n%q", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderUsage(t *testing.T) {
+	tests := []struct {
+		name  string
+		usage *genai.GenerateContentResponseUsageMetadata
+		want  string
+	}{
+		{"none", nil, "The answer.\n"},
+		{
+			"all counts",
+			&genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 120, CachedContentTokenCount: 100, ToolUsePromptTokenCount: 400, CandidatesTokenCount: 30, ThoughtsTokenCount: 12, TotalTokenCount: 562},
+			"The answer.\n\n[Usage]\nTokens: 120 prompt (100 cached), 400 tool use, 30 response, 12 thinking, 562 total\n",
+		},
+		{
+			"no tool use",
+			&genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 8, CandidatesTokenCount: 2, TotalTokenCount: 10},
+			"The answer.\n\n[Usage]\nTokens: 8 prompt (0 cached), 2 response, 0 thinking, 10 total\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &genai.GenerateContentResponse{
+				Candidates:    []*genai.Candidate{{Content: genai.NewContentFromText("The answer.", genai.RoleModel)}},
+				UsageMetadata: tt.usage,
+			}
+			var b strings.Builder
+			Render(&b, resp, nil)
+			if b.String() != tt.want {
+				t.Errorf("Render() =\n%q\nwant\n%q", b.String(), tt.want)
+			}
+		})
+	}
+}
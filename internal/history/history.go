@@ -0,0 +1,259 @@
+// Package history keeps a local record of the questions asked with the
+// query command and the answers they got, so they can be found and rerun.
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/grounding"
+	"github.com/mikesmitty/file-search/internal/jsonl"
+	"google.golang.org/genai"
+)
+
+// Entry is one line of the history: a question and its answer.
+type Entry struct {
+	// ID numbers entries from 1 in the order they were added.
+	ID       int       `json:"id"`
+	Time     time.Time `json:"time"`
+	Question string    `json:"question"`
+	// Store is the resource name of the store queried, and StoreName the
+	// name it was given by, when that was a display name.
+	Store          string   `json:"store,omitempty"`
+	StoreName      string   `json:"storeName,omitempty"`
+	MetadataFilter string   `json:"metadataFilter,omitempty"`
+	Model          string   `json:"model"`
+	Answer         string   `json:"answer"`
+	Sources        []Source `json:"sources,omitempty"`
+	// Cached is true when the answer came from the local query cache.
+	Cached bool `json:"cached,omitempty"`
+	// RerunOf is the ID of the entry this one reran, if any.
+	RerunOf int `json:"rerunOf,omitempty"`
+}
+
+// Source is a document or web page cited by an answer.
+type Source struct {
+	Index        int    `json:"index"`
+	Web          bool   `json:"web,omitempty"`
+	Title        string `json:"title"`
+	URI          string `json:"uri,omitempty"`
+	DocumentName string `json:"documentName,omitempty"`
+	FirstPage    int    `json:"firstPage,omitempty"`
+	LastPage     int    `json:"lastPage,omitempty"`
+	// Snippet is the start of the cited chunk, on one line.
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// Grounding returns s as a grounding.Source, for formatting.
+func (s *Source) Grounding() grounding.Source {
+	return grounding.Source{
+		Index:        s.Index,
+		Web:          s.Web,
+		Title:        s.Title,
+		URI:          s.URI,
+		DocumentName: s.DocumentName,
+		FirstPage:    s.FirstPage,
+		LastPage:     s.LastPage,
+		Text:         s.Snippet,
+	}
+}
+
+// StoreLabel is the store's display name when known, then its resource
+// name, or "-" for a query made without a store.
+func (e *Entry) StoreLabel() string {
+	switch {
+	case e.StoreName != "":
+		return e.StoreName
+	case e.Store != "":
+		return e.Store
+	}
+	return "-"
+}
+
+// FromResponse returns the entry for a query and its response. Time and ID
+// are set when the entry is added.
+func FromResponse(resp *genai.GenerateContentResponse, question, store, metadataFilter, model string) *Entry {
+	e := &Entry{
+		Question:       question,
+		Store:          store,
+		MetadataFilter: metadataFilter,
+		Model:          strings.TrimPrefix(model, "models/"),
+		Answer:         grounding.Text(resp),
+	}
+	for _, cand := range resp.Candidates {
+		for _, src := range grounding.Sources(cand.GroundingMetadata) {
+			e.Sources = append(e.Sources, Source{
+				Index:        src.Index,
+				Web:          src.Web,
+				Title:        src.Title,
+				URI:          src.URI,
+				DocumentName: src.DocumentName,
+				FirstPage:    src.FirstPage,
+				LastPage:     src.LastPage,
+				Snippet:      grounding.Snippet(src.Text, grounding.SnippetLength),
+			})
+		}
+	}
+	return e
+}
+
+// History is a JSON Lines file of entries. It is safe for concurrent use
+// within a process; entries added by concurrent processes may share an ID.
+type History struct {
+	path string
+	now  func() time.Time
+	mu   sync.Mutex
+}
+
+// New returns a History stored at path. The file and its directory are
+// created when the first entry is added.
+func New(path string) *History {
+	return &History{path: path, now: time.Now}
+}
+
+// Path returns the history file's path.
+func (h *History) Path() string {
+	return h.path
+}
+
+// Add stamps e with the next ID and the current time and appends it. A
+// partial entry left at the end by an interrupted write is dropped.
+func (h *History) Add(e *Entry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	f, err := jsonl.Open(h.path)
+	if err != nil {
+		return fmt.Errorf("failed to open query history: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read query history: %w", err)
+	}
+	last, end, err := lastID(f, info.Size())
+	if err != nil {
+		return fmt.Errorf("failed to read query history: %w", err)
+	}
+
+	e.ID = last + 1
+	e.Time = h.now().UTC()
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode query history entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	// Keep the last entry's newline, and add one if it has none
+	keep := end
+	if end > 0 {
+		keep = min(end+1, info.Size())
+	}
+	if keep < info.Size() {
+		if err := f.Truncate(keep); err != nil {
+			return fmt.Errorf("failed to repair query history: %w", err)
+		}
+	}
+	if keep > 0 && keep == end {
+		line = append([]byte{'\n'}, line...)
+	}
+	if _, err := f.Write(line); err != nil {
+		return fmt.Errorf("failed to write query history: %w", err)
+	}
+	return f.Close()
+}
+
+// lastID returns the ID of the last entry in f, which is size bytes long,
+// and the offset where that entry's line ends (before its newline), or 0
+// and 0 if it has none. Only the end of the file is read, growing the window
+// until it holds a whole line. An unparseable last line is taken to be cut
+// short by an interrupted write and skipped.
+func lastID(f *os.File, size int64) (int, int64, error) {
+	end := size
+	skipped := false
+	for window := int64(64 * 1024); ; window *= 2 {
+		n := min(window, end)
+		data := make([]byte, n)
+		if _, err := f.ReadAt(data, end-n); err != nil && err != io.EOF {
+			return 0, 0, err
+		}
+		data = bytes.TrimRight(data, "\n")
+		start := bytes.LastIndexByte(data, '\n')
+		if start < 0 && n < end {
+			continue
+		}
+		if len(data) == 0 {
+			return 0, 0, nil
+		}
+		var last struct {
+			ID int `json:"id"`
+		}
+		err := json.Unmarshal(data[start+1:], &last)
+		if err == nil {
+			return last.ID, end - n + int64(len(data)), nil
+		}
+		if skipped {
+			return 0, 0, err
+		}
+		// Look again before the partial line
+		skipped = true
+		end -= n - int64(start+1)
+		window = 32 * 1024
+	}
+}
+
+// Read returns the entries in the history at path newer than since, oldest
+// first. A missing history has no entries, and an unparseable last line,
+// left by an interrupted write, is ignored.
+func Read(path string, since time.Time) ([]Entry, error) {
+	var entries []Entry
+	err := jsonl.Read(path, func(e Entry) {
+		if !e.Time.Before(since) {
+			entries = append(entries, e)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read query history: %w", err)
+	}
+	return entries, nil
+}
+
+// Get returns the entry with the given ID.
+func Get(path string, id int) (*Entry, error) {
+	entries, err := Read(path, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if entries[i].ID == id {
+			return &entries[i], nil
+		}
+	}
+	return nil, fmt.Errorf("query history entry not found: %d", id)
+}
+
+// Search returns the entries whose question, answer or store contain every
+// word of text, ignoring case.
+func Search(entries []Entry, text string) []Entry {
+	words := strings.Fields(strings.ToLower(text))
+	matches := []Entry{}
+	for _, e := range entries {
+		haystack := strings.ToLower(strings.Join([]string{e.Question, e.Answer, e.StoreName, e.Store}, "\n"))
+		found := true
+		for _, w := range words {
+			if !strings.Contains(haystack, w) {
+				found = false
+				break
+			}
+		}
+		if found {
+			matches = append(matches, e)
+		}
+	}
+	return matches
+}
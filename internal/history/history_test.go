@@ -0,0 +1,192 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+func TestFromResponse(t *testing.T) {
+	resp := &genai.GenerateContentResponse{
+		Candidates: []*genai.Candidate{{
+			Content: genai.NewContentFromText("Twenty days.", genai.RoleModel),
+			GroundingMetadata: &genai.GroundingMetadata{GroundingChunks: []*genai.GroundingChunk{
+				{RetrievedContext: &genai.GroundingChunkRetrievedContext{
+					Title:        "vacation.txt",
+					DocumentName: "fileSearchStores/hr/documents/vacation",
+					Text:         "--- PAGE 3 ---\nEmployees   accrue\nvacation days.",
+				}},
+				{Web: &genai.GroundingChunkWeb{Title: "Example", URI: "https://example.com"}},
+			}},
+		}},
+	}
+	e := FromResponse(resp, "How many vacation days?", "fileSearchStores/hr", `team = "hr"`, "models/gemini-2.5-flash")
+	want := &Entry{
+		Question:       "How many vacation days?",
+		Store:          "fileSearchStores/hr",
+		MetadataFilter: `team = "hr"`,
+		Model:          "gemini-2.5-flash",
+		Answer:         "Twenty days.",
+		Sources: []Source{
+			{Index: 1, Title: "vacation.txt", DocumentName: "fileSearchStores/hr/documents/vacation", FirstPage: 3, LastPage: 3, Snippet: "--- PAGE 3 --- Employees accrue vacation days."},
+			{Index: 2, Web: true, Title: "Example", URI: "https://example.com"},
+		},
+	}
+	if !reflect.DeepEqual(e, want) {
+		t.Errorf("FromResponse =\n%+v\nwant\n%+v", e, want)
+	}
+}
+
+func TestAddAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dir", "history.jsonl")
+	if entries, err := Read(path, time.Time{}); err != nil || entries != nil {
+		t.Fatalf("Read of a missing history = %v, %v", entries, err)
+	}
+
+	h := New(path)
+	clock := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return clock }
+	for _, q := range []string{"first", "second", "third"} {
+		e := &Entry{Question: q, Model: "gemini-2.5-flash"}
+		if err := h.Add(e); err != nil {
+			t.Fatal(err)
+		}
+		if !e.Time.Equal(clock) {
+			t.Errorf("Add set time %v, want %v", e.Time, clock)
+		}
+		clock = clock.Add(time.Hour)
+	}
+
+	entries, err := Read(path, time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].ID != 2 || entries[0].Question != "second" || entries[1].ID != 3 {
+		t.Errorf("Read since 10:00 = %+v", entries)
+	}
+
+	e, err := Get(path, 1)
+	if err != nil || e.Question != "first" {
+		t.Errorf("Get(1) = %+v, %v", e, err)
+	}
+	if _, err := Get(path, 4); err == nil || !strings.Contains(err.Error(), "not found: 4") {
+		t.Errorf("Get(4) error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("history mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestAddAfterLongEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	h := New(path)
+	// Longer than the first window lastID reads
+	if err := h.Add(&Entry{Question: "long", Answer: strings.Repeat("a", 200*1024)}); err != nil {
+		t.Fatal(err)
+	}
+	e := &Entry{Question: "next"}
+	if err := h.Add(e); err != nil {
+		t.Fatal(err)
+	}
+	if e.ID != 2 {
+		t.Errorf("ID after a long entry = %d, want 2", e.ID)
+	}
+}
+
+func TestInterruptedWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	h := New(path)
+	for _, q := range []string{"first", "second"} {
+		if err := h.Add(&Entry{Question: q}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"id":3,"time":"2026-10-01T09:00:00Z","quest`)
+	f.Close()
+
+	if entries, err := Read(path, time.Time{}); err != nil || len(entries) != 2 {
+		t.Fatalf("Read with a partial last line = %+v, %v", entries, err)
+	}
+	if e, err := Get(path, 2); err != nil || e.Question != "second" {
+		t.Errorf("Get(2) = %+v, %v", e, err)
+	}
+	e := &Entry{Question: "third"}
+	if err := h.Add(e); err != nil {
+		t.Fatal(err)
+	}
+	if e.ID != 3 {
+		t.Errorf("ID after a partial line = %d, want 3", e.ID)
+	}
+	entries, err := Read(path, time.Time{})
+	if err != nil || len(entries) != 3 || entries[2].Question != "third" {
+		t.Fatalf("Read after Add = %+v, %v", entries, err)
+	}
+
+	// Only the last line may be invalid
+	f, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("garbage\n{\"id\":4}\n")
+	f.Close()
+	if _, err := Read(path, time.Time{}); err == nil || !strings.Contains(err.Error(), "history.jsonl:4") {
+		t.Errorf("Read with an invalid line before the last = %v", err)
+	}
+}
+
+func TestAddAfterUnterminatedEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	if err := os.WriteFile(path, []byte(`{"id":1,"question":"first"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	e := &Entry{Question: "second"}
+	if err := New(path).Add(e); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := Read(path, time.Time{})
+	if err != nil || len(entries) != 2 || e.ID != 2 || entries[1].Question != "second" {
+		t.Errorf("after adding to an unterminated entry: ID %d, entries %+v, %v", e.ID, entries, err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	entries := []Entry{
+		{ID: 1, Question: "How many vacation days?", Answer: "Twenty days per year.", StoreName: "Handbook"},
+		{ID: 2, Question: "When do deploys happen?", Answer: "Every Tuesday.", Store: "fileSearchStores/eng"},
+		{ID: 3, Question: "Calibration procedure", Answer: "Takes ten minutes.", StoreName: "Manuals"},
+	}
+	for _, tt := range []struct {
+		text string
+		want []int
+	}{
+		{"VACATION", []int{1}},
+		{"days twenty", []int{1}},
+		{"tuesday", []int{2}},
+		{"handbook", []int{1}},
+		{"filesearchstores/eng", []int{2}},
+		{"ten days", nil},
+		{"", []int{1, 2, 3}},
+	} {
+		var got []int
+		for _, e := range Search(entries, tt.text) {
+			got = append(got, e.ID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package history
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Render writes an entry in the CLI's text format: what was asked, then the
+// answer and its sources as the query command printed them.
+func Render(w io.Writer, e *Entry) {
+	fmt.Fprintf(w, "ID: %d\n", e.ID)
+	fmt.Fprintf(w, "Time: %s\n", e.Time.Local().Format(time.RFC3339))
+	fmt.Fprintf(w, "Question: %s\n", e.Question)
+	fmt.Fprintf(w, "Store: %s\n", e.StoreLabel())
+	if e.MetadataFilter != "" {
+		fmt.Fprintf(w, "Metadata Filter: %s\n", e.MetadataFilter)
+	}
+	fmt.Fprintf(w, "Model: %s\n", e.Model)
+	if e.RerunOf > 0 {
+		fmt.Fprintf(w, "Rerun Of: %d\n", e.RerunOf)
+	}
+	if e.Cached {
+		fmt.Fprintln(w, "Cached: true")
+	}
+	fmt.Fprintf(w, "\n%s\n", e.Answer)
+	if len(e.Sources) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nSources:")
+	for _, line := range sourceLines(e) {
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+}
+
+// sourceLines formats each source on one line, as the query command lists them.
+func sourceLines(e *Entry) []string {
+	lines := make([]string, 0, len(e.Sources))
+	for i := range e.Sources {
+		src := e.Sources[i].Grounding()
+		if src.Web {
+			lines = append(lines, fmt.Sprintf("%d. [Web] %s (%s)", src.Index, src.Title, src.URI))
+			continue
+		}
+		line := fmt.Sprintf("%d. [Doc] %s", src.Index, src.Title)
+		if l := src.Location(); l != "" {
+			line += fmt.Sprintf(" (%s)", l)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Compare writes two answers to a question side by side, in columns that
+// fit within width, for comparing models or stores.
+func Compare(w io.Writer, left, right *Entry, width int) {
+	col := max((width-3)/2, 20)
+	fmt.Fprintf(w, "Question: %s\n\n", left.Question)
+	lh, lb := compareColumn(left, col)
+	rh, rb := compareColumn(right, col)
+	// Pad the headings to the same height so the rule lines up
+	for len(lh) < len(rh) {
+		lh = append(lh, "")
+	}
+	for len(rh) < len(lh) {
+		rh = append(rh, "")
+	}
+	row := func(a, b string) {
+		line := a + strings.Repeat(" ", col-utf8.RuneCountInString(a)) + " | " + b
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+	for i := range lh {
+		row(lh[i], rh[i])
+	}
+	fmt.Fprintf(w, "%s-+-%s\n", strings.Repeat("-", col), strings.Repeat("-", col))
+	for i := range max(len(lb), len(rb)) {
+		var a, b string
+		if i < len(lb) {
+			a = lb[i]
+		}
+		if i < len(rb) {
+			b = rb[i]
+		}
+		row(a, b)
+	}
+}
+
+// compareColumn lays out an entry's heading and body as lines of at most
+// width runes.
+func compareColumn(e *Entry, width int) (head, body []string) {
+	head = wrap(fmt.Sprintf("#%d %s, %s", e.ID, e.Model, e.StoreLabel()), width)
+	if e.MetadataFilter != "" {
+		head = append(head, wrap("Filter: "+e.MetadataFilter, width)...)
+	}
+	for _, para := range strings.Split(e.Answer, "\n") {
+		body = append(body, wrap(para, width)...)
+	}
+	if len(e.Sources) > 0 {
+		body = append(body, "", "Sources:")
+		for _, s := range sourceLines(e) {
+			for _, line := range wrap(s, width-2) {
+				body = append(body, "  "+line)
+			}
+		}
+	}
+	return head, body
+}
+
+// wrap breaks text into lines of at most width runes at spaces, splitting
+// words longer than a line. Empty text is one empty line.
+func wrap(text string, width int) []string {
+	var lines []string
+	var line []rune
+	for _, word := range strings.Fields(text) {
+		runes := []rune(word)
+		for len(runes) > 0 {
+			switch {
+			case len(line) == 0 && len(runes) > width:
+				lines = append(lines, string(runes[:width]))
+				runes = runes[width:]
+			case len(line) == 0:
+				line, runes = runes, nil
+			case len(line)+1+len(runes) <= width:
+				line = append(append(line, ' '), runes...)
+				runes = nil
+			default:
+				lines = append(lines, string(line))
+				line = nil
+			}
+		}
+	}
+	if len(line) > 0 || len(lines) == 0 {
+		lines = append(lines, string(line))
+	}
+	return lines
+}
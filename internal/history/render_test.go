@@ -0,0 +1,90 @@
+package history
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestWrap(t *testing.T) {
+	for _, tt := range []struct {
+		text  string
+		width int
+		want  []string
+	}{
+		{"", 10, []string{""}},
+		{"short", 10, []string{"short"}},
+		{"one two three four", 9, []string{"one two", "three", "four"}},
+		{"  extra   spaces  ", 20, []string{"extra spaces"}},
+		{"abcdefghijkl xy", 5, []string{"abcde", "fghij", "kl xy"}},
+		{"héllo wörld", 5, []string{"héllo", "wörld"}},
+	} {
+		if got := wrap(tt.text, tt.width); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("wrap(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	left := &Entry{
+		ID: 3, Question: "How many vacation days?", Model: "gemini-2.5-flash", StoreName: "Handbook",
+		Answer:  "Employees accrue vacation monthly, up to twenty days per year.",
+		Sources: []Source{{Index: 1, Title: "vacation.txt", FirstPage: 2, LastPage: 2}},
+	}
+	right := &Entry{
+		ID: 5, Question: "How many vacation days?", Model: "gemini-2.5-pro", StoreName: "Handbook", MetadataFilter: `team = "hr"`,
+		Answer: "Twenty.",
+	}
+	var b strings.Builder
+	Compare(&b, left, right, 63)
+	got := b.String()
+	want := `Question: How many vacation days?
+
+#3 gemini-2.5-flash, Handbook  | #5 gemini-2.5-pro, Handbook
+                               | Filter: team = "hr"
+-------------------------------+-------------------------------
+Employees accrue vacation      | Twenty.
+monthly, up to twenty days per |
+year.                          |
+                               |
+Sources:                       |
+  1. [Doc] vacation.txt (Page  |
+  2)                           |
+`
+	if got != want {
+		t.Errorf("Compare =\n%s\nwant\n%s", got, want)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(got, "\n"), "\n") {
+		if n := utf8.RuneCountInString(line); n > 63 {
+			t.Errorf("line is %d wide, over 63: %q", n, line)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	e := &Entry{
+		ID: 7, Question: "When do deploys happen?", Model: "gemini-2.5-flash", Store: "fileSearchStores/eng",
+		Answer: "Every Tuesday.", RerunOf: 2, Cached: true,
+		Sources: []Source{{Index: 1, Title: "deploys.txt", URI: "gs://docs/deploys.txt"}, {Index: 2, Web: true, Title: "Blog", URI: "https://example.com"}},
+	}
+	var b strings.Builder
+	Render(&b, e)
+	for _, want := range []string{
+		"ID: 7\n",
+		"Question: When do deploys happen?\n",
+		"Store: fileSearchStores/eng\n",
+		"Rerun Of: 2\n",
+		"Cached: true\n",
+		"\nEvery Tuesday.\n",
+		"  1. [Doc] deploys.txt (URI: gs://docs/deploys.txt)\n",
+		"  2. [Web] Blog (https://example.com)\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("Render output lacks %q:\n%s", want, b.String())
+		}
+	}
+	if strings.Contains(b.String(), "Metadata Filter") {
+		t.Errorf("Render shows an empty metadata filter:\n%s", b.String())
+	}
+}
@@ -0,0 +1,110 @@
+// Package jsonl reads and appends to the JSON Lines files the CLI keeps its
+// logs and history in.
+package jsonl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// maxLineSize bounds a single line. Query answers with many sources make for
+// long lines.
+const maxLineSize = 16 * 1024 * 1024
+
+// Open opens the file at path for reading and appending. The file and its
+// directory are created, readable only by the user, if they don't exist.
+func Open(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
+}
+
+// Append encodes v and appends it to the file at path as a single line. A
+// partial last line, left by an interrupted write, is dropped first so that
+// it can't run into the new one.
+func Append(path string, v any) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	f, err := Open(path)
+	if err != nil {
+		return err
+	}
+	if err := dropPartialLine(f); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// dropPartialLine truncates f after its last newline, reading backwards from
+// the end until it finds one.
+func dropPartialLine(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	end := info.Size()
+	buf := make([]byte, 4096)
+	for end > 0 {
+		n := min(int64(len(buf)), end)
+		if _, err := f.ReadAt(buf[:n], end-n); err != nil {
+			return err
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			end -= n - int64(i+1)
+			break
+		}
+		end -= n
+	}
+	if end == info.Size() {
+		return nil
+	}
+	return f.Truncate(end)
+}
+
+// Read decodes each line of the file at path and calls fn with it, skipping
+// empty lines. A missing file has no lines. A line that doesn't decode is an
+// error unless it is the last one: an append cut short, by a crash say, leaves
+// a partial last line, which is skipped.
+func Read[T any](path string, fn func(T)) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var invalid error
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, maxLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		// Only the last line may be invalid
+		if invalid != nil {
+			return invalid
+		}
+		var v T
+		if err := json.Unmarshal(scanner.Bytes(), &v); err != nil {
+			invalid = fmt.Errorf("invalid entry at %s:%d: %w", path, line, err)
+			continue
+		}
+		fn(v)
+	}
+	return scanner.Err()
+}
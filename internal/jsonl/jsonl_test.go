@@ -0,0 +1,95 @@
+package jsonl
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dir", "log.jsonl")
+	for _, v := range []any{map[string]int{"id": 1}, map[string]int{"id": 2}} {
+		if err := Append(path, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "{\"id\":1}\n{\"id\":2}\n"; got != want {
+		t.Errorf("file = %q, want %q", got, want)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	// A partial last line is dropped rather than run into the next
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"id":`)
+	f.Close()
+	if err := Append(path, map[string]int{"id": 3}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "{\"id\":1}\n{\"id\":2}\n{\"id\":3}\n" {
+		t.Errorf("file after appending to a partial line = %q", data)
+	}
+
+	if err := Append(path, make(chan int)); err == nil {
+		t.Error("expected an error for a value JSON can't encode")
+	}
+}
+
+func TestRead(t *testing.T) {
+	type entry struct {
+		ID int `json:"id"`
+	}
+	read := func(path string) ([]int, error) {
+		var ids []int
+		err := Read(path, func(e entry) { ids = append(ids, e.ID) })
+		return ids, err
+	}
+	dir := t.TempDir()
+
+	if ids, err := read(filepath.Join(dir, "missing.jsonl")); err != nil || ids != nil {
+		t.Errorf("Read of a missing file = %v, %v", ids, err)
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		want    []int
+		wantErr string
+	}{
+		{name: "entries", data: "{\"id\":1}\n\n{\"id\":2}\n", want: []int{1, 2}},
+		{name: "partial last line", data: "{\"id\":1}\n{\"id\":2}\n{\"id\":", want: []int{1, 2}},
+		{name: "invalid line before the last", data: "{\"id\":1}\ngarbage\n{\"id\":3}\n", wantErr: "log.jsonl:2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "log.jsonl")
+			if err := os.WriteFile(path, []byte(tt.data), 0600); err != nil {
+				t.Fatal(err)
+			}
+			ids, err := read(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Read = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("Read = %v, %v, want %v", ids, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// Package logging builds the CLI's structured loggers and logs the HTTP
+// requests made to the API with credentials redacted.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Log formats accepted by New.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options configures New.
+type Options struct {
+	// Level is the minimum level logged (default: slog.LevelInfo).
+	Level slog.Level
+	// Format is FormatText (the default) or FormatJSON.
+	Format string
+	// File, if set, is appended to instead of writing to Writer.
+	File string
+	// Writer receives the logs when File is empty (default: os.Stderr).
+	Writer io.Writer
+}
+
+// ParseLevel parses a level name: debug, info, warn or error.
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("invalid log level: %s (must be 'debug', 'info', 'warn' or 'error')", s)
+}
+
+// Discard returns a logger that drops everything.
+func Discard() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
+
+// New returns a logger writing to opts.File or opts.Writer, and a function
+// that closes the log file, if one was opened.
+func New(opts *Options) (*slog.Logger, func() error, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	noop := func() error { return nil }
+	w, closeFn := opts.Writer, noop
+	if opts.File != "" {
+		if err := os.MkdirAll(filepath.Dir(opts.File), 0700); err != nil {
+			return nil, noop, fmt.Errorf("failed to create log directory: %w", err)
+		}
+		f, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, noop, fmt.Errorf("failed to open log file: %w", err)
+		}
+		w, closeFn = f, f.Close
+	}
+	if w == nil {
+		w = os.Stderr
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: opts.Level}
+	var handler slog.Handler
+	switch strings.ToLower(opts.Format) {
+	case "", FormatText:
+		handler = slog.NewTextHandler(w, handlerOpts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, handlerOpts)
+	default:
+		closeFn()
+		return nil, noop, fmt.Errorf("invalid log format: %s (must be 'text' or 'json')", opts.Format)
+	}
+	return slog.New(handler), closeFn, nil
+}
@@ -0,0 +1,158 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    slog.Level
+		wantErr bool
+	}{
+		{in: "", want: slog.LevelInfo},
+		{in: "DEBUG", want: slog.LevelDebug},
+		{in: "warning", want: slog.LevelWarn},
+		{in: "error", want: slog.LevelError},
+		{in: "trace", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "file-search.log")
+	logger, closeFn, err := New(&Options{Level: slog.LevelWarn, Format: FormatJSON, File: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("dropped")
+	logger.Warn("kept", "file", "notes.txt")
+	if err := closeFn(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var record map[string]any
+	if err := json.Unmarshal(data, &record); err != nil {
+		t.Fatalf("log is not one JSON record: %v\n%s", err, data)
+	}
+	if record["msg"] != "kept" || record["file"] != "notes.txt" {
+		t.Errorf("record = %v", record)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("log file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	if _, _, err := New(&Options{Format: "xml"}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.Error(w, `{"error": {"message": "store not found"}}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("X-Goog-Upload-Status", "active")
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := &http.Client{Transport: Transport(nil, logger)}
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/v1beta/fileSearchStores?key=secret-key&pageSize=10", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Goog-Api-Key", "secret-key")
+	req.Header.Set("Authorization", "Bearer secret-token")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	resp, err = client.Get(srv.URL + "/missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "store not found") {
+		t.Errorf("the caller lost the error body: %q", body)
+	}
+
+	logs := buf.String()
+	for _, secret := range []string{"secret-key", "secret-token"} {
+		if strings.Contains(logs, secret) {
+			t.Errorf("logs contain %s:\n%s", secret, logs)
+		}
+	}
+	for _, want := range []string{
+		`msg="http request" method=GET`,
+		"key=REDACTED",
+		"pageSize=10",
+		"headers.X-Goog-Api-Key=REDACTED",
+		"headers.Authorization=REDACTED",
+		"headers.X-Goog-Upload-Status=active",
+		"status=200",
+		"status=404",
+		`store not found`,
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("logs missing %q:\n%s", want, logs)
+		}
+	}
+}
+
+func TestTransportDisabled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/unavailable" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	client := &http.Client{Transport: Transport(nil, logger)}
+	for _, path := range []string{"/ok", "/unavailable"} {
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if strings.Contains(buf.String(), `msg="http request"`) {
+		t.Errorf("debug request logs written at warn level:\n%s", buf.String())
+	}
+	if strings.Contains(buf.String(), "status=200") {
+		t.Errorf("a successful response was logged at warn level:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "status=503") {
+		t.Errorf("a 503 response was not logged at warn level:\n%s", buf.String())
+	}
+}
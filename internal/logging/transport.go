@@ -0,0 +1,153 @@
+package logging
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// redacted replaces credentials in logged URLs and headers.
+const redacted = "REDACTED"
+
+// sensitiveHeaders are never logged in the clear.
+var sensitiveHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+	"set-cookie":          true,
+	"x-goog-api-key":      true,
+}
+
+// sensitiveParams are query parameters that carry credentials.
+var sensitiveParams = map[string]bool{
+	"key":          true,
+	"access_token": true,
+	"token":        true,
+}
+
+// maxErrorBody caps how much of an error response's body is logged.
+const maxErrorBody = 2048
+
+// RedactURL returns u as a string with credential query parameters replaced.
+func RedactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	q := u.Query()
+	changed := false
+	for name := range q {
+		if sensitiveParams[strings.ToLower(name)] {
+			q.Set(name, redacted)
+			changed = true
+		}
+	}
+	if !changed && u.User == nil {
+		return u.String()
+	}
+	c := *u
+	c.User = nil
+	if changed {
+		c.RawQuery = q.Encode()
+	}
+	return c.String()
+}
+
+// RedactHeaders returns h as a log attribute value, with credential headers
+// replaced.
+func RedactHeaders(h http.Header) slog.Value {
+	attrs := make([]slog.Attr, 0, len(h))
+	for name, values := range h {
+		value := strings.Join(values, ", ")
+		if sensitiveHeaders[strings.ToLower(name)] {
+			value = redacted
+		}
+		attrs = append(attrs, slog.String(name, value))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// Transport wraps base (http.DefaultTransport if nil) to log each request
+// and response at debug level: method, redacted URL and headers, status and
+// duration. Failed requests and 429 or 5xx responses are logged at warn
+// level, and the start of an error response's body is included.
+func Transport(base http.RoundTripper, logger *slog.Logger) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if logger == nil {
+		logger = Discard()
+	}
+	return &transport{base: base, logger: logger}
+}
+
+type transport struct {
+	base   http.RoundTripper
+	logger *slog.Logger
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !t.logger.Enabled(ctx, slog.LevelWarn) {
+		return t.base.RoundTrip(req)
+	}
+	target := RedactURL(req.URL)
+	t.logger.LogAttrs(ctx, slog.LevelDebug, "http request",
+		slog.String("method", req.Method),
+		slog.String("url", target),
+		slog.Any("headers", RedactHeaders(req.Header)),
+		slog.Int64("content_length", req.ContentLength),
+	)
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	elapsed := time.Since(start)
+	if err != nil {
+		t.logger.LogAttrs(ctx, slog.LevelWarn, "http request failed",
+			slog.String("method", req.Method),
+			slog.String("url", target),
+			slog.Duration("elapsed", elapsed),
+			slog.Any("error", err),
+		)
+		return nil, err
+	}
+
+	level := slog.LevelDebug
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		level = slog.LevelWarn
+	}
+	if !t.logger.Enabled(ctx, level) {
+		return resp, nil
+	}
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", target),
+		slog.Int("status", resp.StatusCode),
+		slog.Duration("elapsed", elapsed),
+		slog.Any("headers", RedactHeaders(resp.Header)),
+	}
+	if resp.StatusCode >= 400 {
+		attrs = append(attrs, slog.String("body", peekBody(resp)))
+	}
+	t.logger.LogAttrs(ctx, level, "http response", attrs...)
+	return resp, nil
+}
+
+// peekBody reads the start of resp's body and puts it back for the caller.
+func peekBody(resp *http.Response) string {
+	if resp.Body == nil {
+		return ""
+	}
+	head, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), resp.Body), resp.Body}
+	if err != nil {
+		return ""
+	}
+	return string(head)
+}
@@ -0,0 +1,227 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/mikesmitty/file-search/internal/constants"
+	"github.com/mikesmitty/file-search/internal/gemini"
+	"google.golang.org/genai"
+)
+
+// resourcesAccessKey is the StoreAccess key that applies to resource reads.
+const resourcesAccessKey = "resources"
+
+// StoreAccess restricts which stores each tool may use. Keys are tool names
+// or glob patterns over them ("delete_*", "*"), plus "resources" for
+// resource reads. Values are glob patterns matched against a store's display
+// name and ID; a leading "!" denies instead. A store is allowed for a tool
+// when, for every matching key, it matches none of the deny patterns and, if
+// there are allow patterns, at least one of them. Tools without a matching
+// key may use any store.
+//
+// For example, query any store, upload only to scratch stores and never
+// delete production ones:
+//
+//	StoreAccess{"upload_file": {"scratch-*"}, "delete_*": {"!prod-*"}}
+type StoreAccess map[string][]string
+
+// rulesFor returns the pattern lists that apply to a tool, in key order.
+func (a StoreAccess) rulesFor(tool string) [][]string {
+	keys := make([]string, 0, len(a))
+	for key := range a {
+		if ok, _ := path.Match(key, tool); ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	rules := make([][]string, 0, len(keys))
+	for _, key := range keys {
+		rules = append(rules, a[key])
+	}
+	return rules
+}
+
+// allows reports whether a store, known by any of names, is allowed for tool.
+func (a StoreAccess) allows(tool string, names ...string) bool {
+	for _, patterns := range a.rulesFor(tool) {
+		allowed, hasAllow := false, false
+		for _, pattern := range patterns {
+			deny := strings.HasPrefix(pattern, "!")
+			pattern = strings.TrimPrefix(pattern, "!")
+			matched := false
+			for _, name := range names {
+				if ok, _ := path.Match(pattern, name); ok && name != "" {
+					matched = true
+					break
+				}
+			}
+			if deny && matched {
+				return false
+			}
+			if !deny {
+				hasAllow = true
+				allowed = allowed || matched
+			}
+		}
+		if hasAllow && !allowed {
+			return false
+		}
+	}
+	return true
+}
+
+// describe summarizes the patterns that apply to a tool for error messages.
+func (a StoreAccess) describe(tool string) string {
+	var patterns []string
+	for _, rules := range a.rulesFor(tool) {
+		patterns = append(patterns, rules...)
+	}
+	return strings.Join(patterns, ", ")
+}
+
+type toolNameKey struct{}
+
+// withToolName is a tool handler middleware that records the tool being
+// called, so the store access checks know which rules apply.
+func withToolName(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return next(context.WithValue(ctx, toolNameKey{}, request.Params.Name), request)
+	}
+}
+
+// accessTool returns the StoreAccess key for the current call.
+func accessTool(ctx context.Context) string {
+	if tool, ok := ctx.Value(toolNameKey{}).(string); ok {
+		return tool
+	}
+	return resourcesAccessKey
+}
+
+// restrictedClient enforces StoreAccess on every call that names a store,
+// so each tool and resource is covered without checks of its own.
+type restrictedClient struct {
+	GeminiClient
+	access  StoreAccess
+	denials *denialLog
+}
+
+func newRestrictedClient(client GeminiClient, access StoreAccess, denials *denialLog) GeminiClient {
+	if client == nil || len(access) == 0 {
+		return client
+	}
+	return &restrictedClient{GeminiClient: client, access: access, denials: denials}
+}
+
+// check returns an error if the store is not allowed for the current call.
+// Rules may name stores by display name, so when the caller doesn't know it
+// the store is fetched, and a store whose display name can't be fetched is
+// refused rather than checked against its ID alone.
+func (c *restrictedClient) check(ctx context.Context, storeID, displayName string) error {
+	if len(c.access.rulesFor(accessTool(ctx))) == 0 {
+		return nil
+	}
+	if displayName == "" {
+		store, err := c.GeminiClient.GetStore(ctx, storeID)
+		if err != nil {
+			return fmt.Errorf("cannot check store %s against the server's store access rules for %s: %w", storeID, accessTool(ctx), err)
+		}
+		displayName = store.DisplayName
+	}
+	return c.checkNames(ctx, storeID, displayName)
+}
+
+// checkNames checks a store whose display name is known, recording a
+// refusal in the audit log.
+func (c *restrictedClient) checkNames(ctx context.Context, storeID, displayName string) error {
+	tool := accessTool(ctx)
+	if !c.access.allows(tool, storeID, strings.TrimPrefix(storeID, constants.StoreResourcePrefix), displayName) {
+		err := fmt.Errorf("store %s is not allowed for %s by the server's store access rules (%s)", storeID, tool, c.access.describe(tool))
+		c.denials.record(ctx, "UseStore", []string{storeID}, err.Error())
+		return err
+	}
+	return nil
+}
+
+func (c *restrictedClient) ResolveStoreName(ctx context.Context, nameOrID string) (string, error) {
+	storeID, err := c.GeminiClient.ResolveStoreName(ctx, nameOrID)
+	if err != nil {
+		return "", err
+	}
+	// A store found by display name needn't be fetched again
+	displayName := ""
+	if strings.HasPrefix(storeID, constants.StoreResourcePrefix) && !strings.HasPrefix(nameOrID, constants.StoreResourcePrefix) {
+		displayName = nameOrID
+	}
+	return storeID, c.check(ctx, storeID, displayName)
+}
+
+func (c *restrictedClient) ListStores(ctx context.Context) ([]*genai.FileSearchStore, error) {
+	stores, err := c.GeminiClient.ListStores(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tool := accessTool(ctx)
+	allowed := stores[:0:0]
+	for _, st := range stores {
+		if c.access.allows(tool, st.Name, strings.TrimPrefix(st.Name, constants.StoreResourcePrefix), st.DisplayName) {
+			allowed = append(allowed, st)
+		}
+	}
+	return allowed, nil
+}
+
+func (c *restrictedClient) GetStore(ctx context.Context, name string) (*genai.FileSearchStore, error) {
+	if len(c.access.rulesFor(accessTool(ctx))) == 0 {
+		return c.GeminiClient.GetStore(ctx, name)
+	}
+	store, err := c.GeminiClient.GetStore(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot check store %s against the server's store access rules for %s: %w", name, accessTool(ctx), err)
+	}
+	if err := c.checkNames(ctx, name, store.DisplayName); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func (c *restrictedClient) ListDocuments(ctx context.Context, storeID string) ([]*genai.Document, error) {
+	if err := c.check(ctx, storeID, ""); err != nil {
+		return nil, err
+	}
+	return c.GeminiClient.ListDocuments(ctx, storeID)
+}
+
+func (c *restrictedClient) GetDocument(ctx context.Context, name string) (*genai.Document, error) {
+	if err := c.check(ctx, parentStore(name), ""); err != nil {
+		return nil, err
+	}
+	return c.GeminiClient.GetDocument(ctx, name)
+}
+
+func (c *restrictedClient) DeleteDocument(ctx context.Context, name string, force bool) error {
+	if err := c.check(ctx, parentStore(name), ""); err != nil {
+		return err
+	}
+	return c.GeminiClient.DeleteDocument(ctx, name, force)
+}
+
+func (c *restrictedClient) GetOperation(ctx context.Context, operationName string, operationType gemini.OperationType) (*gemini.OperationStatus, error) {
+	if err := c.check(ctx, parentStore(operationName), ""); err != nil {
+		return nil, err
+	}
+	return c.GeminiClient.GetOperation(ctx, operationName, operationType)
+}
+
+// parentStore returns the store resource name at the start of a document or
+// operation name, e.g. fileSearchStores/abc for fileSearchStores/abc/documents/x.
+func parentStore(name string) string {
+	rest := strings.TrimPrefix(name, constants.StoreResourcePrefix)
+	id, _, _ := strings.Cut(rest, "/")
+	return constants.StoreResourcePrefix + id
+}
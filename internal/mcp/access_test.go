@@ -0,0 +1,193 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mikesmitty/file-search/internal/gemini"
+	"google.golang.org/genai"
+)
+
+func TestStoreAccessAllows(t *testing.T) {
+	access := StoreAccess{
+		"upload_file": {"scratch-*"},
+		"delete_*":    {"!prod-*"},
+		"*":           {"!secret"},
+	}
+	tests := []struct {
+		tool  string
+		store string
+		want  bool
+	}{
+		{"query_knowledge_base", "prod-kb", true},
+		{"query_knowledge_base", "secret", false},
+		{"upload_file", "scratch-notes", true},
+		{"upload_file", "prod-kb", false},
+		{"delete_store", "scratch-notes", true},
+		{"delete_document", "prod-kb", false},
+		{"delete_store", "secret", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tool+"/"+tt.store, func(t *testing.T) {
+			if got := access.allows(tt.tool, tt.store); got != tt.want {
+				t.Errorf("allows(%s, %s) = %v, want %v", tt.tool, tt.store, got, tt.want)
+			}
+		})
+	}
+}
+
+// accessClient serves two stores: scratch-notes (scratch-1) and prod-kb (prod-1).
+func accessClient() *MockGeminiClient {
+	stores := []*genai.FileSearchStore{
+		{Name: "fileSearchStores/scratch-1", DisplayName: "scratch-notes"},
+		{Name: "fileSearchStores/prod-1", DisplayName: "prod-kb"},
+	}
+	find := func(nameOrID string) *genai.FileSearchStore {
+		for _, st := range stores {
+			if st.Name == nameOrID || st.DisplayName == nameOrID {
+				return st
+			}
+		}
+		return nil
+	}
+	return &MockGeminiClient{
+		ListStoresFunc: func(ctx context.Context) ([]*genai.FileSearchStore, error) { return stores, nil },
+		ResolveStoreNameFunc: func(ctx context.Context, nameOrID string) (string, error) {
+			if st := find(nameOrID); st != nil {
+				return st.Name, nil
+			}
+			return "", fmt.Errorf("%w: store not found: %s", gemini.ErrNotFound, nameOrID)
+		},
+		GetStoreFunc: func(ctx context.Context, name string) (*genai.FileSearchStore, error) {
+			if st := find(name); st != nil {
+				return st, nil
+			}
+			return nil, fmt.Errorf("%w: %s", gemini.ErrNotFound, name)
+		},
+		ListDocumentsFunc: func(ctx context.Context, storeID string) ([]*genai.Document, error) { return nil, nil },
+		QueryFunc: func(ctx context.Context, text, storeName, modelName, metadataFilter string) (*genai.GenerateContentResponse, error) {
+			return &genai.GenerateContentResponse{}, nil
+		},
+		DeleteStoreFunc: func(ctx context.Context, name string, force bool) error { return nil },
+		GetOperationFunc: func(ctx context.Context, name string, opType gemini.OperationType) (*gemini.OperationStatus, error) {
+			return &gemini.OperationStatus{Name: name, Done: true}, nil
+		},
+	}
+}
+
+func TestStoreAccessTools(t *testing.T) {
+	s := NewServerWithOptions(accessClient(), &Options{
+		Tools:       []string{"all"},
+		Destructive: DestructiveAllow,
+		StoreAccess: StoreAccess{
+			"list_stores":   {"scratch-*"},
+			"delete_*":      {"!prod-*"},
+			"get_operation": {"scratch-*"},
+			"resources":     {"!prod-*"},
+		},
+	})
+
+	tests := []struct {
+		name    string
+		tool    string
+		args    map[string]any
+		want    string
+		notWant string
+	}{
+		{name: "query any store", tool: "query_knowledge_base", args: map[string]any{"query": "q", "store_name": "prod-kb"}, notWant: "not allowed"},
+		{name: "delete scratch", tool: "delete_store", args: map[string]any{"store_name": "scratch-notes"}, want: "Deleted store: fileSearchStores/scratch-1"},
+		{name: "delete prod", tool: "delete_store", args: map[string]any{"store_name": "prod-kb"}, want: "store fileSearchStores/prod-1 is not allowed for delete_store by the server's store access rules (!prod-*)"},
+		{name: "delete prod by id", tool: "delete_store", args: map[string]any{"store_name": "fileSearchStores/prod-1"}, want: "is not allowed for delete_store"},
+		{name: "list filtered", tool: "list_stores", args: map[string]any{}, want: "scratch-notes", notWant: "prod-kb"},
+		{name: "operation in other store", tool: "get_operation", args: map[string]any{"operation_name": "fileSearchStores/prod-1/operations/op-1"}, want: "is not allowed for get_operation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text := callToolText(t, s, tt.tool, tt.args)
+			if tt.want != "" && !strings.Contains(text, tt.want) {
+				t.Errorf("result = %s, want it to contain %s", text, tt.want)
+			}
+			if tt.notWant != "" && strings.Contains(text, tt.notWant) {
+				t.Errorf("result = %s, want it not to contain %s", text, tt.notWant)
+			}
+		})
+	}
+
+	resp, _ := json.Marshal(s.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"filesearch://stores/prod-kb"}}`)))
+	if !strings.Contains(string(resp), "is not allowed for resources") {
+		t.Errorf("resource read of a denied store = %s", resp)
+	}
+}
+
+func TestStoreAccessGetStoreFails(t *testing.T) {
+	client := accessClient()
+	getStores := 0
+	client.GetStoreFunc = func(ctx context.Context, name string) (*genai.FileSearchStore, error) {
+		getStores++
+		return nil, errors.New("429 Too Many Requests")
+	}
+	deleted := false
+	client.DeleteStoreFunc = func(ctx context.Context, name string, force bool) error {
+		deleted = true
+		return nil
+	}
+	client.DeleteDocumentFunc = func(ctx context.Context, name string, force bool) error {
+		deleted = true
+		return nil
+	}
+	s := NewServerWithOptions(client, &Options{
+		Tools:       []string{"all"},
+		Destructive: DestructiveAllow,
+		StoreAccess: StoreAccess{"delete_*": {"!prod-*"}},
+	})
+
+	// A deny rule on display names can't be checked against the ID alone
+	for _, tt := range []struct {
+		tool string
+		args map[string]any
+	}{
+		{"delete_store", map[string]any{"store_name": "fileSearchStores/prod-1"}},
+		{"delete_document", map[string]any{"store_name": "fileSearchStores/prod-1", "document_name": "fileSearchStores/prod-1/documents/doc-1"}},
+	} {
+		text := callToolText(t, s, tt.tool, tt.args)
+		if !strings.Contains(text, "cannot check store fileSearchStores/prod-1 against the server's store access rules") || !strings.Contains(text, "429") {
+			t.Errorf("%s with GetStore failing = %s", tt.tool, text)
+		}
+	}
+	if deleted {
+		t.Error("a delete went ahead although the store's display name was unknown")
+	}
+
+	// A store resolved by display name is checked without fetching it
+	getStores = 0
+	if text := callToolText(t, s, "delete_store", map[string]any{"store_name": "prod-kb"}); !strings.Contains(text, "is not allowed for delete_store") {
+		t.Errorf("delete_store by display name = %s", text)
+	}
+	if getStores != 0 {
+		t.Errorf("GetStore called %d times for a store resolved by display name", getStores)
+	}
+
+	// Tools without rules don't need the store
+	if text := callToolText(t, s, "query_knowledge_base", map[string]any{"query": "q", "store_name": "fileSearchStores/prod-1"}); strings.Contains(text, "cannot check") {
+		t.Errorf("query without rules = %s", text)
+	}
+}
+
+func TestReadOnlyServer(t *testing.T) {
+	s := NewServerWithOptions(&MockGeminiClient{}, &Options{Tools: []string{"all"}, ReadOnly: true})
+	tools := getRegisteredTools(s)
+	for _, name := range []string{"create_store", "delete_store", "import_file_to_store", "upload_file", "delete_file", "delete_document"} {
+		if contains(tools, name) {
+			t.Errorf("read-only server registered %s", name)
+		}
+	}
+	for _, name := range []string{"list_stores", "query_knowledge_base", "get_document", "wait_operation"} {
+		if !contains(tools, name) {
+			t.Errorf("read-only server is missing %s", name)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package mcp
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/mikesmitty/file-search/internal/audit"
+)
+
+// withAuditActor is a tool handler middleware that attributes the changes a
+// tool call makes to the calling client, for the audit log. Over HTTP with
+// bearer tokens the actor is the token that authenticated the request, since
+// the client's name is whatever the client says it is.
+func withAuditActor(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return next(audit.WithActor(ctx, auditActor(ctx, request.Params.Name)), request)
+	}
+}
+
+// auditActor identifies the caller of tool in ctx.
+func auditActor(ctx context.Context, tool string) audit.Actor {
+	actor := audit.Actor{Name: "unknown", Source: "mcp", Tool: tool}
+	if session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo); ok {
+		if info := session.GetClientInfo(); info.Name != "" {
+			actor.Name = info.Name
+		}
+	}
+	if token, ok := bearerIdentity(ctx); ok {
+		actor.Client = actor.Name
+		actor.Name = token
+	}
+	return actor
+}
+
+// denialLog records the calls the server refuses, which never reach the API
+// client and its audit middleware.
+type denialLog struct {
+	log    *audit.Log
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+// newDenialLog returns a denialLog writing to log, or nil if log is nil.
+func newDenialLog(log *audit.Log, logger *slog.Logger) *denialLog {
+	if log == nil {
+		return nil
+	}
+	return &denialLog{log: log, logger: logger, now: time.Now}
+}
+
+// record logs that action on resources was refused and why. It does nothing
+// on a nil denialLog.
+func (d *denialLog) record(ctx context.Context, action string, resources []string, reason string) {
+	if d == nil {
+		return
+	}
+	actor, ok := audit.ActorFrom(ctx)
+	if !ok {
+		actor = auditActor(ctx, "")
+	}
+	e := &audit.Entry{
+		Time:      d.now().UTC(),
+		Actor:     actor.Name,
+		Client:    actor.Client,
+		Source:    actor.Source,
+		Tool:      actor.Tool,
+		Action:    action,
+		Resources: resources,
+		Outcome:   audit.OutcomeDenied,
+		Error:     reason,
+	}
+	if err := d.log.Append(e); err != nil {
+		d.logger.LogAttrs(ctx, slog.LevelWarn, "failed to record a refused call", slog.Any("error", err))
+	}
+}
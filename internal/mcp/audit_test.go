@@ -0,0 +1,88 @@
+package mcp
+
+import (
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/audit"
+)
+
+func TestRefusalsAudited(t *testing.T) {
+	dir := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(outside, []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	s := NewServerWithOptions(accessClient(), &Options{
+		Tools:       []string{"all"},
+		Destructive: DestructiveConfirm,
+		StoreAccess: StoreAccess{"delete_*": {"!prod-*"}},
+		Upload:      &UploadPolicy{AllowedRoots: []string{dir}},
+		AuditLog:    audit.New(path),
+	})
+
+	callToolText(t, s, "delete_store", map[string]any{"store_name": "prod-kb"})
+	callToolText(t, s, "delete_store", map[string]any{"store_name": "scratch-notes"})
+	callToolText(t, s, "upload_file", map[string]any{"path": outside})
+	callToolText(t, s, "list_stores", map[string]any{})
+
+	entries, err := audit.Read(path, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ tool, action, resource string }{
+		{"delete_store", "UseStore", "fileSearchStores/prod-1"},
+		{"delete_store", "DeleteStore", "fileSearchStores/scratch-1"},
+		{"upload_file", "UploadFile", outside},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("audit log = %+v, want %d refusals", entries, len(want))
+	}
+	for i, w := range want {
+		e := entries[i]
+		if e.Outcome != audit.OutcomeDenied || e.Tool != w.tool || e.Action != w.action || len(e.Resources) != 1 || e.Resources[0] != w.resource {
+			t.Errorf("entry %d = %+v, want %s %s of %s denied", i, e, w.tool, w.action, w.resource)
+		}
+		if e.Source != "mcp" || e.Error == "" {
+			t.Errorf("entry %d = %+v, want an mcp source and a reason", i, e)
+		}
+	}
+}
+
+func TestAuditActorIsBearerToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	s := NewServerWithOptions(accessClient(), &Options{
+		Tools:       []string{"all"},
+		Destructive: DestructiveDeny,
+		AuditLog:    audit.New(path),
+	})
+	ts := httptest.NewServer(NewHTTPHandler(s, nil, &HTTPOptions{BearerTokens: []string{"alice-token", "bob-token"}}))
+	defer ts.Close()
+
+	init := postMCP(t, ts.URL, "bob-token", "", initializeRequest)
+	init.Body.Close()
+	call := `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"delete_store","arguments":{"store_name":"scratch-notes"}}}`
+	resp := postMCP(t, ts.URL, "bob-token", init.Header.Get("Mcp-Session-Id"), call)
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	entries, err := audit.Read(path, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("audit log = %+v, want one refusal", entries)
+	}
+	// The client's own name is kept, but the token identifies the actor
+	if e := entries[0]; e.Actor != tokenFingerprint("bob-token") || e.Client != "test" || e.Outcome != audit.OutcomeDenied {
+		t.Errorf("entry = %+v, want actor %s and client test", e, tokenFingerprint("bob-token"))
+	}
+	if tokenFingerprint("alice-token") == tokenFingerprint("bob-token") {
+		t.Error("tokens share a fingerprint")
+	}
+}
@@ -0,0 +1,92 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// requestIDMeta is the _meta field the before-call hook uses to pass the
+// JSON-RPC request ID on to the middleware, which only sees the request.
+const requestIDMeta = "io.github.mikesmitty.file-search/requestId"
+
+// inFlightCalls tracks running tool calls so notifications/cancelled can
+// cancel their contexts. Cancelling stops upload and import handlers from
+// polling; they then report the operation that is still running.
+type inFlightCalls struct {
+	mu    sync.Mutex
+	calls map[string]context.CancelFunc
+}
+
+func newInFlightCalls() *inFlightCalls {
+	return &inFlightCalls{calls: make(map[string]context.CancelFunc)}
+}
+
+// callKey identifies a request within its session, since request IDs are
+// only unique per client.
+func callKey(ctx context.Context, id mcp.RequestId) string {
+	session := ""
+	if s := server.ClientSessionFromContext(ctx); s != nil {
+		session = s.SessionID()
+	}
+	return session + "/" + id.String()
+}
+
+// tagRequest is a before-call hook that records the request ID in _meta.
+func (f *inFlightCalls) tagRequest(ctx context.Context, id any, request *mcp.CallToolRequest) {
+	if id == nil {
+		return
+	}
+	if request.Params.Meta == nil {
+		request.Params.Meta = &mcp.Meta{}
+	}
+	if request.Params.Meta.AdditionalFields == nil {
+		request.Params.Meta.AdditionalFields = make(map[string]any)
+	}
+	request.Params.Meta.AdditionalFields[requestIDMeta] = mcp.NewRequestId(id)
+}
+
+// middleware runs each tool call with a context that handleCancelled can cancel.
+func (f *inFlightCalls) middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if request.Params.Meta == nil {
+			return next(ctx, request)
+		}
+		id, ok := request.Params.Meta.AdditionalFields[requestIDMeta].(mcp.RequestId)
+		if !ok {
+			return next(ctx, request)
+		}
+		delete(request.Params.Meta.AdditionalFields, requestIDMeta)
+
+		ctx, cancel := context.WithCancel(ctx)
+		key := callKey(ctx, id)
+		f.mu.Lock()
+		f.calls[key] = cancel
+		f.mu.Unlock()
+		defer func() {
+			f.mu.Lock()
+			delete(f.calls, key)
+			f.mu.Unlock()
+			cancel()
+		}()
+		return next(ctx, request)
+	}
+}
+
+// handleCancelled cancels the call named by a notifications/cancelled.
+// Unknown or already finished requests are ignored, as the protocol allows.
+func (f *inFlightCalls) handleCancelled(ctx context.Context, notification mcp.JSONRPCNotification) {
+	id, ok := notification.Params.AdditionalFields["requestId"]
+	if !ok || id == nil {
+		return
+	}
+	key := callKey(ctx, mcp.NewRequestId(id))
+	f.mu.Lock()
+	cancel := f.calls[key]
+	f.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
@@ -0,0 +1,74 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mikesmitty/file-search/internal/gemini"
+	"google.golang.org/genai"
+)
+
+func TestCancelToolCall(t *testing.T) {
+	started := make(chan struct{})
+	client := &MockGeminiClient{
+		ResolveStoreNameFunc: func(ctx context.Context, nameOrID string) (string, error) {
+			return "fileSearchStores/kb-1", nil
+		},
+		UploadFileFunc: func(ctx context.Context, path string, opts *gemini.UploadFileOptions) (*genai.File, error) {
+			close(started)
+			select {
+			case <-ctx.Done():
+				return nil, &gemini.OperationCanceledError{Name: "fileSearchStores/kb-1/operations/op-1", Err: ctx.Err()}
+			case <-time.After(5 * time.Second):
+				return nil, nil
+			}
+		},
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "guide.md")
+	if err := os.WriteFile(path, []byte("# Guide"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := NewServerWithOptions(client, &Options{Tools: []string{"all"}, Upload: &UploadPolicy{AllowedRoots: []string{dir}}})
+	session := &testSession{notifications: make(chan mcp.JSONRPCNotification, 10)}
+	if err := s.RegisterSession(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+	ctx := s.WithContext(context.Background(), session)
+
+	done := make(chan []byte, 1)
+	go func() {
+		resp, _ := json.Marshal(s.HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"upload_file","arguments":{"path":`+strconv.Quote(path)+`,"store_name":"KB"}}}`)))
+		done <- resp
+	}()
+	select {
+	case <-started:
+	case resp := <-done:
+		t.Fatalf("upload did not start: %s", resp)
+	}
+
+	// A different request ID leaves the call running
+	s.HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":8}}`))
+	select {
+	case resp := <-done:
+		t.Fatalf("call finished before it was cancelled: %s", resp)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	s.HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":7,"reason":"user aborted"}}`))
+	select {
+	case resp := <-done:
+		if !strings.Contains(string(resp), `"isError":true`) || !strings.Contains(string(resp), "fileSearchStores/kb-1/operations/op-1") {
+			t.Errorf("expected an error naming the running operation, got %s", resp)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("call was not cancelled")
+	}
+}
@@ -0,0 +1,209 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// DestructivePolicy controls how the delete tools behave.
+type DestructivePolicy string
+
+const (
+	// DestructiveDeny refuses every delete.
+	DestructiveDeny DestructivePolicy = "deny"
+	// DestructiveConfirm answers a delete with a preview and a one-time
+	// confirmation token, and only deletes when a second call echoes it.
+	DestructiveConfirm DestructivePolicy = "confirm"
+	// DestructiveAllow deletes immediately.
+	DestructiveAllow DestructivePolicy = "allow"
+)
+
+// ParseDestructivePolicy validates a policy name. An empty name is DestructiveConfirm.
+func ParseDestructivePolicy(s string) (DestructivePolicy, error) {
+	switch p := DestructivePolicy(s); p {
+	case DestructiveDeny, DestructiveConfirm, DestructiveAllow:
+		return p, nil
+	case "":
+		return DestructiveConfirm, nil
+	}
+	return "", fmt.Errorf("invalid destructive policy: %s (must be 'deny', 'confirm' or 'allow')", s)
+}
+
+// confirmationTTL is how long a confirmation token stays valid.
+const confirmationTTL = 5 * time.Minute
+
+// pendingKey identifies a confirmation token within the MCP session it was
+// issued to.
+type pendingKey struct {
+	session string
+	token   string
+}
+
+// pendingDeletion is a previewed delete waiting for its confirmation token.
+type pendingDeletion struct {
+	tool    string
+	target  string
+	force   bool
+	expires time.Time
+}
+
+// deletionPreview is returned in place of a delete in confirm mode.
+type deletionPreview struct {
+	Action            string    `json:"action"`
+	Target            string    `json:"target"`
+	Force             bool      `json:"force"`
+	Preview           any       `json:"preview"`
+	ConfirmationToken string    `json:"confirmationToken"`
+	ExpiresAt         time.Time `json:"expiresAt"`
+	Message           string    `json:"message"`
+}
+
+// deleteActions are the API calls the delete tools make, as the audit log
+// names them.
+var deleteActions = map[string]string{
+	"delete_store":    "DeleteStore",
+	"delete_file":     "DeleteFile",
+	"delete_document": "DeleteDocument",
+}
+
+// destructiveGuard applies the DestructivePolicy to delete tool calls.
+type destructiveGuard struct {
+	policy  DestructivePolicy
+	now     func() time.Time
+	denials *denialLog
+
+	mu      sync.Mutex
+	pending map[pendingKey]pendingDeletion
+}
+
+func newDestructiveGuard(policy DestructivePolicy, denials *denialLog) *destructiveGuard {
+	if policy == "" {
+		policy = DestructiveConfirm
+	}
+	return &destructiveGuard{policy: policy, now: time.Now, denials: denials, pending: make(map[pendingKey]pendingDeletion)}
+}
+
+// authorize decides whether a delete of target may run. It returns nil if
+// so, or the result to send instead: a refusal, or a preview of what would
+// be deleted with a confirmation token. The token is bound to the MCP
+// session, tool, target and force flag, so it can't be spent by another
+// client or on a different delete.
+// Refusals and previews are recorded as denied in the audit log.
+func (g *destructiveGuard) authorize(ctx context.Context, tool, target string, args map[string]interface{}, preview func() (any, error)) *mcp.CallToolResult {
+	refuse := func(msg string) *mcp.CallToolResult {
+		g.denials.record(ctx, deleteActions[tool], []string{target}, msg)
+		return mcp.NewToolResultError(msg)
+	}
+	switch g.policy {
+	case DestructiveAllow:
+		return nil
+	case DestructiveDeny:
+		return refuse(fmt.Sprintf("%s is disabled: the server's destructive tool policy is %q", tool, g.policy))
+	}
+
+	force := getBoolArg(args, "force")
+	now := g.now()
+	g.mu.Lock()
+	for key, p := range g.pending {
+		if now.After(p.expires) {
+			delete(g.pending, key)
+		}
+	}
+	session := sessionID(ctx)
+	if token, _ := getStringArg(args, "confirmation_token"); token != "" {
+		key := pendingKey{session: session, token: token}
+		p, ok := g.pending[key]
+		delete(g.pending, key)
+		g.mu.Unlock()
+		if !ok {
+			return refuse("confirmation_token is invalid, expired, already used or was issued to another session; call again without it for a new preview")
+		}
+		if p.tool != tool || p.target != target || p.force != force {
+			return refuse(fmt.Sprintf("confirmation_token was issued for %s of %s (force: %v), not this call; call again without it for a new preview", p.tool, p.target, p.force))
+		}
+		return nil
+	}
+	g.mu.Unlock()
+
+	details, err := preview()
+	if err != nil {
+		return mcp.NewToolResultError(err.Error())
+	}
+	token, err := newConfirmationToken()
+	if err != nil {
+		return mcp.NewToolResultError(err.Error())
+	}
+	p := pendingDeletion{tool: tool, target: target, force: force, expires: now.Add(confirmationTTL)}
+	g.mu.Lock()
+	g.pending[pendingKey{session: session, token: token}] = p
+	g.mu.Unlock()
+	g.denials.record(ctx, deleteActions[tool], []string{target}, "awaiting confirmation: nothing was deleted")
+
+	res, err := mcp.NewToolResultJSON(deletionPreview{
+		Action:            tool,
+		Target:            target,
+		Force:             force,
+		Preview:           details,
+		ConfirmationToken: token,
+		ExpiresAt:         p.expires,
+		Message:           fmt.Sprintf("Nothing was deleted. Show this preview to the user, and only if they agree, call %s again with the same arguments and confirmation_token set to %s.", tool, token),
+	})
+	if err != nil {
+		return mcp.NewToolResultError(err.Error())
+	}
+	return res
+}
+
+// sessionID returns the ID of the MCP session ctx belongs to, or "" outside one.
+func sessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
+
+func newConfirmationToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate confirmation token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Tool annotations, so clients can tell which tools are safe to auto-approve.
+func readOnlyTool(title string) mcp.ToolOption {
+	return mcp.WithToolAnnotation(mcp.ToolAnnotation{
+		Title:           title,
+		ReadOnlyHint:    mcp.ToBoolPtr(true),
+		DestructiveHint: mcp.ToBoolPtr(false),
+		IdempotentHint:  mcp.ToBoolPtr(true),
+		OpenWorldHint:   mcp.ToBoolPtr(true),
+	})
+}
+
+func additiveTool(title string) mcp.ToolOption {
+	return mcp.WithToolAnnotation(mcp.ToolAnnotation{
+		Title:           title,
+		ReadOnlyHint:    mcp.ToBoolPtr(false),
+		DestructiveHint: mcp.ToBoolPtr(false),
+		IdempotentHint:  mcp.ToBoolPtr(false),
+		OpenWorldHint:   mcp.ToBoolPtr(true),
+	})
+}
+
+func destructiveTool(title string) mcp.ToolOption {
+	return mcp.WithToolAnnotation(mcp.ToolAnnotation{
+		Title:           title,
+		ReadOnlyHint:    mcp.ToBoolPtr(false),
+		DestructiveHint: mcp.ToBoolPtr(true),
+		IdempotentHint:  mcp.ToBoolPtr(true),
+		OpenWorldHint:   mcp.ToBoolPtr(true),
+	})
+}
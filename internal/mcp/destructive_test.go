@@ -0,0 +1,196 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// callToolText calls a tool and returns the text of its result.
+func callToolText(t *testing.T, s *server.MCPServer, name string, args map[string]any) string {
+	t.Helper()
+	params, _ := json.Marshal(map[string]any{"name": name, "arguments": args})
+	resp, err := json.Marshal(s.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":`+string(params)+`}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Result struct {
+			Content []struct{ Text string } `json:"content"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(resp, &decoded); err != nil || len(decoded.Result.Content) == 0 {
+		t.Fatalf("unexpected response: %s", resp)
+	}
+	return decoded.Result.Content[0].Text
+}
+
+func TestDestructivePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  DestructivePolicy
+		calls   []map[string]any // "token" is replaced with the last preview's token
+		want    []string
+		deletes int
+	}{
+		{
+			name:   "deny",
+			policy: DestructiveDeny,
+			calls:  []map[string]any{{}},
+			want:   []string{`delete_store is disabled: the server's destructive tool policy is "deny"`},
+		},
+		{
+			name:    "allow",
+			policy:  DestructiveAllow,
+			calls:   []map[string]any{{}},
+			want:    []string{"Deleted store: fileSearchStores/kb-1"},
+			deletes: 1,
+		},
+		{
+			name:  "default previews",
+			calls: []map[string]any{{}},
+			want:  []string{`"activeDocuments":1`},
+		},
+		{
+			name:    "confirm with token once",
+			policy:  DestructiveConfirm,
+			calls:   []map[string]any{{}, {"confirmation_token": "token"}, {"confirmation_token": "token"}},
+			want:    []string{`"confirmationToken":"`, "Deleted store: fileSearchStores/kb-1", "confirmation_token is invalid, expired, already used"},
+			deletes: 1,
+		},
+		{
+			name:   "token bound to force",
+			policy: DestructiveConfirm,
+			calls:  []map[string]any{{}, {"confirmation_token": "token", "force": true}},
+			want:   []string{`"force":false`, "was issued for delete_store of fileSearchStores/kb-1 (force: false)"},
+		},
+		{
+			name:   "made-up token",
+			policy: DestructiveConfirm,
+			calls:  []map[string]any{{"confirmation_token": "0123456789abcdef"}},
+			want:   []string{"confirmation_token is invalid"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := resourceClient()
+			deletes := 0
+			client.DeleteStoreFunc = func(ctx context.Context, name string, force bool) error {
+				deletes++
+				return nil
+			}
+			s := NewServerWithOptions(client, &Options{Tools: []string{"all"}, Destructive: tt.policy})
+
+			var token string
+			for i, extra := range tt.calls {
+				args := map[string]any{"store_name": "Team KB"}
+				for k, v := range extra {
+					if v == "token" {
+						v = token
+					}
+					args[k] = v
+				}
+				text := callToolText(t, s, "delete_store", args)
+				var preview deletionPreview
+				if json.Unmarshal([]byte(text), &preview) == nil && preview.ConfirmationToken != "" {
+					token = preview.ConfirmationToken
+				}
+				if !strings.Contains(text, tt.want[i]) {
+					t.Errorf("call %d: result = %s, want it to contain %s", i, text, tt.want[i])
+				}
+			}
+			if deletes != tt.deletes {
+				t.Errorf("DeleteStore called %d times, want %d", deletes, tt.deletes)
+			}
+		})
+	}
+}
+
+func TestConfirmationTokenExpires(t *testing.T) {
+	g := newDestructiveGuard(DestructiveConfirm, nil)
+	clock := time.Now()
+	g.now = func() time.Time { return clock }
+	preview := func() (any, error) { return map[string]string{"name": "files/abc"}, nil }
+
+	res := g.authorize(context.Background(), "delete_file", "files/abc", map[string]interface{}{}, preview)
+	var p deletionPreview
+	if err := json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &p); err != nil || p.ConfirmationToken == "" {
+		t.Fatalf("expected a preview with a token, got %v", res.Content)
+	}
+	if !p.ExpiresAt.Equal(clock.Add(confirmationTTL)) {
+		t.Errorf("expiresAt = %v, want %v", p.ExpiresAt, clock.Add(confirmationTTL))
+	}
+
+	clock = clock.Add(confirmationTTL + time.Second)
+	res = g.authorize(context.Background(), "delete_file", "files/abc", map[string]interface{}{"confirmation_token": p.ConfirmationToken}, preview)
+	if res == nil || !res.IsError {
+		t.Fatalf("expired token was accepted")
+	}
+	if len(g.pending) != 0 {
+		t.Errorf("expired confirmations not cleaned up: %d left", len(g.pending))
+	}
+}
+
+// namedSession is a client session with its own ID.
+type namedSession struct {
+	testSession
+	id string
+}
+
+func (s *namedSession) SessionID() string { return s.id }
+
+func TestConfirmationTokenBoundToSession(t *testing.T) {
+	g := newDestructiveGuard(DestructiveConfirm, nil)
+	s := server.NewMCPServer("test", "1.0.0")
+	alice := s.WithContext(context.Background(), &namedSession{id: "alice"})
+	mallory := s.WithContext(context.Background(), &namedSession{id: "mallory"})
+	preview := func() (any, error) { return map[string]string{"name": "files/abc"}, nil }
+
+	res := g.authorize(alice, "delete_file", "files/abc", map[string]interface{}{}, preview)
+	var p deletionPreview
+	if err := json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &p); err != nil || p.ConfirmationToken == "" {
+		t.Fatalf("expected a preview with a token, got %v", res.Content)
+	}
+
+	confirm := map[string]interface{}{"confirmation_token": p.ConfirmationToken}
+	if res := g.authorize(mallory, "delete_file", "files/abc", confirm, preview); res == nil || !res.IsError {
+		t.Fatal("token was accepted from another session")
+	}
+	// The other session's attempt doesn't spend the token
+	if res := g.authorize(alice, "delete_file", "files/abc", confirm, preview); res != nil {
+		t.Errorf("token rejected in the session it was issued to: %v", res.Content)
+	}
+}
+
+func TestToolAnnotations(t *testing.T) {
+	s := NewServer(&MockGeminiClient{}, []string{"all"})
+	resp, _ := json.Marshal(s.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)))
+	var decoded struct {
+		Result struct {
+			Tools []mcp.Tool `json:"tools"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(resp, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	destructive := map[string]bool{"delete_store": true, "delete_file": true, "delete_document": true}
+	readOnly := map[string]bool{"list_stores": true, "query_knowledge_base": true, "get_document": true, "wait_operation": true}
+	for _, tool := range decoded.Result.Tools {
+		a := tool.Annotations
+		if a.Title == "" || a.DestructiveHint == nil || a.ReadOnlyHint == nil {
+			t.Errorf("%s: missing annotations %+v", tool.Name, a)
+			continue
+		}
+		if *a.DestructiveHint != destructive[tool.Name] {
+			t.Errorf("%s: destructiveHint = %v", tool.Name, *a.DestructiveHint)
+		}
+		if readOnly[tool.Name] && !*a.ReadOnlyHint {
+			t.Errorf("%s: readOnlyHint = false", tool.Name)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultShutdownTimeout is how long in-flight requests get to finish after
+// the server is asked to stop.
+const defaultShutdownTimeout = 10 * time.Second
+
+// HTTPOptions configures the HTTP transport.
+type HTTPOptions struct {
+	// Addr is the address to listen on, e.g. ":8080".
+	Addr string
+	// BearerTokens are the accepted "Authorization: Bearer" tokens. When empty
+	// the server accepts unauthenticated requests.
+	BearerTokens []string
+	// AllowedOrigins are the browser origins allowed by CORS, or "*" for any.
+	// When empty, cross-origin requests get no CORS headers.
+	AllowedOrigins []string
+	// ShutdownTimeout bounds how long the server waits for in-flight requests
+	// when ctx is cancelled (default: 10s).
+	ShutdownTimeout time.Duration
+}
+
+// RunHTTPServer serves MCP over HTTP until ctx is cancelled, then shuts down
+// gracefully. The streamable HTTP transport is served at /mcp, and the older
+// SSE transport at /sse and /message.
+func RunHTTPServer(ctx context.Context, client GeminiClient, serverOpts *Options, opts *HTTPOptions) error {
+	if opts == nil {
+		opts = &HTTPOptions{}
+	}
+	if err := serverOpts.validate(); err != nil {
+		return err
+	}
+	ln, err := net.Listen("tcp", opts.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", opts.Addr, err)
+	}
+	if serverOpts != nil && serverOpts.Logger != nil {
+		serverOpts.Logger.Info("MCP HTTP server listening", "addr", ln.Addr().String())
+	}
+	return serveHTTP(ctx, NewServerWithOptions(client, serverOpts), ln, opts)
+}
+
+func serveHTTP(ctx context.Context, s *server.MCPServer, ln net.Listener, opts *HTTPOptions) error {
+	httpServer := &http.Server{ReadHeaderTimeout: 10 * time.Second}
+	sse := server.NewSSEServer(s, server.WithHTTPServer(httpServer))
+	httpServer.Handler = NewHTTPHandler(s, sse, opts)
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- httpServer.Serve(ln)
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	timeout := opts.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// Closes the SSE sessions, then waits for in-flight requests
+	err := sse.Shutdown(shutdownCtx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		// Long-lived streams are still open; drop them
+		err = httpServer.Close()
+	}
+	if serveErr := <-errc; !errors.Is(serveErr, http.ErrServerClosed) {
+		return serveErr
+	}
+	return err
+}
+
+// NewHTTPHandler returns the HTTP handler for s: the streamable HTTP
+// transport at /mcp, the SSE transport at /sse and /message when sse is
+// not nil, and an unauthenticated /healthz, behind CORS and bearer-token
+// checks.
+func NewHTTPHandler(s *server.MCPServer, sse *server.SSEServer, opts *HTTPOptions) http.Handler {
+	if opts == nil {
+		opts = &HTTPOptions{}
+	}
+	mcpMux := http.NewServeMux()
+	mcpMux.Handle("/mcp", server.NewStreamableHTTPServer(s))
+	if sse != nil {
+		mcpMux.Handle("/sse", sse)
+		mcpMux.Handle("/message", sse)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+	mux.Handle("/", requireBearer(opts.BearerTokens, markRemote(mcpMux)))
+	return withCORS(opts.AllowedOrigins, mux)
+}
+
+type remoteKey struct{}
+
+// markRemote marks the requests' contexts, which the transports pass on to
+// tool handlers, as coming from a remote client.
+func markRemote(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), remoteKey{}, true)))
+	})
+}
+
+// isRemote reports whether ctx belongs to a request that came over HTTP
+// rather than stdio, whose client runs on this machine.
+func isRemote(ctx context.Context) bool {
+	remote, _ := ctx.Value(remoteKey{}).(bool)
+	return remote
+}
+
+type bearerKey struct{}
+
+// requireBearer rejects requests without one of tokens. An empty list
+// disables the check. Accepted requests carry the token's fingerprint in
+// their context, for bearerIdentity.
+func requireBearer(tokens []string, next http.Handler) http.Handler {
+	if len(tokens) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		got = strings.TrimSpace(got)
+		if ok && validToken(tokens, got) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), bearerKey{}, tokenFingerprint(got))))
+			return
+		}
+		w.Header().Set("WWW-Authenticate", `Bearer realm="file-search"`)
+		http.Error(w, "missing or invalid bearer token", http.StatusUnauthorized)
+	})
+}
+
+// bearerIdentity returns the identity of the bearer token that authenticated
+// the request ctx belongs to, "token:" and the start of its SHA-256 hash, so
+// the audit log can tell tokens apart without recording them.
+func bearerIdentity(ctx context.Context) (string, bool) {
+	identity, ok := ctx.Value(bearerKey{}).(string)
+	return identity, ok
+}
+
+func tokenFingerprint(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "token:" + hex.EncodeToString(sum[:6])
+}
+
+// validToken compares got against every token in constant time.
+func validToken(tokens []string, got string) bool {
+	valid := 0
+	for _, t := range tokens {
+		valid |= subtle.ConstantTimeCompare([]byte(t), []byte(got))
+	}
+	return got != "" && valid == 1
+}
+
+// withCORS adds CORS headers for allowed origins and answers preflight
+// requests, which browsers send without credentials.
+func withCORS(origins []string, next http.Handler) http.Handler {
+	if len(origins) == 0 {
+		return next
+	}
+	anyOrigin := slices.Contains(origins, "*")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		allowed := origin != "" && (anyOrigin || slices.Contains(origins, origin))
+		if allowed {
+			h := w.Header()
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Add("Vary", "Origin")
+			h.Set("Access-Control-Expose-Headers", "Mcp-Session-Id")
+		}
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			if !allowed {
+				http.Error(w, "origin not allowed", http.StatusForbidden)
+				return
+			}
+			h := w.Header()
+			h.Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+			h.Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Mcp-Session-Id, Mcp-Protocol-Version, Last-Event-ID")
+			h.Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,148 @@
+package mcp
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const initializeRequest = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1.0.0"}}}`
+
+func TestHTTPHandler(t *testing.T) {
+	s := NewServer(&MockGeminiClient{}, []string{"query"})
+	ts := httptest.NewServer(NewHTTPHandler(s, nil, &HTTPOptions{
+		BearerTokens:   []string{"alice-token", "bob-token"},
+		AllowedOrigins: []string{"https://app.example.com"},
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		headers    map[string]string
+		body       string
+		wantStatus int
+		wantBody   string
+		wantHeader map[string]string
+	}{
+		{
+			name:       "health check needs no token",
+			method:     http.MethodGet,
+			path:       "/healthz",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "missing token",
+			method:     http.MethodPost,
+			path:       "/mcp",
+			headers:    map[string]string{"Content-Type": "application/json"},
+			body:       initializeRequest,
+			wantStatus: http.StatusUnauthorized,
+			wantHeader: map[string]string{"WWW-Authenticate": `Bearer realm="file-search"`},
+		},
+		{
+			name:       "wrong token",
+			method:     http.MethodPost,
+			path:       "/mcp",
+			headers:    map[string]string{"Content-Type": "application/json", "Authorization": "Bearer mallory"},
+			body:       initializeRequest,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "valid token",
+			method:     http.MethodPost,
+			path:       "/mcp",
+			headers:    map[string]string{"Content-Type": "application/json", "Authorization": "Bearer bob-token", "Origin": "https://app.example.com"},
+			body:       initializeRequest,
+			wantStatus: http.StatusOK,
+			wantBody:   `"name":"Gemini File Search"`,
+			wantHeader: map[string]string{"Access-Control-Allow-Origin": "https://app.example.com"},
+		},
+		{
+			name:       "preflight from allowed origin",
+			method:     http.MethodOptions,
+			path:       "/mcp",
+			headers:    map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "POST"},
+			wantStatus: http.StatusNoContent,
+			wantHeader: map[string]string{"Access-Control-Allow-Origin": "https://app.example.com", "Access-Control-Allow-Methods": "GET, POST, DELETE, OPTIONS"},
+		},
+		{
+			name:       "preflight from other origin",
+			method:     http.MethodOptions,
+			path:       "/mcp",
+			headers:    map[string]string{"Origin": "https://evil.example.com", "Access-Control-Request-Method": "POST"},
+			wantStatus: http.StatusForbidden,
+			wantHeader: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, ts.URL+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %q)", resp.StatusCode, tt.wantStatus, body)
+			}
+			if !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", body, tt.wantBody)
+			}
+			for k, v := range tt.wantHeader {
+				if got := resp.Header.Get(k); got != v {
+					t.Errorf("header %s = %q, want %q", k, got, v)
+				}
+			}
+		})
+	}
+}
+
+func TestServeHTTPShutdown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- serveHTTP(ctx, NewServer(&MockGeminiClient{}, nil), ln, &HTTPOptions{ShutdownTimeout: time.Second})
+	}()
+
+	resp, err := http.Post("http://"+ln.Addr().String()+"/mcp", "application/json", strings.NewReader(initializeRequest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("serveHTTP() = %v, want nil after shutdown", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+	if _, err := http.Get("http://" + ln.Addr().String() + "/healthz"); err == nil {
+		t.Error("server still accepting connections after shutdown")
+	}
+}
@@ -27,6 +27,11 @@ func TestMCPServerIntegration(t *testing.T) {
 		"upload_file":          {"path", "name"},
 		"delete_file":          {"file_name"},
 		"delete_document":      {"store_name", "document_name"},
+		"get_store":            {"store_name"},
+		"get_document":         {"store_name", "document_name"},
+		"get_operation":        {"operation_name"},
+		"wait_operation":       {"operation_name"},
+		"list_models":          {},
 	}
 
 	// Get registered tools via reflection
@@ -0,0 +1,50 @@
+package mcp
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// withLogging returns a tool handler middleware that logs each tool call:
+// its arguments at debug level, then its outcome and duration, at warn
+// level if it failed.
+func withLogging(logger *slog.Logger) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			attrs := []slog.Attr{slog.String("tool", request.Params.Name)}
+			if session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo); ok {
+				attrs = append(attrs, slog.String("client", session.GetClientInfo().Name))
+			}
+			logger.LogAttrs(ctx, slog.LevelDebug, "tool call started", append(attrs, slog.Any("arguments", request.Params.Arguments))...)
+
+			start := time.Now()
+			result, err := next(ctx, request)
+			attrs = append(attrs, slog.Duration("elapsed", time.Since(start)))
+			switch {
+			case err != nil:
+				logger.LogAttrs(ctx, slog.LevelWarn, "tool call failed", append(attrs, slog.Any("error", err))...)
+			case result != nil && result.IsError:
+				logger.LogAttrs(ctx, slog.LevelWarn, "tool call failed", append(attrs, slog.String("error", resultText(result)))...)
+			default:
+				logger.LogAttrs(ctx, slog.LevelInfo, "tool call", attrs...)
+			}
+			return result, err
+		}
+	}
+}
+
+// resultText joins the text content of a tool result.
+func resultText(result *mcp.CallToolResult) string {
+	var parts []string
+	for _, c := range result.Content {
+		if text, ok := mcp.AsTextContent(c); ok {
+			parts = append(parts, text.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,33 @@
+package mcp
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestToolLogging(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewServerWithOptions(accessClient(), &Options{
+		Tools:       []string{"all"},
+		StoreAccess: StoreAccess{"delete_*": {"!prod-*"}},
+		Destructive: DestructiveAllow,
+		Logger:      slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	})
+	callToolText(t, s, "list_stores", map[string]any{})
+	callToolText(t, s, "delete_store", map[string]any{"store_name": "prod-kb"})
+
+	logs := buf.String()
+	for _, want := range []string{
+		`level=DEBUG msg="tool call started" tool=list_stores`,
+		`level=INFO msg="tool call" tool=list_stores`,
+		`arguments=map[store_name:prod-kb]`,
+		`level=WARN msg="tool call failed" tool=delete_store`,
+		`is not allowed for delete_store`,
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("logs missing %q:\n%s", want, logs)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mikesmitty/file-search/internal/gemini"
+)
+
+// defaultWaitTimeout is how long wait_operation waits when the call does not
+// say. Clients usually time out tool calls on their own well before this.
+const defaultWaitTimeout = 5 * time.Minute
+
+// defaultPollInterval is how often wait_operation checks an operation when
+// Options.PollInterval is unset.
+const defaultPollInterval = 2 * time.Second
+
+// operationArgs returns the operation name and type arguments of
+// get_operation and wait_operation.
+func operationArgs(request mcp.CallToolRequest) (string, gemini.OperationType, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return "", "", fmt.Errorf("arguments must be a map")
+	}
+	name, ok := getStringArg(args, "operation_name")
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("operation_name must be a string")
+	}
+	opType, _ := getStringArg(args, "type")
+	switch gemini.OperationType(opType) {
+	case "", gemini.OperationTypeImport, gemini.OperationTypeUpload:
+		return name, gemini.OperationType(opType), nil
+	}
+	return "", "", fmt.Errorf("invalid operation type: %s (must be 'import' or 'upload')", opType)
+}
+
+func makeGetOperationHandler(client GeminiClient) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if client == nil {
+			return mcp.NewToolResultError("Gemini API key not configured. Please set GEMINI_API_KEY environment variable."), nil
+		}
+		name, opType, err := operationArgs(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		status, err := client.GetOperation(ctx, name, opType)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		res, err := mcp.NewToolResultJSON(status)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return res, nil
+	}
+}
+
+func makeWaitOperationHandler(client GeminiClient, pollInterval time.Duration) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if client == nil {
+			return mcp.NewToolResultError("Gemini API key not configured. Please set GEMINI_API_KEY environment variable."), nil
+		}
+		name, opType, err := operationArgs(request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		timeout := defaultWaitTimeout
+		if args, ok := request.Params.Arguments.(map[string]interface{}); ok {
+			if secs, ok := args["timeout_seconds"].(float64); ok && secs > 0 {
+				timeout = time.Duration(secs * float64(time.Second))
+			}
+		}
+
+		reporter := newProgressReporter(ctx, request)
+		deadline := time.NewTimer(timeout)
+		defer deadline.Stop()
+		for {
+			status, err := client.GetOperation(ctx, name, opType)
+			if err != nil {
+				if ctx.Err() != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("stopped waiting for operation %s: %v (the operation is still running)", name, ctx.Err())), nil
+				}
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if status.Done {
+				if status.Failed {
+					return mcp.NewToolResultError(fmt.Sprintf("operation %s failed: %s", status.Name, status.ErrorMessage)), nil
+				}
+				if reporter != nil {
+					reporter.Report(gemini.ProgressEvent{Type: gemini.ProgressDone, Operation: status.Name, Document: status.DocumentName})
+				}
+				res, err := mcp.NewToolResultJSON(status)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				return res, nil
+			}
+			if reporter != nil {
+				reporter.Report(gemini.ProgressEvent{Type: gemini.ProgressPolling, Operation: status.Name})
+			}
+
+			select {
+			case <-ctx.Done():
+				return mcp.NewToolResultError(fmt.Sprintf("stopped waiting for operation %s: %v (the operation is still running)", name, ctx.Err())), nil
+			case <-deadline.C:
+				return mcp.NewToolResultError(fmt.Sprintf("timed out after %s waiting for operation %s; it is still running", timeout, name)), nil
+			case <-time.After(pollInterval):
+			}
+		}
+	}
+}
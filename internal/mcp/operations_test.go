@@ -0,0 +1,125 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mikesmitty/file-search/internal/gemini"
+)
+
+// operationClient returns a client whose operation finishes after the given
+// number of pending polls, failing with failMsg if it is set.
+func operationClient(pendingPolls int, failMsg string) *MockGeminiClient {
+	polls := 0
+	return &MockGeminiClient{
+		GetOperationFunc: func(ctx context.Context, name string, opType gemini.OperationType) (*gemini.OperationStatus, error) {
+			if !strings.Contains(name, "/operations/") {
+				return nil, fmt.Errorf("invalid operation name: must contain '/operations/'")
+			}
+			status := &gemini.OperationStatus{Name: name, Type: gemini.OperationTypeUpload}
+			if polls++; polls > pendingPolls {
+				status.Done = true
+				status.Failed = failMsg != ""
+				status.ErrorMessage = failMsg
+				status.DocumentName = "fileSearchStores/kb-1/documents/guide-1"
+			}
+			return status, nil
+		},
+	}
+}
+
+func TestOperationTools(t *testing.T) {
+	const op = "fileSearchStores/kb-1/upload/operations/op-1"
+	tests := []struct {
+		name      string
+		tool      string
+		args      map[string]interface{}
+		client    *MockGeminiClient
+		want      string
+		wantError bool
+	}{
+		{
+			name:   "get pending operation",
+			tool:   "get_operation",
+			args:   map[string]interface{}{"operation_name": op},
+			client: operationClient(1, ""),
+			want:   `"done":false`,
+		},
+		{
+			name:      "get invalid name",
+			tool:      "get_operation",
+			args:      map[string]interface{}{"operation_name": "files/abc"},
+			client:    operationClient(0, ""),
+			want:      "invalid operation name",
+			wantError: true,
+		},
+		{
+			name:      "get invalid type",
+			tool:      "get_operation",
+			args:      map[string]interface{}{"operation_name": op, "type": "export"},
+			client:    operationClient(0, ""),
+			want:      "invalid operation type: export",
+			wantError: true,
+		},
+		{
+			name:   "wait until done",
+			tool:   "wait_operation",
+			args:   map[string]interface{}{"operation_name": op, "type": "upload"},
+			client: operationClient(3, ""),
+			want:   `"documentName":"fileSearchStores/kb-1/documents/guide-1"`,
+		},
+		{
+			name:      "wait for failed operation",
+			tool:      "wait_operation",
+			args:      map[string]interface{}{"operation_name": op},
+			client:    operationClient(1, "unsupported file type"),
+			want:      "operation " + op + " failed: unsupported file type",
+			wantError: true,
+		},
+		{
+			name:      "wait times out",
+			tool:      "wait_operation",
+			args:      map[string]interface{}{"operation_name": op, "timeout_seconds": 0.02},
+			client:    operationClient(1<<30, ""),
+			want:      "waiting for operation " + op + "; it is still running",
+			wantError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := makeGetOperationHandler(tt.client)
+			if tt.tool == "wait_operation" {
+				handler = makeWaitOperationHandler(tt.client, time.Millisecond)
+			}
+			result, err := handler(context.Background(), mcp.CallToolRequest{
+				Params: mcp.CallToolParams{Name: tt.tool, Arguments: tt.args},
+			})
+			if err != nil {
+				t.Fatalf("handler returned error: %v", err)
+			}
+			text := result.Content[0].(mcp.TextContent).Text
+			if result.IsError != tt.wantError || !strings.Contains(text, tt.want) {
+				t.Errorf("result = %q (isError %v), want %q (isError %v)", text, result.IsError, tt.want, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestWaitOperationPollInterval(t *testing.T) {
+	const op = "fileSearchStores/kb-1/upload/operations/op-1"
+	// At the default 2s interval, the second poll would come after the timeout
+	s := NewServerWithOptions(operationClient(1, ""), &Options{Tools: []string{"wait_operation"}, PollInterval: time.Millisecond})
+	msg := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"wait_operation","arguments":{"operation_name":%q,"timeout_seconds":1}}}`, op)
+	resp, err := json.Marshal(s.HandleMessage(context.Background(), []byte(msg)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(resp), `\"done\":true`) {
+		t.Errorf("wait_operation = %s, want a finished operation", resp)
+	}
+}
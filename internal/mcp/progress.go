@@ -0,0 +1,70 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/mikesmitty/file-search/internal/gemini"
+	"github.com/mikesmitty/file-search/internal/progress"
+)
+
+// progressNotifier forwards upload and import progress to the client as
+// notifications/progress. Progress runs from 0 to 1: the upload covers the
+// first half and indexing creeps toward 1 with each poll, so the value only
+// ever increases as the protocol requires.
+type progressNotifier struct {
+	ctx   context.Context
+	srv   *server.MCPServer
+	token mcp.ProgressToken
+
+	mu       sync.Mutex
+	progress float64
+	polls    int
+}
+
+// newProgressReporter returns a reporter for the tool call, or nil if the
+// client did not ask for progress.
+func newProgressReporter(ctx context.Context, request mcp.CallToolRequest) gemini.ProgressReporter {
+	if request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil {
+		return nil
+	}
+	srv := server.ServerFromContext(ctx)
+	if srv == nil {
+		return nil
+	}
+	return &progressNotifier{ctx: ctx, srv: srv, token: request.Params.Meta.ProgressToken}
+}
+
+// Report implements gemini.ProgressReporter.
+func (p *progressNotifier) Report(e gemini.ProgressEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	value := p.progress
+	switch e.Type {
+	case gemini.ProgressBytesSent:
+		if e.TotalBytes > 0 {
+			value = 0.5 * float64(e.BytesSent) / float64(e.TotalBytes)
+		}
+	case gemini.ProgressOperationCreated:
+		value = 0.5
+	case gemini.ProgressPolling:
+		p.polls++
+		value = 1 - 0.5/float64(p.polls+1)
+	case gemini.ProgressDone, gemini.ProgressFailed:
+		value = 1
+	}
+	if value < p.progress {
+		value = p.progress
+	}
+	p.progress = value
+
+	p.srv.SendNotificationToClient(p.ctx, "notifications/progress", map[string]any{
+		"progressToken": p.token,
+		"progress":      value,
+		"total":         1.0,
+		"message":       progress.Describe(e),
+	})
+}
@@ -0,0 +1,172 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// registerPrompts adds prompts for the workflows people otherwise write by
+// hand. Each expands into instructions for calling query_knowledge_base, so
+// they are only useful when that tool is enabled.
+func registerPrompts(s *server.MCPServer) {
+	s.AddPrompt(mcp.NewPrompt("ask_with_citations",
+		mcp.WithPromptDescription("Answer a question from the knowledge base, citing the documents each statement comes from."),
+		mcp.WithArgument("question", mcp.RequiredArgument(), mcp.ArgumentDescription("The question to answer.")),
+		mcp.WithArgument("store_name", mcp.ArgumentDescription("The store to search.")),
+		mcp.WithArgument("metadata_filter", mcp.ArgumentDescription("Optional metadata filter, e.g. 'category = \"runbook\"'.")),
+	), makeAskWithCitationsPrompt())
+
+	s.AddPrompt(mcp.NewPrompt("summarize_document",
+		mcp.WithPromptDescription("Summarize one document in a store."),
+		mcp.WithArgument("store_name", mcp.RequiredArgument(), mcp.ArgumentDescription("The store containing the document.")),
+		mcp.WithArgument("document_name", mcp.RequiredArgument(), mcp.ArgumentDescription("The display name of the document.")),
+		mcp.WithArgument("focus", mcp.ArgumentDescription("Optional aspect to focus the summary on.")),
+		mcp.WithArgument("metadata_filter", mcp.ArgumentDescription("Optional metadata filter that selects the document, e.g. 'doc_id = \"1234\"'.")),
+	), makeSummarizeDocumentPrompt())
+
+	s.AddPrompt(mcp.NewPrompt("compare_documents",
+		mcp.WithPromptDescription("Compare two documents in a store and point out where they agree and differ."),
+		mcp.WithArgument("store_name", mcp.RequiredArgument(), mcp.ArgumentDescription("The store containing the documents.")),
+		mcp.WithArgument("document_a", mcp.RequiredArgument(), mcp.ArgumentDescription("The display name of the first document.")),
+		mcp.WithArgument("document_b", mcp.RequiredArgument(), mcp.ArgumentDescription("The display name of the second document.")),
+		mcp.WithArgument("aspect", mcp.ArgumentDescription("Optional aspect to compare, e.g. 'retry behaviour'.")),
+		mcp.WithArgument("metadata_filter_a", mcp.ArgumentDescription("Optional metadata filter that selects the first document, e.g. 'doc_id = \"1234\"'.")),
+		mcp.WithArgument("metadata_filter_b", mcp.ArgumentDescription("Optional metadata filter that selects the second document.")),
+	), makeCompareDocumentsPrompt())
+
+	s.AddPrompt(mcp.NewPrompt("find_owner",
+		mcp.WithPromptDescription("Find the team or person who owns a system, service or topic."),
+		mcp.WithArgument("topic", mcp.RequiredArgument(), mcp.ArgumentDescription("The system, service or topic.")),
+		mcp.WithArgument("store_name", mcp.ArgumentDescription("The store to search.")),
+		mcp.WithArgument("owner_key", mcp.ArgumentDescription("The custom metadata key that records ownership (default: owner).")),
+	), makeFindOwnerPrompt())
+}
+
+// promptArgs returns the prompt's arguments, or an error naming the first
+// required argument that is missing.
+func promptArgs(request mcp.GetPromptRequest, required ...string) (map[string]string, error) {
+	args := request.Params.Arguments
+	if args == nil {
+		args = map[string]string{}
+	}
+	for _, name := range required {
+		if strings.TrimSpace(args[name]) == "" {
+			return nil, fmt.Errorf("missing required argument: %s", name)
+		}
+	}
+	return args, nil
+}
+
+// queryCall describes a query_knowledge_base call with its arguments quoted,
+// leaving out empty ones.
+func queryCall(query, store, filter string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "query_knowledge_base with query=%s", strconv.Quote(query))
+	if store != "" {
+		fmt.Fprintf(&b, ", store_name=%s", strconv.Quote(store))
+	}
+	if filter != "" {
+		fmt.Fprintf(&b, ", metadata_filter=%s", strconv.Quote(filter))
+	}
+	return b.String()
+}
+
+func promptResult(description, text string) *mcp.GetPromptResult {
+	return mcp.NewGetPromptResult(description, []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+	})
+}
+
+func makeAskWithCitationsPrompt() server.PromptHandlerFunc {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		args, err := promptArgs(request, "question")
+		if err != nil {
+			return nil, err
+		}
+		text := fmt.Sprintf(`Answer the question below using only the knowledge base.
+
+1. Call %s.
+2. Base every statement on the passages the tool returns. After each statement, cite its source document as [n] and list the sources at the end as "[n] document title".
+3. If the results do not answer the question, say so instead of guessing.
+
+Question: %s`, queryCall(args["question"], args["store_name"], args["metadata_filter"]), args["question"])
+		return promptResult("Answer with citations", text), nil
+	}
+}
+
+func makeSummarizeDocumentPrompt() server.PromptHandlerFunc {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		args, err := promptArgs(request, "store_name", "document_name")
+		if err != nil {
+			return nil, err
+		}
+		doc := args["document_name"]
+		query := fmt.Sprintf("Summarize the document %q: its purpose, main points and conclusions.", doc)
+		if focus := args["focus"]; focus != "" {
+			query = fmt.Sprintf("Summarize what the document %q says about %s.", doc, focus)
+		}
+		text := fmt.Sprintf(`Summarize the document %q.
+
+1. Call %s.
+2. Use only passages whose source is %q; ignore passages from other documents.
+3. Write a short overview followed by the key points as bullets, and cite %q once at the end.
+4. If the document is not in the results, say it could not be found rather than summarizing other documents.`,
+			doc, queryCall(query, args["store_name"], args["metadata_filter"]), doc, doc)
+		return promptResult("Summarize "+doc, text), nil
+	}
+}
+
+func makeCompareDocumentsPrompt() server.PromptHandlerFunc {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		args, err := promptArgs(request, "store_name", "document_a", "document_b")
+		if err != nil {
+			return nil, err
+		}
+		a, b, store := args["document_a"], args["document_b"], args["store_name"]
+		subject := "their content"
+		if aspect := args["aspect"]; aspect != "" {
+			subject = aspect
+		}
+		text := fmt.Sprintf(`Compare the documents %q and %q on %s.
+
+1. Call %s.
+2. Call %s.
+3. From each result, use only passages whose source is the document asked about.
+4. Present a table with one row per point of comparison and a column for each document, then list where they agree, where they differ, and anything one covers that the other does not.
+5. Cite the document behind every cell. If either document has nothing on the subject, say so.`,
+			a, b, subject,
+			queryCall(fmt.Sprintf("What does the document %q say about %s?", a, subject), store, args["metadata_filter_a"]),
+			queryCall(fmt.Sprintf("What does the document %q say about %s?", b, subject), store, args["metadata_filter_b"]))
+		return promptResult(fmt.Sprintf("Compare %s and %s", a, b), text), nil
+	}
+}
+
+func makeFindOwnerPrompt() server.PromptHandlerFunc {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		args, err := promptArgs(request, "topic")
+		if err != nil {
+			return nil, err
+		}
+		topic, store := args["topic"], args["store_name"]
+		key := args["owner_key"]
+		if key == "" {
+			key = "owner"
+		}
+		text := fmt.Sprintf(`Find who owns %s.
+
+1. Call %s.
+2. Look in the results for owning teams, maintainers, on-call rotations, code owners or contacts, and check the %q custom metadata of the cited documents (readable from their filesearch:// resources).
+3. When you have a candidate owner, confirm it by calling %s.
+4. Answer with the owner, how to contact them, and the documents that say so. If sources disagree or are outdated, list every candidate with its source and date.`,
+			topic,
+			queryCall(fmt.Sprintf("Who owns, maintains or is the point of contact for %s?", topic), store, ""),
+			key,
+			queryCall("What does <owner> own?", store, fmt.Sprintf("%s = %s", key, strconv.Quote("<owner>"))))
+		return promptResult("Find the owner of "+topic, text), nil
+	}
+}
@@ -0,0 +1,113 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestGetPrompt(t *testing.T) {
+	s := NewServer(&MockGeminiClient{}, []string{"query"})
+
+	tests := []struct {
+		name    string
+		prompt  string
+		args    map[string]string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:   "ask with citations",
+			prompt: "ask_with_citations",
+			args:   map[string]string{"question": "How do I rotate keys?", "store_name": "Runbooks", "metadata_filter": `team = "infra"`},
+			want:   []string{`query_knowledge_base with query="How do I rotate keys?", store_name="Runbooks", metadata_filter="team = \"infra\""`, "[n]"},
+		},
+		{
+			name:   "ask without store",
+			prompt: "ask_with_citations",
+			args:   map[string]string{"question": "What is SLO?"},
+			want:   []string{`query_knowledge_base with query="What is SLO?".`},
+		},
+		{
+			name:   "summarize with focus",
+			prompt: "summarize_document",
+			args:   map[string]string{"store_name": "Specs", "document_name": "api.md", "focus": "auth"},
+			want:   []string{`Summarize what the document \"api.md\" says about auth.`, `store_name="Specs"`, `source is "api.md"`},
+		},
+		{
+			name:   "compare",
+			prompt: "compare_documents",
+			args:   map[string]string{"store_name": "Specs", "document_a": "v1.md", "document_b": "v2.md", "aspect": "pagination"},
+			want:   []string{`document \"v1.md\" say about pagination?`, `document \"v2.md\" say about pagination?`, "table"},
+		},
+		{
+			name:   "compare with filters",
+			prompt: "compare_documents",
+			args:   map[string]string{"store_name": "Specs", "document_a": "v1.md", "document_b": "v2.md", "metadata_filter_a": `version = "1"`, "metadata_filter_b": `version = "2"`},
+			want: []string{
+				`document \"v1.md\" say about their content?", store_name="Specs", metadata_filter="version = \"1\""`,
+				`document \"v2.md\" say about their content?", store_name="Specs", metadata_filter="version = \"2\""`,
+			},
+		},
+		{
+			name:   "find owner with custom key",
+			prompt: "find_owner",
+			args:   map[string]string{"topic": "billing service", "owner_key": "team"},
+			want:   []string{"Who owns, maintains or is the point of contact for billing service?", `metadata_filter="team = \"<owner>\""`},
+		},
+		{
+			name:    "missing required argument",
+			prompt:  "compare_documents",
+			args:    map[string]string{"store_name": "Specs", "document_a": "v1.md"},
+			wantErr: "missing required argument: document_b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, _ := json.Marshal(tt.args)
+			msg := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"prompts/get","params":{"name":%q,"arguments":%s}}`, tt.prompt, args)
+			resp, err := json.Marshal(s.HandleMessage(context.Background(), []byte(msg)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded struct {
+				Result struct {
+					Messages []struct {
+						Role    string
+						Content struct{ Text string }
+					}
+				}
+				Error *struct{ Message string }
+			}
+			if err := json.Unmarshal(resp, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != "" {
+				if decoded.Error == nil || !strings.Contains(decoded.Error.Message, tt.wantErr) {
+					t.Fatalf("expected error %q, got %s", tt.wantErr, resp)
+				}
+				return
+			}
+			if decoded.Error != nil || len(decoded.Result.Messages) != 1 || decoded.Result.Messages[0].Role != "user" {
+				t.Fatalf("unexpected response: %s", resp)
+			}
+			text := decoded.Result.Messages[0].Content.Text
+			for _, want := range tt.want {
+				if !strings.Contains(text, want) {
+					t.Errorf("prompt missing %s:\n%s", want, text)
+				}
+			}
+		})
+	}
+}
+
+func TestPromptsRequireQueryTool(t *testing.T) {
+	s := NewServer(&MockGeminiClient{}, []string{"list_stores"})
+	resp, _ := json.Marshal(s.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"prompts/list"}`)))
+	if strings.Contains(string(resp), "ask_with_citations") {
+		t.Errorf("prompts registered without query_knowledge_base: %s", resp)
+	}
+}
@@ -0,0 +1,95 @@
+package mcp
+
+import (
+	"fmt"
+
+	"github.com/mikesmitty/file-search/internal/grounding"
+	"google.golang.org/genai"
+)
+
+// Verbosity levels for query_knowledge_base.
+const (
+	// verbosityCompact cites each source with a one-line snippet.
+	verbosityCompact = "compact"
+	// verbosityFull includes the full text of each cited chunk.
+	verbosityFull = "full"
+)
+
+// queryResult is the structured output of query_knowledge_base: the answer
+// and what it cites, without the rest of the GenerateContentResponse.
+type queryResult struct {
+	Answer    string      `json:"answer" jsonschema_description:"The model's answer"`
+	Citations []citation  `json:"citations" jsonschema_description:"The sources the answer is grounded in, in citation order"`
+	Model     string      `json:"model" jsonschema_description:"The model that answered"`
+	Usage     *queryUsage `json:"usage,omitempty" jsonschema_description:"Token counts for the request"`
+	Cached    bool        `json:"cached,omitempty" jsonschema_description:"True when the answer came from the local query cache and used no tokens"`
+}
+
+type citation struct {
+	Index        int    `json:"index" jsonschema_description:"1-based position of the source in the response's grounding chunks"`
+	Title        string `json:"title"`
+	URI          string `json:"uri,omitempty"`
+	DocumentName string `json:"documentName,omitempty" jsonschema_description:"Resource name of the cited document"`
+	Web          bool   `json:"web,omitempty" jsonschema_description:"True for web search results"`
+	FirstPage    int    `json:"firstPage,omitempty"`
+	LastPage     int    `json:"lastPage,omitempty"`
+	Snippet      string `json:"snippet,omitempty" jsonschema_description:"The start of the cited chunk, on one line"`
+	Text         string `json:"text,omitempty" jsonschema_description:"The full cited chunk, with verbosity full"`
+}
+
+type queryUsage struct {
+	PromptTokens   int32 `json:"promptTokens"`
+	ResponseTokens int32 `json:"responseTokens"`
+	TotalTokens    int32 `json:"totalTokens"`
+}
+
+// parseVerbosity validates the verbosity argument. An empty value is verbosityCompact.
+func parseVerbosity(s string) (string, error) {
+	switch s {
+	case "":
+		return verbosityCompact, nil
+	case verbosityCompact, verbosityFull:
+		return s, nil
+	}
+	return "", fmt.Errorf("invalid verbosity: %s (must be '%s' or '%s')", s, verbosityCompact, verbosityFull)
+}
+
+// newQueryResult condenses a query response. Citations come from
+// grounding.Sources, so page spans match the CLI's output.
+func newQueryResult(resp *genai.GenerateContentResponse, model, verbosity string) *queryResult {
+	result := &queryResult{
+		Answer:    grounding.Text(resp),
+		Citations: []citation{},
+		Model:     model,
+	}
+	if resp.ModelVersion != "" {
+		result.Model = resp.ModelVersion
+	}
+	if u := resp.UsageMetadata; u != nil {
+		result.Usage = &queryUsage{
+			PromptTokens:   u.PromptTokenCount,
+			ResponseTokens: u.CandidatesTokenCount,
+			TotalTokens:    u.TotalTokenCount,
+		}
+	}
+	for _, cand := range resp.Candidates {
+		for _, src := range grounding.Sources(cand.GroundingMetadata) {
+			c := citation{
+				Index:        src.Index,
+				Title:        src.Title,
+				URI:          src.URI,
+				DocumentName: src.DocumentName,
+				Web:          src.Web,
+				FirstPage:    src.FirstPage,
+				LastPage:     src.LastPage,
+			}
+			if verbosity == verbosityFull {
+				c.Text = grounding.FullText(src.Text)
+			} else {
+				c.Snippet = grounding.Snippet(src.Text, grounding.SnippetLength)
+			}
+			result.Citations = append(result.Citations, c)
+		}
+	}
+	return result
+}
@@ -0,0 +1,93 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+func groundedResponse() *genai.GenerateContentResponse {
+	return &genai.GenerateContentResponse{
+		ModelVersion: "gemini-2.5-flash-001",
+		Candidates: []*genai.Candidate{{
+			Content: &genai.Content{Parts: []*genai.Part{{Text: "The T-800 runs on a hydrogen cell."}}},
+			GroundingMetadata: &genai.GroundingMetadata{
+				GroundingChunks: []*genai.GroundingChunk{
+					{RetrievedContext: &genai.GroundingChunkRetrievedContext{
+						Title: "spec.pdf",
+						URI:   "gs://kb/spec.pdf",
+						Text:  "--- PAGE 7 ---\nPower:\n\n\n\nhydrogen fuel cell, 120 year lifespan",
+					}},
+					{Web: &genai.GroundingChunkWeb{Title: "Wiki", URI: "https://example.com/t800"}},
+				},
+			},
+		}},
+		UsageMetadata: &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 12, CandidatesTokenCount: 8, TotalTokenCount: 20},
+	}
+}
+
+func TestNewQueryResult(t *testing.T) {
+	compact := newQueryResult(groundedResponse(), "gemini-2.5-flash", verbosityCompact)
+	if compact.Answer != "The T-800 runs on a hydrogen cell." || compact.Model != "gemini-2.5-flash-001" {
+		t.Errorf("answer/model = %q/%q", compact.Answer, compact.Model)
+	}
+	if compact.Usage == nil || *compact.Usage != (queryUsage{PromptTokens: 12, ResponseTokens: 8, TotalTokens: 20}) {
+		t.Errorf("usage = %+v", compact.Usage)
+	}
+	if len(compact.Citations) != 2 {
+		t.Fatalf("got %d citations, want 2", len(compact.Citations))
+	}
+	doc, web := compact.Citations[0], compact.Citations[1]
+	if doc.Title != "spec.pdf" || doc.FirstPage != 7 || doc.LastPage != 7 || doc.Text != "" {
+		t.Errorf("document citation = %+v", doc)
+	}
+	if doc.Snippet != "--- PAGE 7 --- Power: hydrogen fuel cell, 120 year lifespan" {
+		t.Errorf("snippet = %q", doc.Snippet)
+	}
+	if !web.Web || web.Index != 2 || web.URI != "https://example.com/t800" {
+		t.Errorf("web citation = %+v", web)
+	}
+
+	full := newQueryResult(groundedResponse(), "gemini-2.5-flash", verbosityFull)
+	if c := full.Citations[0]; c.Snippet != "" || c.Text != "--- PAGE 7 ---\nPower:\n\nhydrogen fuel cell, 120 year lifespan" {
+		t.Errorf("full citation = %+v", c)
+	}
+
+	ungrounded := newQueryResult(&genai.GenerateContentResponse{}, "gemini-2.5-flash", verbosityCompact)
+	if ungrounded.Model != "gemini-2.5-flash" || ungrounded.Citations == nil || ungrounded.Usage != nil {
+		t.Errorf("ungrounded result = %+v", ungrounded)
+	}
+}
+
+func TestQueryToolOutput(t *testing.T) {
+	client := resourceClient()
+	client.QueryFunc = func(ctx context.Context, text, storeName, modelName, metadataFilter string) (*genai.GenerateContentResponse, error) {
+		return groundedResponse(), nil
+	}
+	s := NewServer(client, []string{"query"})
+
+	text := callToolText(t, s, "query_knowledge_base", map[string]any{"query": "power?", "store_name": "Team KB"})
+	var result queryResult
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		t.Fatalf("result is not a queryResult: %s", text)
+	}
+	if strings.Contains(text, "groundingMetadata") || len(result.Citations) != 2 {
+		t.Errorf("result = %s", text)
+	}
+
+	text = callToolText(t, s, "query_knowledge_base", map[string]any{"query": "power?", "verbosity": "everything"})
+	if !strings.Contains(text, "invalid verbosity: everything") {
+		t.Errorf("result = %s, want an invalid verbosity error", text)
+	}
+
+	tool := s.GetTool("query_knowledge_base").Tool
+	schema, _ := json.Marshal(tool.OutputSchema)
+	for _, field := range []string{`"answer"`, `"citations"`, `"firstPage"`, `"required":["answer","citations","model"]`} {
+		if !strings.Contains(string(schema), field) {
+			t.Errorf("output schema %s is missing %s", schema, field)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/mikesmitty/file-search/internal/constants"
+	"github.com/mikesmitty/file-search/internal/gemini"
+	"google.golang.org/genai"
+)
+
+// Resource URIs. {store} and {doc} are the last segment of the resource name
+// (e.g. "abc" for fileSearchStores/abc) or a URL-escaped display name.
+const (
+	storesURI           = "filesearch://stores"
+	storeURITemplate    = "filesearch://stores/{store}"
+	documentURITemplate = "filesearch://stores/{store}/documents/{doc}"
+)
+
+// storeURI returns the resource URI of a store resource name.
+func storeURI(name string) string {
+	return storesURI + "/" + strings.TrimPrefix(name, constants.StoreResourcePrefix)
+}
+
+// documentURI returns the resource URI of a document resource name.
+func documentURI(name string) string {
+	store, doc, _ := strings.Cut(name, constants.DocumentResourcePrefix)
+	return storeURI(store) + "/documents/" + doc
+}
+
+// storeSummary is an entry of the filesearch://stores resource.
+type storeSummary struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+// storeResource is the payload of a store resource.
+type storeResource struct {
+	Name             string            `json:"name"`
+	DisplayName      string            `json:"displayName,omitempty"`
+	CreateTime       *time.Time        `json:"createTime,omitempty"`
+	UpdateTime       *time.Time        `json:"updateTime,omitempty"`
+	ActiveDocuments  int64             `json:"activeDocuments"`
+	PendingDocuments int64             `json:"pendingDocuments"`
+	FailedDocuments  int64             `json:"failedDocuments"`
+	SizeBytes        int64             `json:"sizeBytes"`
+	Documents        []documentSummary `json:"documents"`
+}
+
+// documentSummary is an entry of a store resource's document list.
+type documentSummary struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName,omitempty"`
+	State       string `json:"state,omitempty"`
+}
+
+// documentResource is the payload of a document resource.
+type documentResource struct {
+	Name           string         `json:"name"`
+	DisplayName    string         `json:"displayName,omitempty"`
+	State          string         `json:"state,omitempty"`
+	SizeBytes      int64          `json:"sizeBytes"`
+	MIMEType       string         `json:"mimeType,omitempty"`
+	CreateTime     *time.Time     `json:"createTime,omitempty"`
+	UpdateTime     *time.Time     `json:"updateTime,omitempty"`
+	CustomMetadata map[string]any `json:"customMetadata,omitempty"`
+}
+
+// registerResources exposes stores and documents as browsable resources, so
+// clients can read store stats and document metadata without tool calls.
+func registerResources(s *server.MCPServer, client GeminiClient) {
+	s.AddResource(mcp.NewResource(storesURI, "File Search Stores",
+		mcp.WithResourceDescription("All File Search Stores with the URI of each store."),
+		mcp.WithMIMEType("application/json"),
+	), makeStoresResourceHandler(client))
+
+	s.AddResourceTemplate(mcp.NewResourceTemplate(documentURITemplate, "Document",
+		mcp.WithTemplateDescription("Metadata of a document in a File Search Store: state, size and custom metadata."),
+		mcp.WithTemplateMIMEType("application/json"),
+	), makeDocumentResourceHandler(client))
+
+	s.AddResourceTemplate(mcp.NewResourceTemplate(storeURITemplate, "File Search Store",
+		mcp.WithTemplateDescription("Document counts and size of a File Search Store, with the URI of each document."),
+		mcp.WithTemplateMIMEType("application/json"),
+	), makeStoreResourceHandler(client))
+}
+
+// notifyResourcesChanged tells connected clients to re-list resources after
+// a tool adds or removes stores or documents.
+func notifyResourcesChanged(ctx context.Context) {
+	if srv := server.ServerFromContext(ctx); srv != nil {
+		srv.SendNotificationToAllClients(mcp.MethodNotificationResourcesListChanged, nil)
+	}
+}
+
+func makeStoresResourceHandler(client GeminiClient) server.ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		if client == nil {
+			return nil, errNoClient
+		}
+		stores, err := client.ListStores(ctx)
+		if err != nil {
+			return nil, err
+		}
+		summaries := make([]storeSummary, 0, len(stores))
+		for _, st := range stores {
+			summaries = append(summaries, storeSummary{URI: storeURI(st.Name), Name: st.Name, DisplayName: st.DisplayName})
+		}
+		return jsonResource(request.Params.URI, map[string]any{"stores": summaries})
+	}
+}
+
+func makeStoreResourceHandler(client GeminiClient) server.ResourceTemplateHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		if client == nil {
+			return nil, errNoClient
+		}
+		storeID, err := resolveStoreSegment(ctx, client, request.Params.Arguments["store"])
+		if err != nil {
+			return nil, err
+		}
+		res, err := describeStore(ctx, client, storeID)
+		if err != nil {
+			return nil, err
+		}
+		return jsonResource(request.Params.URI, res)
+	}
+}
+
+func makeDocumentResourceHandler(client GeminiClient) server.ResourceTemplateHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		if client == nil {
+			return nil, errNoClient
+		}
+		storeID, err := resolveStoreSegment(ctx, client, request.Params.Arguments["store"])
+		if err != nil {
+			return nil, err
+		}
+		seg, err := uriSegment(request.Params.Arguments["doc"])
+		if err != nil {
+			return nil, err
+		}
+		docID, err := client.ResolveDocumentName(ctx, storeID, seg)
+		if errors.Is(err, gemini.ErrNotFound) {
+			// Not a display name, so a document ID
+			docID, err = storeID+constants.DocumentResourcePrefix+seg, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		res, err := describeDocument(ctx, client, docID)
+		if err != nil {
+			return nil, err
+		}
+		return jsonResource(request.Params.URI, res)
+	}
+}
+
+// describeStore returns a store's stats and document list.
+func describeStore(ctx context.Context, client GeminiClient, storeID string) (*storeResource, error) {
+	store, err := client.GetStore(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := client.ListDocuments(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &storeResource{
+		Name:             store.Name,
+		DisplayName:      store.DisplayName,
+		CreateTime:       optionalTime(store.CreateTime),
+		UpdateTime:       optionalTime(store.UpdateTime),
+		ActiveDocuments:  store.ActiveDocumentsCount,
+		PendingDocuments: store.PendingDocumentsCount,
+		FailedDocuments:  store.FailedDocumentsCount,
+		SizeBytes:        store.SizeBytes,
+		Documents:        make([]documentSummary, 0, len(docs)),
+	}
+	for _, d := range docs {
+		res.Documents = append(res.Documents, documentSummary{URI: documentURI(d.Name), Name: d.Name, DisplayName: d.DisplayName, State: string(d.State)})
+	}
+	return res, nil
+}
+
+// describeDocument returns a document's state, size and custom metadata.
+func describeDocument(ctx context.Context, client GeminiClient, docID string) (*documentResource, error) {
+	doc, err := client.GetDocument(ctx, docID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &documentResource{
+		Name:        doc.Name,
+		DisplayName: doc.DisplayName,
+		State:       string(doc.State),
+		SizeBytes:   doc.SizeBytes,
+		MIMEType:    doc.MIMEType,
+		CreateTime:  optionalTime(doc.CreateTime),
+		UpdateTime:  optionalTime(doc.UpdateTime),
+	}
+	if len(doc.CustomMetadata) > 0 {
+		res.CustomMetadata = make(map[string]any, len(doc.CustomMetadata))
+		for _, m := range doc.CustomMetadata {
+			res.CustomMetadata[m.Key] = metadataValue(m)
+		}
+	}
+	return res, nil
+}
+
+var errNoClient = errors.New("Gemini API key not configured. Please set GEMINI_API_KEY environment variable.")
+
+// uriSegment returns a matched URI template variable, unescaped.
+func uriSegment(v any) (string, error) {
+	if values, ok := v.([]string); ok && len(values) > 0 {
+		v = values[0]
+	}
+	s, ok := v.(string)
+	if !ok || s == "" {
+		return "", fmt.Errorf("invalid resource URI")
+	}
+	return url.PathUnescape(s)
+}
+
+// resolveStoreSegment resolves the {store} segment of a resource URI, which
+// is either a store display name or the ID part of its resource name.
+func resolveStoreSegment(ctx context.Context, client GeminiClient, v any) (string, error) {
+	seg, err := uriSegment(v)
+	if err != nil {
+		return "", err
+	}
+	storeID, err := client.ResolveStoreName(ctx, seg)
+	if errors.Is(err, gemini.ErrNotFound) {
+		return constants.StoreResourcePrefix + seg, nil
+	}
+	return storeID, err
+}
+
+// metadataValue returns the value of a custom metadata entry, whichever type it is.
+func metadataValue(m *genai.CustomMetadata) any {
+	switch {
+	case m.NumericValue != nil:
+		return *m.NumericValue
+	case m.StringListValue != nil:
+		return m.StringListValue.Values
+	}
+	return m.StringValue
+}
+
+// optionalTime returns nil for unset timestamps so they are omitted.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func jsonResource(uri string, v any) ([]mcp.ResourceContents, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{URI: uri, MIMEType: "application/json", Text: string(data)}}, nil
+}
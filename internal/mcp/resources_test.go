@@ -0,0 +1,184 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mikesmitty/file-search/internal/gemini"
+	"google.golang.org/genai"
+)
+
+func resourceClient() *MockGeminiClient {
+	score := float32(4)
+	stores := []*genai.FileSearchStore{{Name: "fileSearchStores/kb-1", DisplayName: "Team KB", ActiveDocumentsCount: 1, SizeBytes: 42}}
+	docs := []*genai.Document{{
+		Name:        "fileSearchStores/kb-1/documents/guide-1",
+		DisplayName: "guide.md",
+		State:       genai.DocumentStateActive,
+		SizeBytes:   42,
+		MIMEType:    "text/markdown",
+		CustomMetadata: []*genai.CustomMetadata{
+			{Key: "team", StringValue: "infra"},
+			{Key: "score", NumericValue: &score},
+			{Key: "tags", StringListValue: &genai.StringList{Values: []string{"a", "b"}}},
+		},
+	}}
+	return &MockGeminiClient{
+		ListStoresFunc: func(ctx context.Context) ([]*genai.FileSearchStore, error) { return stores, nil },
+		ResolveStoreNameFunc: func(ctx context.Context, nameOrID string) (string, error) {
+			for _, s := range stores {
+				if s.DisplayName == nameOrID || s.Name == nameOrID {
+					return s.Name, nil
+				}
+			}
+			return "", fmt.Errorf("%w: store not found: %s", gemini.ErrNotFound, nameOrID)
+		},
+		GetStoreFunc: func(ctx context.Context, name string) (*genai.FileSearchStore, error) {
+			if name != stores[0].Name {
+				return nil, fmt.Errorf("%w: %s", gemini.ErrNotFound, name)
+			}
+			return stores[0], nil
+		},
+		ListDocumentsFunc: func(ctx context.Context, storeID string) ([]*genai.Document, error) { return docs, nil },
+		ResolveDocumentNameFunc: func(ctx context.Context, storeNameOrID, docNameOrID string) (string, error) {
+			if docNameOrID == docs[0].DisplayName {
+				return docs[0].Name, nil
+			}
+			return "", fmt.Errorf("%w: document not found: %s", gemini.ErrNotFound, docNameOrID)
+		},
+		GetDocumentFunc: func(ctx context.Context, name string) (*genai.Document, error) {
+			if name != docs[0].Name {
+				return nil, fmt.Errorf("%w: %s", gemini.ErrNotFound, name)
+			}
+			return docs[0], nil
+		},
+	}
+}
+
+func TestReadResource(t *testing.T) {
+	s := NewServer(resourceClient(), []string{"query"})
+
+	tests := []struct {
+		name    string
+		uri     string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "store list",
+			uri:  "filesearch://stores",
+			want: []string{`"uri": "filesearch://stores/kb-1"`, `"displayName": "Team KB"`},
+		},
+		{
+			name: "store by id",
+			uri:  "filesearch://stores/kb-1",
+			want: []string{`"activeDocuments": 1`, `"sizeBytes": 42`, `"uri": "filesearch://stores/kb-1/documents/guide-1"`, `"state": "STATE_ACTIVE"`},
+		},
+		{
+			name: "store by escaped display name",
+			uri:  "filesearch://stores/Team%20KB",
+			want: []string{`"name": "fileSearchStores/kb-1"`},
+		},
+		{
+			name: "document by id",
+			uri:  "filesearch://stores/kb-1/documents/guide-1",
+			want: []string{`"mimeType": "text/markdown"`, `"team": "infra"`, `"score": 4`, `"tags": [`},
+		},
+		{
+			name: "document by display name",
+			uri:  "filesearch://stores/Team%20KB/documents/guide.md",
+			want: []string{`"name": "fileSearchStores/kb-1/documents/guide-1"`},
+		},
+		{
+			name:    "missing store",
+			uri:     "filesearch://stores/nope",
+			wantErr: "not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":%q}}`, tt.uri)
+			resp, err := json.Marshal(s.HandleMessage(context.Background(), []byte(msg)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded struct {
+				Result struct {
+					Contents []struct{ Text string } `json:"contents"`
+				} `json:"result"`
+				Error *struct{ Message string } `json:"error"`
+			}
+			if err := json.Unmarshal(resp, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != "" {
+				if decoded.Error == nil || !strings.Contains(decoded.Error.Message, tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %s", tt.wantErr, resp)
+				}
+				return
+			}
+			if decoded.Error != nil || len(decoded.Result.Contents) != 1 {
+				t.Fatalf("unexpected response: %s", resp)
+			}
+			text := decoded.Result.Contents[0].Text
+			for _, want := range tt.want {
+				if !strings.Contains(text, want) {
+					t.Errorf("resource missing %s:\n%s", want, text)
+				}
+			}
+		})
+	}
+}
+
+// testSession is a client session that buffers the notifications it is sent.
+type testSession struct {
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *testSession) Initialize()       {}
+func (s *testSession) Initialized() bool { return true }
+func (s *testSession) SessionID() string { return "test" }
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+func TestResourcesListChangedNotification(t *testing.T) {
+	client := resourceClient()
+	client.CreateStoreFunc = func(ctx context.Context, displayName string) (*genai.FileSearchStore, error) {
+		return &genai.FileSearchStore{Name: "fileSearchStores/new-1", DisplayName: displayName}, nil
+	}
+	s := NewServer(client, []string{"all"})
+	session := &testSession{notifications: make(chan mcp.JSONRPCNotification, 10)}
+	if err := s.RegisterSession(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+	ctx := s.WithContext(context.Background(), session)
+
+	tests := []struct {
+		name       string
+		call       string
+		wantNotify bool
+	}{
+		{"read-only tool", `{"name":"list_stores","arguments":{}}`, false},
+		{"create store", `{"name":"create_store","arguments":{"display_name":"New"}}`, true},
+		{"failed delete", `{"name":"delete_store","arguments":{"store_name":"Missing"}}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":`+tt.call+`}`))
+			var got []string
+			for len(session.notifications) > 0 {
+				got = append(got, (<-session.notifications).Method)
+			}
+			notified := len(got) == 1 && got[0] == mcp.MethodNotificationResourcesListChanged
+			if notified != tt.wantNotify || (!tt.wantNotify && len(got) > 0) {
+				t.Errorf("notifications = %v, want list_changed: %v", got, tt.wantNotify)
+			}
+		})
+	}
+}
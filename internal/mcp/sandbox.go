@@ -0,0 +1,213 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// DefaultMaxUploadSize is the largest file upload_file accepts unless
+// UploadPolicy.MaxFileSize says otherwise. It matches the Gemini API's limit.
+const DefaultMaxUploadSize = 100 << 20
+
+// DefaultDenyPatterns keep credentials and VCS internals out of uploads even
+// inside an allowed root.
+var DefaultDenyPatterns = []string{
+	".ssh", ".gnupg", ".aws", ".azure", ".kube", ".docker", ".git",
+	".env", ".env.*", ".netrc", ".npmrc", ".pypirc",
+	"id_rsa*", "id_dsa*", "id_ecdsa*", "id_ed25519*",
+	"*.pem", "*.key", "*.p12", "*.pfx", "*.kdbx",
+}
+
+// rootsTimeout bounds how long upload_file waits for the client to list its roots.
+const rootsTimeout = 5 * time.Second
+
+// UploadPolicy restricts which local files upload_file may read, so a model
+// can't be talked into uploading arbitrary files from the machine.
+type UploadPolicy struct {
+	// AllowedRoots are the directories uploads must be inside. When empty, the
+	// roots the MCP client declares are used, or failing that the working
+	// directory (the workspace, when launched as a Gemini CLI extension).
+	// Over HTTP, where the client is remote, neither is trusted and uploads
+	// are refused unless AllowedRoots is set.
+	AllowedRoots []string
+	// DenyPatterns are glob patterns matched against each path element below
+	// the root, e.g. ".ssh" or "*.pem". Nil means DefaultDenyPatterns.
+	DenyPatterns []string
+	// MaxFileSize is the largest file in bytes (default: DefaultMaxUploadSize).
+	MaxFileSize int64
+}
+
+// uploadRejection explains why upload_file refused a path.
+type uploadRejection struct {
+	reason string
+}
+
+func (e *uploadRejection) Error() string {
+	return "upload rejected: " + e.reason
+}
+
+func rejectf(format string, args ...any) error {
+	return &uploadRejection{reason: fmt.Sprintf(format, args...)}
+}
+
+// openUpload resolves path, checks it against the policy and opens it. The
+// upload must read from the returned file: it is checked again after opening,
+// so swapping the path for a link between the check and the open is caught.
+// Symlinks are resolved first, so a link inside a root can't point outside it.
+func (p *UploadPolicy) openUpload(ctx context.Context, path string) (*os.File, error) {
+	resolved, err := p.checkPath(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(resolved)
+	if err != nil {
+		return nil, rejectf("%s does not exist or cannot be read", path)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, rejectf("%s does not exist or cannot be read", path)
+	}
+	// The opened file must still be the one the checked path names
+	again, err := filepath.EvalSymlinks(path)
+	if err != nil || again != resolved {
+		f.Close()
+		return nil, rejectf("%s changed while it was being checked", path)
+	}
+	if current, err := os.Stat(resolved); err != nil || !os.SameFile(info, current) {
+		f.Close()
+		return nil, rejectf("%s changed while it was being checked", path)
+	}
+
+	if !info.Mode().IsRegular() {
+		f.Close()
+		return nil, rejectf("%s is not a regular file", path)
+	}
+	limit := p.MaxFileSize
+	if limit <= 0 {
+		limit = DefaultMaxUploadSize
+	}
+	if info.Size() > limit {
+		f.Close()
+		return nil, rejectf("%s is %d bytes, over the %d byte limit", path, info.Size(), limit)
+	}
+	return f, nil
+}
+
+// checkPath resolves path and checks it against the roots and deny patterns,
+// returning the resolved path.
+func (p *UploadPolicy) checkPath(ctx context.Context, path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		return "", rejectf("%s is not an absolute path", path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", rejectf("%s does not exist or cannot be read", path)
+	}
+
+	roots, source := p.roots(ctx)
+	if len(roots) == 0 {
+		if isRemote(ctx) {
+			return "", rejectf("uploads over HTTP need the server's upload roots to be set (--upload-roots)")
+		}
+		return "", rejectf("no allowed roots are configured")
+	}
+	rel, ok := withinRoots(resolved, roots)
+	if !ok {
+		return "", rejectf("%s is outside the allowed roots (%s, from %s)", path, strings.Join(roots, ", "), source)
+	}
+
+	patterns := p.DenyPatterns
+	if patterns == nil {
+		patterns = DefaultDenyPatterns
+	}
+	// Check the name the model asked for as well as the link target
+	elements := strings.Split(rel, string(filepath.Separator))
+	elements = append(elements, filepath.Base(path))
+	for _, pattern := range patterns {
+		for _, elem := range elements {
+			if ok, _ := filepath.Match(pattern, elem); ok {
+				return "", rejectf("%s matches the deny pattern %q", path, pattern)
+			}
+		}
+	}
+	return resolved, nil
+}
+
+// roots returns the allowed roots with symlinks resolved, and where they
+// came from. Only the server's upload roots apply to remote clients.
+func (p *UploadPolicy) roots(ctx context.Context) ([]string, string) {
+	if len(p.AllowedRoots) > 0 {
+		return resolveRoots(p.AllowedRoots), "the server's upload roots"
+	}
+	if isRemote(ctx) {
+		return nil, ""
+	}
+	if roots := clientRoots(ctx); len(roots) > 0 {
+		return resolveRoots(roots), "the client's roots"
+	}
+	if wd, err := os.Getwd(); err == nil {
+		return resolveRoots([]string{wd}), "the working directory"
+	}
+	return nil, ""
+}
+
+// clientRoots asks the MCP client for its file:// roots, if it declared the
+// roots capability.
+func clientRoots(ctx context.Context) []string {
+	srv := server.ServerFromContext(ctx)
+	session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo)
+	if srv == nil || !ok || session.GetClientCapabilities().Roots == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, rootsTimeout)
+	defer cancel()
+	result, err := srv.RequestRoots(ctx, mcp.ListRootsRequest{})
+	if err != nil {
+		return nil
+	}
+	var roots []string
+	for _, root := range result.Roots {
+		u, err := url.Parse(root.URI)
+		if err != nil || u.Scheme != "file" || u.Path == "" {
+			continue
+		}
+		roots = append(roots, filepath.FromSlash(u.Path))
+	}
+	return roots
+}
+
+func resolveRoots(roots []string) []string {
+	resolved := make([]string, 0, len(roots))
+	for _, root := range roots {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			continue
+		}
+		if r, err := filepath.EvalSymlinks(abs); err == nil {
+			abs = r
+		}
+		resolved = append(resolved, abs)
+	}
+	return resolved
+}
+
+// withinRoots returns path relative to the first root that contains it.
+func withinRoots(path string, roots []string) (string, bool) {
+	for _, root := range roots {
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		return rel, true
+	}
+	return "", false
+}
@@ -0,0 +1,225 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/mikesmitty/file-search/internal/gemini"
+	"google.golang.org/genai"
+)
+
+// rootsSession is a client session that declares the roots capability.
+type rootsSession struct {
+	testSession
+	roots []string
+}
+
+func (s *rootsSession) GetClientInfo() mcp.Implementation            { return mcp.Implementation{} }
+func (s *rootsSession) SetClientInfo(mcp.Implementation)             {}
+func (s *rootsSession) SetClientCapabilities(mcp.ClientCapabilities) {}
+func (s *rootsSession) GetClientCapabilities() mcp.ClientCapabilities {
+	caps := mcp.ClientCapabilities{}
+	caps.Roots = &struct {
+		ListChanged bool `json:"listChanged,omitempty"`
+	}{}
+	return caps
+}
+func (s *rootsSession) ListRoots(ctx context.Context, request mcp.ListRootsRequest) (*mcp.ListRootsResult, error) {
+	result := &mcp.ListRootsResult{}
+	for _, root := range s.roots {
+		result.Roots = append(result.Roots, mcp.Root{URI: "file://" + filepath.ToSlash(root)})
+	}
+	return result, nil
+}
+
+var _ server.SessionWithRoots = (*rootsSession)(nil)
+
+func TestOpenUpload(t *testing.T) {
+	dir := t.TempDir()
+	workspace := filepath.Join(dir, "workspace")
+	outside := filepath.Join(dir, "outside")
+	for _, d := range []string{filepath.Join(workspace, "docs"), filepath.Join(workspace, ".ssh"), outside} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		"workspace/docs/guide.md": "# Guide",
+		"workspace/big.txt":       strings.Repeat("x", 2048),
+		"workspace/.ssh/id_rsa":   "secret",
+		"workspace/server.pem":    "secret",
+		"outside/secret.txt":      "secret",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A link inside the workspace pointing out of it, and one to a denied name
+	if err := os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(workspace, "notes.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(workspace, "docs", "guide.md"), filepath.Join(workspace, ".env")); err != nil {
+		t.Fatal(err)
+	}
+
+	policy := &UploadPolicy{AllowedRoots: []string{workspace}, MaxFileSize: 1024}
+	tests := []struct {
+		name    string
+		policy  *UploadPolicy
+		path    string
+		wantErr string
+	}{
+		{name: "inside root", path: filepath.Join(workspace, "docs", "guide.md")},
+		{name: "relative path", path: "docs/guide.md", wantErr: "is not an absolute path"},
+		{name: "outside root", path: filepath.Join(outside, "secret.txt"), wantErr: "is outside the allowed roots"},
+		{name: "traversal", path: filepath.Join(workspace, "..", "outside", "secret.txt"), wantErr: "is outside the allowed roots"},
+		{name: "symlink escape", path: filepath.Join(workspace, "notes.txt"), wantErr: "is outside the allowed roots"},
+		{name: "denied directory", path: filepath.Join(workspace, ".ssh", "id_rsa"), wantErr: `deny pattern ".ssh"`},
+		{name: "denied extension", path: filepath.Join(workspace, "server.pem"), wantErr: `deny pattern "*.pem"`},
+		{name: "denied link name", path: filepath.Join(workspace, ".env"), wantErr: `deny pattern ".env"`},
+		{name: "deny patterns off", policy: &UploadPolicy{AllowedRoots: []string{workspace}, DenyPatterns: []string{}}, path: filepath.Join(workspace, "server.pem")},
+		{name: "directory", path: filepath.Join(workspace, "docs"), wantErr: "is not a regular file"},
+		{name: "missing", path: filepath.Join(workspace, "missing.md"), wantErr: "does not exist"},
+		{name: "too large", path: filepath.Join(workspace, "big.txt"), wantErr: "over the 1024 byte limit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := policy
+			if tt.policy != nil {
+				p = tt.policy
+			}
+			f, err := p.openUpload(context.Background(), tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("openUpload(%s) = %v, want error containing %q", tt.path, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("openUpload(%s) = %v", tt.path, err)
+			}
+			defer f.Close()
+			if !filepath.IsAbs(f.Name()) {
+				t.Errorf("opened path %q is not absolute", f.Name())
+			}
+		})
+	}
+}
+
+func TestUploadUsesClientRoots(t *testing.T) {
+	root, other := t.TempDir(), t.TempDir()
+	for _, d := range []string{root, other} {
+		if err := os.WriteFile(filepath.Join(d, "guide.md"), []byte("# Guide"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client := &MockGeminiClient{
+		UploadFileFunc: func(ctx context.Context, path string, opts *gemini.UploadFileOptions) (*genai.File, error) {
+			return &genai.File{Name: "files/guide", DisplayName: opts.DisplayName}, nil
+		},
+	}
+	s := NewServer(client, []string{"upload"})
+	session := &rootsSession{testSession: testSession{notifications: make(chan mcp.JSONRPCNotification, 10)}, roots: []string{root}}
+	if err := s.RegisterSession(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+	ctx := s.WithContext(context.Background(), session)
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"inside client root", filepath.Join(root, "guide.md"), `\"displayName\":\"guide.md\"`},
+		{"outside client root", filepath.Join(other, "guide.md"), "from the client's roots"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"upload_file","arguments":{"path":` + strconv.Quote(tt.path) + `}}}`
+			resp, err := json.Marshal(s.HandleMessage(ctx, []byte(msg)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(resp), tt.want) {
+				t.Errorf("response = %s, want it to contain %s", resp, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoteUploadNeedsServerRoots(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "guide.md")
+	if err := os.WriteFile(path, []byte("# Guide"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	var uploaded *os.File
+	client := &MockGeminiClient{
+		UploadFileFunc: func(ctx context.Context, path string, opts *gemini.UploadFileOptions) (*genai.File, error) {
+			uploaded = opts.File
+			return &genai.File{Name: "files/guide", DisplayName: opts.DisplayName}, nil
+		},
+	}
+	call := `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"upload_file","arguments":{"path":` + strconv.Quote(path) + `}}}`
+
+	// Neither the client's roots nor the working directory count over HTTP
+	for _, policy := range []*UploadPolicy{nil, {AllowedRoots: []string{dir}}} {
+		s := NewServerWithOptions(client, &Options{Tools: []string{"upload"}, Upload: policy})
+		ts := httptest.NewServer(NewHTTPHandler(s, nil, nil))
+		init := postMCP(t, ts.URL, "", "", initializeRequest)
+		init.Body.Close()
+		session := init.Header.Get("Mcp-Session-Id")
+		resp := postMCP(t, ts.URL, "", session, call)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		ts.Close()
+
+		if policy == nil {
+			if !strings.Contains(string(body), "need the server's upload roots") || uploaded != nil {
+				t.Errorf("upload without server roots = %s", body)
+			}
+			continue
+		}
+		if !strings.Contains(string(body), `\"name\":\"files/guide\"`) {
+			t.Errorf("upload inside the server roots = %s", body)
+		}
+		if uploaded == nil || uploaded.Name() != path {
+			t.Errorf("UploadFile got file %v, want the opened %s", uploaded, path)
+		}
+	}
+}
+
+// postMCP sends a JSON-RPC message to the streamable HTTP endpoint, with a
+// bearer token if token is set.
+func postMCP(t *testing.T, url, token, session, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url+"/mcp", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if session != "" {
+		req.Header.Set("Mcp-Session-Id", session)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
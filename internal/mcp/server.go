@@ -4,11 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/mikesmitty/file-search/internal/audit"
 	"github.com/mikesmitty/file-search/internal/constants"
 	"github.com/mikesmitty/file-search/internal/gemini"
+	"github.com/mikesmitty/file-search/internal/querycache"
 	"google.golang.org/genai"
 )
 
@@ -16,6 +21,8 @@ import (
 type GeminiClient interface {
 	ListStores(ctx context.Context) ([]*genai.FileSearchStore, error)
 	ListFiles(ctx context.Context) ([]*genai.File, error)
+	GetFile(ctx context.Context, name string) (*genai.File, error)
+	GetStore(ctx context.Context, name string) (*genai.FileSearchStore, error)
 	ResolveStoreName(ctx context.Context, nameOrID string) (string, error)
 	ListDocuments(ctx context.Context, storeID string) ([]*genai.Document, error)
 	CreateStore(ctx context.Context, displayName string) (*genai.FileSearchStore, error)
@@ -25,60 +32,156 @@ type GeminiClient interface {
 	Query(ctx context.Context, text string, storeName string, modelName string, metadataFilter string) (*genai.GenerateContentResponse, error)
 	UploadFile(ctx context.Context, path string, opts *gemini.UploadFileOptions) (*genai.File, error)
 	DeleteFile(ctx context.Context, name string) error
+	GetDocument(ctx context.Context, name string) (*genai.Document, error)
 	ResolveDocumentName(ctx context.Context, storeNameOrID, docNameOrID string) (string, error)
 	DeleteDocument(ctx context.Context, name string, force bool) error
+	GetOperation(ctx context.Context, operationName string, operationType gemini.OperationType) (*gemini.OperationStatus, error)
+	ListModels(ctx context.Context) ([]*genai.Model, error)
 	Close()
 }
 
-func RunServer(ctx context.Context, client GeminiClient, enabledTools []string) error {
-	s := NewServer(client, enabledTools)
-	return server.ServeStdio(s)
+// Any gemini.API implementation, including wrapped clients, can back the server.
+var _ GeminiClient = gemini.API(nil)
+
+// Options configures the MCP server.
+type Options struct {
+	// Tools are the tools to enable, by name or group ("all", "read", ...),
+	// with "-" exclusions. See ResolveTools.
+	Tools []string
+	// Upload restricts the files upload_file may read. Nil applies the
+	// default policy: the client's roots or the working directory.
+	Upload *UploadPolicy
+	// Destructive controls the delete tools (default: DestructiveConfirm).
+	Destructive DestructivePolicy
+	// StoreAccess restricts which stores each tool may use.
+	StoreAccess StoreAccess
+	// ReadOnly registers only the tools that don't modify anything.
+	ReadOnly bool
+	// AuditLog, if set, records the calls the server refuses: store access
+	// denials, delete refusals and previews, and sandboxed uploads. Calls
+	// that reach the API are audited by the client's middleware.
+	AuditLog *audit.Log
+	// PollInterval is how often wait_operation checks an operation
+	// (default: 2s).
+	PollInterval time.Duration
+	// Logger receives a record of each tool call and transport errors
+	// (default: discarded). With the stdio transport it must not write to
+	// stdout, which carries the protocol.
+	Logger *slog.Logger
+}
+
+// validate reports configuration errors, such as unknown tool names.
+func (o *Options) validate() error {
+	if o == nil {
+		return nil
+	}
+	_, err := ResolveTools(o.Tools)
+	return err
+}
+
+func RunServer(ctx context.Context, client GeminiClient, opts *Options) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	s := NewServerWithOptions(client, opts)
+	var stdioOpts []server.StdioOption
+	if opts != nil && opts.Logger != nil {
+		stdioOpts = append(stdioOpts, server.WithErrorLogger(slog.NewLogLogger(opts.Logger.Handler(), slog.LevelError)))
+	}
+	return server.ServeStdio(s, stdioOpts...)
 }
 
 // NewServer creates a new MCP server instance with the configured tools.
 // It is exported to allow testing of the server configuration and tool registration.
 func NewServer(client GeminiClient, enabledTools []string) *server.MCPServer {
+	return NewServerWithOptions(client, &Options{Tools: enabledTools})
+}
+
+// NewServerWithOptions creates a new MCP server configured by opts.
+func NewServerWithOptions(client GeminiClient, opts *Options) *server.MCPServer {
+	if opts == nil {
+		opts = &Options{}
+	}
+	uploads := opts.Upload
+	if uploads == nil {
+		uploads = &UploadPolicy{}
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	pollInterval := opts.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+	denials := newDenialLog(opts.AuditLog, logger)
+	guard := newDestructiveGuard(opts.Destructive, denials)
+	client = newRestrictedClient(client, opts.StoreAccess, denials)
+
+	calls := newInFlightCalls()
+	hooks := &server.Hooks{}
+	hooks.AddBeforeCallTool(calls.tagRequest)
 	s := server.NewMCPServer(
 		"Gemini File Search",
 		"1.0.0",
+		server.WithResourceCapabilities(false, true),
+		server.WithPromptCapabilities(false),
+		server.WithHooks(hooks),
+		server.WithToolHandlerMiddleware(calls.middleware),
+		server.WithToolHandlerMiddleware(withTracing()),
+		server.WithToolHandlerMiddleware(withLogging(logger)),
+		server.WithToolHandlerMiddleware(withToolName),
+		server.WithToolHandlerMiddleware(withAuditActor),
 	)
+	s.AddNotificationHandler("notifications/cancelled", calls.handleCancelled)
 
-	// Helper to check if a tool is enabled
-	isToolEnabled := func(name string) bool {
-		for _, t := range enabledTools {
-			if t == name {
-				return true
-			}
+	registerResources(s, client)
+
+	// In read-only mode, skip every tool not annotated as read-only
+	addTool := func(tool mcp.Tool, handler server.ToolHandlerFunc) {
+		if opts.ReadOnly && (tool.Annotations.ReadOnlyHint == nil || !*tool.Annotations.ReadOnlyHint) {
+			return
 		}
-		return false
+		s.AddTool(tool, handler)
+	}
+
+	// Unknown names are skipped here; RunServer and ResolveTools report them
+	resolved, _ := ResolveTools(skipUnknownTools(opts.Tools))
+	enabled := make(map[string]bool, len(resolved))
+	for _, name := range resolved {
+		enabled[name] = true
 	}
 
 	// Tool: list_stores
-	if isToolEnabled("list_stores") || isToolEnabled("all") {
-		s.AddTool(mcp.NewTool("list_stores",
+	if enabled["list_stores"] {
+		addTool(mcp.NewTool("list_stores",
 			mcp.WithDescription("List all File Search Stores. Returns a JSON array of store objects containing name, displayName, and other metadata."),
+			readOnlyTool("List stores"),
 		), makeListStoresHandler(client))
 	}
 
 	// Tool: list_files
-	if isToolEnabled("list_files") || isToolEnabled("all") {
-		s.AddTool(mcp.NewTool("list_files",
+	if enabled["list_files"] {
+		addTool(mcp.NewTool("list_files",
 			mcp.WithDescription("List all files in the Gemini Files API. Returns a JSON array of file objects."),
+			readOnlyTool("List files"),
 		), makeListFilesHandler(client))
 	}
 
 	// Tool: list_documents
-	if isToolEnabled("list_documents") || isToolEnabled("all") {
-		s.AddTool(mcp.NewTool("list_documents",
+	if enabled["list_documents"] {
+		addTool(mcp.NewTool("list_documents",
 			mcp.WithDescription("List all documents within a specified File Search Store. Returns a JSON array of document objects."),
+			readOnlyTool("List documents"),
 			mcp.WithString("store_name", mcp.Required(), mcp.Description("The resource name or display name of the store to list documents from.")),
 		), makeListDocumentsHandler(client))
 	}
 
 	// Tool: create_store
-	if isToolEnabled("create_store") || isToolEnabled("all") {
-		s.AddTool(mcp.NewTool("create_store",
+	if enabled["create_store"] {
+		addTool(mcp.NewTool("create_store",
 			mcp.WithDescription("Create a new File Search Store."),
+			additiveTool("Create store"),
 			mcp.WithString("display_name", mcp.Required(), mcp.Description("The human-readable name for the new store.")),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			args, ok := request.Params.Arguments.(map[string]interface{})
@@ -94,6 +197,7 @@ func NewServer(client GeminiClient, enabledTools []string) *server.MCPServer {
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
+			notifyResourcesChanged(ctx)
 			res, err := mcp.NewToolResultJSON(store)
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
@@ -103,11 +207,13 @@ func NewServer(client GeminiClient, enabledTools []string) *server.MCPServer {
 	}
 
 	// Tool: delete_store
-	if isToolEnabled("delete_store") || isToolEnabled("all") {
-		s.AddTool(mcp.NewTool("delete_store",
-			mcp.WithDescription("Delete a File Search Store."),
+	if enabled["delete_store"] {
+		addTool(mcp.NewTool("delete_store",
+			mcp.WithDescription("Delete a File Search Store. Depending on the server's policy, the call may instead return a preview of what would be deleted and a confirmation_token; deleting then takes a second call that passes the token back."),
+			destructiveTool("Delete store"),
 			mcp.WithString("store_name", mcp.Required(), mcp.Description("The resource name or display name of the store to delete.")),
 			mcp.WithBoolean("force", mcp.Description("Force delete even if the store contains documents.")),
+			mcp.WithString("confirmation_token", mcp.Description("The token from a previous call's deletion preview, once the user has approved it.")),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			args, ok := request.Params.Arguments.(map[string]interface{})
 			if !ok {
@@ -124,19 +230,26 @@ func NewServer(client GeminiClient, enabledTools []string) *server.MCPServer {
 			if err != nil {
 				return mcp.NewToolResultError(fmt.Sprintf("Failed to resolve store name: %v", err)), nil
 			}
+			if res := guard.authorize(ctx, "delete_store", storeID, args, func() (any, error) {
+				return describeStore(ctx, client, storeID)
+			}); res != nil {
+				return res, nil
+			}
 
 			err = client.DeleteStore(ctx, storeID, force)
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
+			notifyResourcesChanged(ctx)
 			return mcp.NewToolResultText(fmt.Sprintf("Deleted store: %s", storeID)), nil
 		})
 	}
 
 	// Tool: import_file_to_store
-	if isToolEnabled("import_file_to_store") || isToolEnabled("all") {
-		s.AddTool(mcp.NewTool("import_file_to_store",
+	if enabled["import_file_to_store"] {
+		addTool(mcp.NewTool("import_file_to_store",
 			mcp.WithDescription("Import a file from the Files API into a File Search Store. Note: This does not preserve the original display name of the file."),
+			additiveTool("Import file into store"),
 			mcp.WithString("file_name", mcp.Required(), mcp.Description("The resource name or display name of the file to import.")),
 			mcp.WithString("store_name", mcp.Required(), mcp.Description("The resource name or display name of the store to import into.")),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -165,45 +278,55 @@ func NewServer(client GeminiClient, enabledTools []string) *server.MCPServer {
 				return mcp.NewToolResultError(fmt.Sprintf("Failed to resolve store name: %v", err)), nil
 			}
 
-			// Note: ImportFile now returns error only, but prints progress to stdout if not quiet.
-			// Since we are in MCP, we can't easily stream progress.
-			// We'll use Quiet=true to avoid stdout noise and just wait for completion.
-			err = client.ImportFile(ctx, fileID, storeID, &gemini.ImportFileOptions{Quiet: true})
+			err = client.ImportFile(ctx, fileID, storeID, &gemini.ImportFileOptions{
+				Progress: newProgressReporter(ctx, request),
+			})
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
+			notifyResourcesChanged(ctx)
 			return mcp.NewToolResultText(fmt.Sprintf("Imported file %s into store %s", fileID, storeID)), nil
 		})
 	}
 
 	// Tool: query_knowledge_base
-	if isToolEnabled("query_knowledge_base") || isToolEnabled("query") || isToolEnabled("all") {
-		s.AddTool(mcp.NewTool("query_knowledge_base",
-			mcp.WithDescription("Query the knowledge base using Gemini File Search. Use this to answer questions based on uploaded documents."),
+	if enabled["query_knowledge_base"] {
+		addTool(mcp.NewTool("query_knowledge_base",
+			mcp.WithDescription("Query the knowledge base using Gemini File Search. Use this to answer questions based on uploaded documents. Returns the answer, its citations (title, URI, pages and a snippet), token usage and model."),
+			readOnlyTool("Query knowledge base"),
+			mcp.WithOutputSchema[queryResult](),
 			mcp.WithString("query", mcp.Required(), mcp.Description("The question or query to ask.")),
 			mcp.WithString("store_name", mcp.Description("The resource name or display name of the store to search. If omitted, searches all stores (if supported) or requires specific configuration.")),
 			mcp.WithString("model", mcp.Description("The model to use (default: "+constants.DefaultModel+").")),
 			mcp.WithString("metadata_filter", mcp.Description("Optional metadata filter expression to narrow search results. Examples: 'category = \"research\"' for exact match, 'status = \"reviewed\" AND priority = \"high\"' for multiple conditions, 'author = \"Smith\"' for filtering by author metadata.")),
+			mcp.WithString("verbosity", mcp.Enum(verbosityCompact, verbosityFull), mcp.Description("compact (default) cites each source with a short snippet; full includes the full text of each cited chunk.")),
+			mcp.WithBoolean("no_cache", mcp.Description("Ask the API even if a cached answer to the same query exists (default: false).")),
 		), makeQueryKnowledgeBaseHandler(client))
+
+		// Prompts expand into query_knowledge_base calls
+		registerPrompts(s)
 	}
 
 	// Tool: upload_file
-	if isToolEnabled("upload_file") || isToolEnabled("upload") || isToolEnabled("all") {
-		s.AddTool(mcp.NewTool("upload_file",
+	if enabled["upload_file"] {
+		addTool(mcp.NewTool("upload_file",
 			mcp.WithDescription("Upload a local file to Gemini Files API and optionally add it to a store."),
-			mcp.WithString("path", mcp.Required(), mcp.Description("Absolute path to the local file.")),
+			additiveTool("Upload file"),
+			mcp.WithString("path", mcp.Required(), mcp.Description("Absolute path to the local file. It must be inside the workspace or the server's allowed upload roots.")),
 			mcp.WithString("store_name", mcp.Description("The resource name or display name of the store to add the file to.")),
 			mcp.WithString("name", mcp.Description("The display name of the file (optional).")),
 			mcp.WithString("mime_type", mcp.Description("The MIME type of the file (optional).")),
 			mcp.WithString("metadata", mcp.Description("Optional metadata as a JSON string. Examples: '{\"category\": \"research\", \"author\": \"Smith\"}' for multiple fields, '{\"status\": \"draft\"}' for single field, '{\"project\": \"Q4-2024\", \"priority\": \"high\"}' for project tracking. Only used if store_name is provided.")),
-		), makeUploadFileHandler(client))
+		), makeUploadFileHandler(client, uploads, denials))
 	}
 
 	// Tool: delete_file
-	if isToolEnabled("delete_file") || isToolEnabled("delete") || isToolEnabled("all") {
-		s.AddTool(mcp.NewTool("delete_file",
-			mcp.WithDescription("Delete a file from the Gemini Files API."),
+	if enabled["delete_file"] {
+		addTool(mcp.NewTool("delete_file",
+			mcp.WithDescription("Delete a file from the Gemini Files API. Depending on the server's policy, the call may instead return a preview of what would be deleted and a confirmation_token; deleting then takes a second call that passes the token back."),
+			destructiveTool("Delete file"),
 			mcp.WithString("file_name", mcp.Required(), mcp.Description("The resource name or display name of the file to delete.")),
+			mcp.WithString("confirmation_token", mcp.Description("The token from a previous call's deletion preview, once the user has approved it.")),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			args, ok := request.Params.Arguments.(map[string]interface{})
 			if !ok {
@@ -218,6 +341,11 @@ func NewServer(client GeminiClient, enabledTools []string) *server.MCPServer {
 			if err != nil {
 				return mcp.NewToolResultError(fmt.Sprintf("Failed to resolve file name: %v", err)), nil
 			}
+			if res := guard.authorize(ctx, "delete_file", fileID, args, func() (any, error) {
+				return client.GetFile(ctx, fileID)
+			}); res != nil {
+				return res, nil
+			}
 
 			err = client.DeleteFile(ctx, fileID)
 			if err != nil {
@@ -228,12 +356,14 @@ func NewServer(client GeminiClient, enabledTools []string) *server.MCPServer {
 	}
 
 	// Tool: delete_document
-	if isToolEnabled("delete_document") || isToolEnabled("delete") || isToolEnabled("all") {
-		s.AddTool(mcp.NewTool("delete_document",
-			mcp.WithDescription("Delete a document from a File Search Store."),
+	if enabled["delete_document"] {
+		addTool(mcp.NewTool("delete_document",
+			mcp.WithDescription("Delete a document from a File Search Store. Depending on the server's policy, the call may instead return a preview of what would be deleted and a confirmation_token; deleting then takes a second call that passes the token back."),
+			destructiveTool("Delete document"),
 			mcp.WithString("store_name", mcp.Required(), mcp.Description("The resource name or display name of the store.")),
 			mcp.WithString("document_name", mcp.Required(), mcp.Description("The resource name or display name of the document.")),
 			mcp.WithBoolean("force", mcp.Description("Force delete even if the document contains chunks.")),
+			mcp.WithString("confirmation_token", mcp.Description("The token from a previous call's deletion preview, once the user has approved it.")),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			args, ok := request.Params.Arguments.(map[string]interface{})
 			if !ok {
@@ -260,15 +390,69 @@ func NewServer(client GeminiClient, enabledTools []string) *server.MCPServer {
 			if err != nil {
 				return mcp.NewToolResultError(fmt.Sprintf("Failed to resolve document name: %v", err)), nil
 			}
+			if res := guard.authorize(ctx, "delete_document", docID, args, func() (any, error) {
+				return describeDocument(ctx, client, docID)
+			}); res != nil {
+				return res, nil
+			}
 
 			err = client.DeleteDocument(ctx, docID, force)
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
+			notifyResourcesChanged(ctx)
 			return mcp.NewToolResultText(fmt.Sprintf("Deleted document: %s from store %s", docID, storeID)), nil
 		})
 	}
 
+	// Tool: get_store
+	if enabled["get_store"] {
+		addTool(mcp.NewTool("get_store",
+			mcp.WithDescription("Get a File Search Store's details: active, pending and failed document counts, size in bytes and timestamps."),
+			readOnlyTool("Get store"),
+			mcp.WithString("store_name", mcp.Required(), mcp.Description("The resource name or display name of the store.")),
+		), makeGetStoreHandler(client))
+	}
+
+	// Tool: get_document
+	if enabled["get_document"] {
+		addTool(mcp.NewTool("get_document",
+			mcp.WithDescription("Get a document's details: state (pending, active or failed), size, MIME type and custom metadata. To find out why a document failed, check the operation that indexed it with get_operation."),
+			readOnlyTool("Get document"),
+			mcp.WithString("store_name", mcp.Required(), mcp.Description("The resource name or display name of the store.")),
+			mcp.WithString("document_name", mcp.Required(), mcp.Description("The resource name or display name of the document.")),
+		), makeGetDocumentHandler(client))
+	}
+
+	// Tool: get_operation
+	if enabled["get_operation"] {
+		addTool(mcp.NewTool("get_operation",
+			mcp.WithDescription("Get the status of an upload or import operation: whether it is done, whether it failed and why, and the document it created."),
+			readOnlyTool("Get operation"),
+			mcp.WithString("operation_name", mcp.Required(), mcp.Description("The operation name, e.g. fileSearchStores/abc123/operations/op456.")),
+			mcp.WithString("type", mcp.Enum("import", "upload"), mcp.Description("The operation type (auto-detected if omitted).")),
+		), makeGetOperationHandler(client))
+	}
+
+	// Tool: wait_operation
+	if enabled["wait_operation"] {
+		addTool(mcp.NewTool("wait_operation",
+			mcp.WithDescription("Wait for an upload or import operation to finish indexing and return its final status. Fails if the operation fails or is still running when the timeout expires."),
+			readOnlyTool("Wait for operation"),
+			mcp.WithString("operation_name", mcp.Required(), mcp.Description("The operation name, e.g. fileSearchStores/abc123/operations/op456.")),
+			mcp.WithString("type", mcp.Enum("import", "upload"), mcp.Description("The operation type (auto-detected if omitted).")),
+			mcp.WithNumber("timeout_seconds", mcp.Description(fmt.Sprintf("How long to wait before giving up (default: %d).", int(defaultWaitTimeout.Seconds())))),
+		), makeWaitOperationHandler(client, pollInterval))
+	}
+
+	// Tool: list_models
+	if enabled["list_models"] {
+		addTool(mcp.NewTool("list_models",
+			mcp.WithDescription("List the Gemini models available for query_knowledge_base. Returns a JSON array of model objects."),
+			readOnlyTool("List models"),
+		), makeListModelsHandler(client))
+	}
+
 	return s
 }
 
@@ -312,9 +496,13 @@ func makeQueryKnowledgeBaseHandler(client GeminiClient) func(ctx context.Context
 			model = constants.DefaultModel
 		}
 		metadataFilter, _ := getStringArg(args, "metadata_filter")
+		verbosityArg, _ := getStringArg(args, "verbosity")
+		verbosity, err := parseVerbosity(verbosityArg)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
 
 		var storeID string
-		var err error
 		if storeName != "" {
 			storeID, err = client.ResolveStoreName(ctx, storeName)
 			if err != nil {
@@ -322,11 +510,20 @@ func makeQueryKnowledgeBaseHandler(client GeminiClient) func(ctx context.Context
 			}
 		}
 
+		var cached querycache.Status
+		ctx = querycache.WithStatus(ctx, &cached)
+		if getBoolArg(args, "no_cache") {
+			ctx = querycache.Bypass(ctx)
+		}
 		resp, err := client.Query(ctx, query, storeID, model, metadataFilter)
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
-		res, err := mcp.NewToolResultJSON(resp)
+		result := newQueryResult(resp, model, verbosity)
+		if cached.Hit {
+			result.Cached, result.Usage = true, nil
+		}
+		res, err := mcp.NewToolResultJSON(result)
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
@@ -406,7 +603,7 @@ func makeListDocumentsHandler(client GeminiClient) func(ctx context.Context, req
 	}
 }
 
-func makeUploadFileHandler(client GeminiClient) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func makeUploadFileHandler(client GeminiClient, policy *UploadPolicy, denials *denialLog) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		args, ok := request.Params.Arguments.(map[string]interface{})
 		if !ok {
@@ -416,6 +613,13 @@ func makeUploadFileHandler(client GeminiClient) func(ctx context.Context, reques
 		if !ok {
 			return mcp.NewToolResultError("path must be a string"), nil
 		}
+		// Only read files inside the allowed roots, so a model can't exfiltrate arbitrary files
+		f, err := policy.openUpload(ctx, path)
+		if err != nil {
+			denials.record(ctx, "UploadFile", []string{path}, err.Error())
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		defer f.Close()
 		storeName, _ := getStringArg(args, "store_name")
 		displayName, _ := getStringArg(args, "name")
 		mimeType, _ := getStringArg(args, "mime_type")
@@ -430,7 +634,6 @@ func makeUploadFileHandler(client GeminiClient) func(ctx context.Context, reques
 		}
 
 		var storeID string
-		var err error
 		if storeName != "" {
 			storeID, err = client.ResolveStoreName(ctx, storeName)
 			if err != nil {
@@ -438,21 +641,27 @@ func makeUploadFileHandler(client GeminiClient) func(ctx context.Context, reques
 			}
 		}
 
+		if displayName == "" {
+			// Name the file after the path given, not the symlink target
+			displayName = filepath.Base(path)
+		}
 		opts := &gemini.UploadFileOptions{
 			StoreName:   storeID,
 			DisplayName: displayName,
 			MIMEType:    mimeType,
 			Metadata:    metadata,
-			Quiet:       true, // Suppress stdout progress
+			Progress:    newProgressReporter(ctx, request),
+			File:        f,
 		}
 
-		file, err := client.UploadFile(ctx, path, opts)
+		file, err := client.UploadFile(ctx, f.Name(), opts)
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
 
 		// If file is nil, it means it was uploaded to a store (UploadFile returns nil for store uploads as it handles the operation)
 		if file == nil {
+			notifyResourcesChanged(ctx)
 			return mcp.NewToolResultText(fmt.Sprintf("Uploaded %s to store %s", path, storeName)), nil
 		}
 
@@ -463,3 +672,92 @@ func makeUploadFileHandler(client GeminiClient) func(ctx context.Context, reques
 		return res, nil
 	}
 }
+
+func makeGetStoreHandler(client GeminiClient) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if client == nil {
+			return mcp.NewToolResultError("Gemini API key not configured. Please set GEMINI_API_KEY environment variable."), nil
+		}
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			return mcp.NewToolResultError("arguments must be a map"), nil
+		}
+		storeName, ok := getStringArg(args, "store_name")
+		if !ok {
+			return mcp.NewToolResultError("store_name must be a string"), nil
+		}
+
+		storeID, err := client.ResolveStoreName(ctx, storeName)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to resolve store name: %v", err)), nil
+		}
+
+		store, err := client.GetStore(ctx, storeID)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		res, err := mcp.NewToolResultJSON(store)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return res, nil
+	}
+}
+
+func makeGetDocumentHandler(client GeminiClient) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if client == nil {
+			return mcp.NewToolResultError("Gemini API key not configured. Please set GEMINI_API_KEY environment variable."), nil
+		}
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			return mcp.NewToolResultError("arguments must be a map"), nil
+		}
+		storeName, ok := getStringArg(args, "store_name")
+		if !ok {
+			return mcp.NewToolResultError("store_name must be a string"), nil
+		}
+		docName, ok := getStringArg(args, "document_name")
+		if !ok {
+			return mcp.NewToolResultError("document_name must be a string"), nil
+		}
+
+		storeID, err := client.ResolveStoreName(ctx, storeName)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to resolve store name: %v", err)), nil
+		}
+		docID, err := client.ResolveDocumentName(ctx, storeID, docName)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to resolve document name: %v", err)), nil
+		}
+
+		doc, err := client.GetDocument(ctx, docID)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		res, err := mcp.NewToolResultJSON(doc)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return res, nil
+	}
+}
+
+func makeListModelsHandler(client GeminiClient) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if client == nil {
+			return mcp.NewToolResultError("Gemini API key not configured. Please set GEMINI_API_KEY environment variable."), nil
+		}
+		models, err := client.ListModels(ctx)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		res, err := mcp.NewToolResultJSON(map[string]interface{}{
+			"models": models,
+		})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return res, nil
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -15,6 +16,8 @@ import (
 type MockGeminiClient struct {
 	ListStoresFunc          func(ctx context.Context) ([]*genai.FileSearchStore, error)
 	ListFilesFunc           func(ctx context.Context) ([]*genai.File, error)
+	GetFileFunc             func(ctx context.Context, name string) (*genai.File, error)
+	GetStoreFunc            func(ctx context.Context, name string) (*genai.FileSearchStore, error)
 	ResolveStoreNameFunc    func(ctx context.Context, nameOrID string) (string, error)
 	ListDocumentsFunc       func(ctx context.Context, storeID string) ([]*genai.Document, error)
 	CreateStoreFunc         func(ctx context.Context, displayName string) (*genai.FileSearchStore, error)
@@ -24,8 +27,11 @@ type MockGeminiClient struct {
 	QueryFunc               func(ctx context.Context, text string, storeName string, modelName string, metadataFilter string) (*genai.GenerateContentResponse, error)
 	UploadFileFunc          func(ctx context.Context, path string, opts *gemini.UploadFileOptions) (*genai.File, error)
 	DeleteFileFunc          func(ctx context.Context, name string) error
+	GetDocumentFunc         func(ctx context.Context, name string) (*genai.Document, error)
 	ResolveDocumentNameFunc func(ctx context.Context, storeNameOrID, docNameOrID string) (string, error)
 	DeleteDocumentFunc      func(ctx context.Context, name string, force bool) error
+	GetOperationFunc        func(ctx context.Context, operationName string, operationType gemini.OperationType) (*gemini.OperationStatus, error)
+	ListModelsFunc          func(ctx context.Context) ([]*genai.Model, error)
 	CloseFunc               func()
 }
 
@@ -35,6 +41,12 @@ func (m *MockGeminiClient) ListStores(ctx context.Context) ([]*genai.FileSearchS
 func (m *MockGeminiClient) ListFiles(ctx context.Context) ([]*genai.File, error) {
 	return m.ListFilesFunc(ctx)
 }
+func (m *MockGeminiClient) GetFile(ctx context.Context, name string) (*genai.File, error) {
+	return m.GetFileFunc(ctx, name)
+}
+func (m *MockGeminiClient) GetStore(ctx context.Context, name string) (*genai.FileSearchStore, error) {
+	return m.GetStoreFunc(ctx, name)
+}
 func (m *MockGeminiClient) ResolveStoreName(ctx context.Context, nameOrID string) (string, error) {
 	return m.ResolveStoreNameFunc(ctx, nameOrID)
 }
@@ -62,12 +74,21 @@ func (m *MockGeminiClient) UploadFile(ctx context.Context, path string, opts *ge
 func (m *MockGeminiClient) DeleteFile(ctx context.Context, name string) error {
 	return m.DeleteFileFunc(ctx, name)
 }
+func (m *MockGeminiClient) GetDocument(ctx context.Context, name string) (*genai.Document, error) {
+	return m.GetDocumentFunc(ctx, name)
+}
 func (m *MockGeminiClient) ResolveDocumentName(ctx context.Context, storeNameOrID, docNameOrID string) (string, error) {
 	return m.ResolveDocumentNameFunc(ctx, storeNameOrID, docNameOrID)
 }
 func (m *MockGeminiClient) DeleteDocument(ctx context.Context, name string, force bool) error {
 	return m.DeleteDocumentFunc(ctx, name, force)
 }
+func (m *MockGeminiClient) GetOperation(ctx context.Context, operationName string, operationType gemini.OperationType) (*gemini.OperationStatus, error) {
+	return m.GetOperationFunc(ctx, operationName, operationType)
+}
+func (m *MockGeminiClient) ListModels(ctx context.Context) ([]*genai.Model, error) {
+	return m.ListModelsFunc(ctx)
+}
 func (m *MockGeminiClient) Close() {
 	if m.CloseFunc != nil {
 		m.CloseFunc()
@@ -111,6 +132,11 @@ func TestNewServer_ToolRegistration(t *testing.T) {
 		"upload_file",
 		"delete_file",
 		"delete_document",
+		"get_store",
+		"get_document",
+		"get_operation",
+		"wait_operation",
+		"list_models",
 	}
 
 	for _, expected := range expectedTools {
@@ -339,3 +365,65 @@ func TestListDocumentsHandler_OutputFormat(t *testing.T) {
 		t.Errorf("Expected 'documents' to be a non-empty array, got: %s", textContent.Text)
 	}
 }
+
+func TestDetailHandlers(t *testing.T) {
+	client := resourceClient()
+	client.ListModelsFunc = func(ctx context.Context) ([]*genai.Model, error) {
+		return []*genai.Model{{Name: "models/gemini-2.5-flash"}}, nil
+	}
+
+	tests := []struct {
+		name      string
+		handler   func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error)
+		args      map[string]interface{}
+		want      string
+		wantError bool
+	}{
+		{
+			name:    "get store",
+			handler: makeGetStoreHandler(client),
+			args:    map[string]interface{}{"store_name": "Team KB"},
+			want:    `"activeDocumentsCount":"1"`,
+		},
+		{
+			name:      "get missing store",
+			handler:   makeGetStoreHandler(client),
+			args:      map[string]interface{}{"store_name": "Nope"},
+			want:      "Failed to resolve store name",
+			wantError: true,
+		},
+		{
+			name:    "get document",
+			handler: makeGetDocumentHandler(client),
+			args:    map[string]interface{}{"store_name": "Team KB", "document_name": "guide.md"},
+			want:    `"state":"STATE_ACTIVE"`,
+		},
+		{
+			name:      "get document without store",
+			handler:   makeGetDocumentHandler(client),
+			args:      map[string]interface{}{"document_name": "guide.md"},
+			want:      "store_name must be a string",
+			wantError: true,
+		},
+		{
+			name:    "list models",
+			handler: makeListModelsHandler(client),
+			args:    map[string]interface{}{},
+			want:    `"models":[{"name":"models/gemini-2.5-flash"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.handler(context.Background(), mcp.CallToolRequest{
+				Params: mcp.CallToolParams{Arguments: tt.args},
+			})
+			if err != nil {
+				t.Fatalf("handler returned error: %v", err)
+			}
+			text := result.Content[0].(mcp.TextContent).Text
+			if result.IsError != tt.wantError || !strings.Contains(text, tt.want) {
+				t.Errorf("result = %q (isError %v), want %q (isError %v)", text, result.IsError, tt.want, tt.wantError)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package mcp
+
+import (
+	"context"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/mikesmitty/file-search/internal/telemetry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var toolNameAttr = attribute.Key("mcp.tool.name")
+
+// withTracing returns a tool handler middleware that wraps each tool call in
+// a span, so the API calls it makes nest under it, and records its duration.
+// It uses the global OpenTelemetry providers, which are no-op unless
+// telemetry.Setup installed exporters.
+func withTracing() server.ToolHandlerMiddleware {
+	tracer := otel.Tracer(telemetry.InstrumentationName)
+	duration, _ := otel.Meter(telemetry.InstrumentationName).Float64Histogram("filesearch.mcp.tool.duration",
+		metric.WithDescription("Duration of MCP tool calls"),
+		metric.WithUnit("s"))
+
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			tool := request.Params.Name
+			attrs := []attribute.KeyValue{toolNameAttr.String(tool)}
+			if session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo); ok {
+				attrs = append(attrs, attribute.String("mcp.client.name", session.GetClientInfo().Name))
+			}
+			ctx, span := tracer.Start(ctx, "mcp.tool "+tool, trace.WithAttributes(attrs...), trace.WithSpanKind(trace.SpanKindServer))
+			defer span.End()
+
+			start := time.Now()
+			result, err := next(ctx, request)
+			status := telemetry.StatusOK
+			switch {
+			case err != nil:
+				status = telemetry.StatusError
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			case result != nil && result.IsError:
+				status = telemetry.StatusError
+				span.SetStatus(codes.Error, "tool returned an error result")
+			}
+			span.SetAttributes(telemetry.StatusKey.String(status))
+			duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(toolNameAttr.String(tool), telemetry.StatusKey.String(status)))
+			return result, err
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package mcp
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestToolTracing(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	s := NewServerWithOptions(accessClient(), &Options{
+		Tools:       []string{"all"},
+		StoreAccess: StoreAccess{"delete_*": {"!prod-*"}},
+		Destructive: DestructiveAllow,
+	})
+	callToolText(t, s, "list_stores", map[string]any{})
+	callToolText(t, s, "delete_store", map[string]any{"store_name": "prod-kb"})
+
+	want := map[string]codes.Code{
+		"mcp.tool list_stores":  codes.Unset,
+		"mcp.tool delete_store": codes.Error,
+	}
+	for _, span := range spans.Ended() {
+		code, ok := want[span.Name()]
+		if !ok {
+			continue
+		}
+		delete(want, span.Name())
+		if span.Status().Code != code {
+			t.Errorf("%s status = %v, want %v", span.Name(), span.Status().Code, code)
+		}
+	}
+	for name := range want {
+		t.Errorf("no %q span recorded", name)
+	}
+}
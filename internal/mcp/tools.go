@@ -0,0 +1,159 @@
+package mcp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// toolRegistry lists every tool the server can register, in registration
+// order, with the groups it belongs to. "all" covers every tool.
+var toolRegistry = []struct {
+	name   string
+	groups []string
+}{
+	{"list_stores", []string{"read"}},
+	{"list_files", []string{"read"}},
+	{"list_documents", []string{"read"}},
+	{"create_store", []string{"write"}},
+	{"delete_store", []string{"admin", "delete"}},
+	{"import_file_to_store", []string{"write"}},
+	{"query_knowledge_base", []string{"read", "query"}},
+	{"upload_file", []string{"write", "upload"}},
+	{"delete_file", []string{"admin", "delete"}},
+	{"delete_document", []string{"admin", "delete"}},
+	{"get_store", []string{"read"}},
+	{"get_document", []string{"read"}},
+	{"get_operation", []string{"read"}},
+	{"wait_operation", []string{"read"}},
+	{"list_models", []string{"read"}},
+}
+
+// ToolGroups maps each tool group to the tools in it. The read, write and
+// admin groups follow the tool annotations: read-only tools, tools that add
+// stores or files, and tools that delete them. "upload" and "delete" are kept
+// from earlier releases.
+var ToolGroups = func() map[string][]string {
+	groups := map[string][]string{}
+	for _, tool := range toolRegistry {
+		groups["all"] = append(groups["all"], tool.name)
+		for _, group := range tool.groups {
+			groups[group] = append(groups[group], tool.name)
+		}
+	}
+	return groups
+}()
+
+// ToolNames returns the name of every tool, in registration order.
+func ToolNames() []string {
+	return append([]string(nil), ToolGroups["all"]...)
+}
+
+// ResolveTools expands a tool selection into tool names, in registration
+// order. Entries are tool names or groups, and an entry starting with "-"
+// removes a tool or group added before it, e.g. "all,-admin". A selection
+// that starts with an exclusion starts from all tools. Unknown names are an
+// error, so a typo can't silently disable a tool.
+func ResolveTools(selection []string) ([]string, error) {
+	enabled := map[string]bool{}
+	first := true
+	for _, entry := range selection {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		exclude := strings.HasPrefix(entry, "-")
+		name := strings.TrimPrefix(entry, "-")
+		tools, ok := ToolGroups[name]
+		if !ok {
+			if !isTool(name) {
+				return nil, unknownToolError(name)
+			}
+			tools = []string{name}
+		}
+		if exclude && first {
+			for _, tool := range ToolNames() {
+				enabled[tool] = true
+			}
+		}
+		first = false
+		for _, tool := range tools {
+			enabled[tool] = !exclude
+		}
+	}
+
+	var resolved []string
+	for _, tool := range ToolNames() {
+		if enabled[tool] {
+			resolved = append(resolved, tool)
+		}
+	}
+	return resolved, nil
+}
+
+// ToolInfo describes a registered tool.
+type ToolInfo struct {
+	Name        string   `json:"name"`
+	Title       string   `json:"title"`
+	Groups      []string `json:"groups"`
+	ReadOnly    bool     `json:"readOnly"`
+	Destructive bool     `json:"destructive"`
+}
+
+// DescribeTools returns the tools a server built with opts would register,
+// after read-only mode is applied, in registration order.
+func DescribeTools(opts *Options) ([]ToolInfo, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	if _, err := ResolveTools(opts.Tools); err != nil {
+		return nil, err
+	}
+	registered := NewServerWithOptions(nil, opts).ListTools()
+	infos := []ToolInfo{}
+	for _, tool := range toolRegistry {
+		st, ok := registered[tool.name]
+		if !ok {
+			continue
+		}
+		a := st.Tool.Annotations
+		infos = append(infos, ToolInfo{
+			Name:        tool.name,
+			Title:       a.Title,
+			Groups:      append([]string{"all"}, tool.groups...),
+			ReadOnly:    a.ReadOnlyHint != nil && *a.ReadOnlyHint,
+			Destructive: a.DestructiveHint != nil && *a.DestructiveHint,
+		})
+	}
+	return infos, nil
+}
+
+// skipUnknownTools drops the entries ResolveTools would reject.
+func skipUnknownTools(selection []string) []string {
+	var known []string
+	for _, entry := range selection {
+		name := strings.TrimPrefix(strings.TrimSpace(entry), "-")
+		if _, ok := ToolGroups[name]; ok || isTool(name) {
+			known = append(known, entry)
+		}
+	}
+	return known
+}
+
+func isTool(name string) bool {
+	for _, tool := range toolRegistry {
+		if tool.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func unknownToolError(name string) error {
+	groups := make([]string, 0, len(ToolGroups))
+	for group := range ToolGroups {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	return fmt.Errorf("unknown MCP tool or group %q (groups: %s; tools: %s)", name, strings.Join(groups, ", "), strings.Join(ToolNames(), ", "))
+}
@@ -0,0 +1,86 @@
+package mcp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveTools(t *testing.T) {
+	tests := []struct {
+		name      string
+		selection []string
+		want      []string
+		wantErr   string
+	}{
+		{name: "empty", selection: nil, want: nil},
+		{name: "tool", selection: []string{"get_store"}, want: []string{"get_store"}},
+		{name: "query group", selection: []string{"query"}, want: []string{"query_knowledge_base"}},
+		{name: "admin group", selection: []string{"admin"}, want: []string{"delete_store", "delete_file", "delete_document"}},
+		{name: "write group", selection: []string{"write"}, want: []string{"create_store", "import_file_to_store", "upload_file"}},
+		{name: "registration order", selection: []string{"upload_file", "list_stores"}, want: []string{"list_stores", "upload_file"}},
+		{name: "exclude group", selection: []string{"all", "-read", "-admin"}, want: []string{"create_store", "import_file_to_store", "upload_file"}},
+		{name: "exclude then add back", selection: []string{"admin", "-delete", "delete_file"}, want: []string{"delete_file"}},
+		{name: "leading exclusion starts from all", selection: []string{"-read", "-write"}, want: []string{"delete_store", "delete_file", "delete_document"}},
+		{name: "blanks and spaces", selection: []string{" query ", ""}, want: []string{"query_knowledge_base"}},
+		{name: "typo", selection: []string{"query", "lsit_stores"}, wantErr: `unknown MCP tool or group "lsit_stores"`},
+		{name: "unknown exclusion", selection: []string{"all", "-manage"}, wantErr: `unknown MCP tool or group "manage"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveTools(tt.selection)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ResolveTools(%v) error = %v, want %q", tt.selection, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveTools(%v) = %v, want %v", tt.selection, got, tt.want)
+			}
+		})
+	}
+}
+
+// The registry and the server must agree on which tools exist, and the read,
+// write and admin groups must match the tool annotations.
+func TestToolRegistryMatchesServer(t *testing.T) {
+	infos, err := DescribeTools(&Options{Tools: []string{"all"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != len(ToolNames()) {
+		t.Errorf("server registers %d tools, registry has %d", len(infos), len(ToolNames()))
+	}
+	registered := getRegisteredTools(NewServer(&MockGeminiClient{}, []string{"all"}))
+	if len(registered) != len(ToolNames()) {
+		t.Errorf("server registers %v, registry has %v", registered, ToolNames())
+	}
+	for _, info := range infos {
+		want := "write"
+		if info.ReadOnly {
+			want = "read"
+		} else if info.Destructive {
+			want = "admin"
+		}
+		if !contains(info.Groups, want) {
+			t.Errorf("%s is in groups %v, want %s", info.Name, info.Groups, want)
+		}
+	}
+}
+
+func TestDescribeToolsReadOnly(t *testing.T) {
+	infos, err := DescribeTools(&Options{Tools: []string{"write", "query"}, ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].Name != "query_knowledge_base" {
+		t.Errorf("DescribeTools = %+v, want only query_knowledge_base", infos)
+	}
+	if _, err := DescribeTools(&Options{Tools: []string{"list"}}); err == nil {
+		t.Error("DescribeTools accepted an unknown group")
+	}
+}
@@ -0,0 +1,249 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/gemini"
+)
+
+// redrawInterval limits how often byte counts redraw the MultiBar and is how
+// often elapsed times tick over between events.
+const redrawInterval = 100 * time.Millisecond
+
+// nameWidth is the widest file name shown on a worker line.
+const nameWidth = 32
+
+// worker is the display state of one in-flight upload or import.
+type worker struct {
+	name      string
+	operation string
+	sent      int64
+	total     int64
+	started   time.Time
+	indexing  time.Time
+}
+
+// MultiBar renders a batch on a terminal: one line per in-flight file with its
+// upload bytes or indexing time and operation, and an overall bar with
+// throughput and ETA. Finished files scroll above the live lines.
+//
+// Per-file events arrive through Report; Complete records each file's outcome
+// and should be called once per file in the batch. Stop must be called when
+// the batch ends.
+type MultiBar struct {
+	mu      sync.Mutex
+	w       io.Writer
+	now     func() time.Time
+	total   int
+	done    int
+	failed  int
+	bytes   int64
+	start   time.Time
+	active  map[string]*worker
+	order   []string
+	drawn   int
+	drawnAt time.Time
+	stop    chan struct{}
+	stopped sync.WaitGroup
+}
+
+// NewMultiBar creates a MultiBar for a batch of total files that writes to w,
+// which should be a terminal. It redraws periodically until Stop is called.
+func NewMultiBar(w io.Writer, total int) *MultiBar {
+	m := newMultiBar(w, total, time.Now)
+	m.stopped.Add(1)
+	go m.tick()
+	return m
+}
+
+func newMultiBar(w io.Writer, total int, now func() time.Time) *MultiBar {
+	return &MultiBar{
+		w:      w,
+		now:    now,
+		total:  total,
+		start:  now(),
+		active: make(map[string]*worker),
+		stop:   make(chan struct{}),
+	}
+}
+
+func (m *MultiBar) tick() {
+	defer m.stopped.Done()
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-t.C:
+			m.mu.Lock()
+			m.redraw()
+			m.mu.Unlock()
+		}
+	}
+}
+
+// Report implements gemini.ProgressReporter.
+func (m *MultiBar) Report(e gemini.ProgressEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w := m.active[e.Source]
+	switch e.Type {
+	case gemini.ProgressUploadStarted:
+		if w == nil {
+			w = &worker{name: filepath.Base(e.Source), started: m.now()}
+			m.active[e.Source] = w
+			m.order = append(m.order, e.Source)
+		}
+		w.total = e.TotalBytes
+	case gemini.ProgressBytesSent:
+		if w == nil {
+			return
+		}
+		m.bytes += e.BytesSent - w.sent
+		w.sent = e.BytesSent
+		if m.now().Sub(m.drawnAt) < redrawInterval {
+			return
+		}
+	case gemini.ProgressOperationCreated, gemini.ProgressPolling:
+		if w == nil {
+			return
+		}
+		w.operation = e.Operation
+		if w.indexing.IsZero() {
+			w.indexing = m.now()
+		}
+	case gemini.ProgressDone, gemini.ProgressFailed:
+		m.remove(e.Source)
+	}
+	m.redraw()
+}
+
+// Complete records the outcome of one file and prints it above the live lines.
+func (m *MultiBar) Complete(file string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(file)
+	if err != nil {
+		m.failed++
+	} else {
+		m.done++
+	}
+	m.clear()
+	current := m.done + m.failed
+	if err != nil {
+		fmt.Fprintf(m.w, "[%d/%d] ✗ Failed: %s (%v)\n", current, m.total, filepath.Base(file), err)
+	} else {
+		fmt.Fprintf(m.w, "[%d/%d] ✓ Finished: %s\n", current, m.total, filepath.Base(file))
+	}
+	m.redraw()
+}
+
+// Stop stops redrawing and erases the live lines.
+func (m *MultiBar) Stop() {
+	select {
+	case <-m.stop:
+		return
+	default:
+		close(m.stop)
+	}
+	m.stopped.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clear()
+}
+
+func (m *MultiBar) remove(source string) {
+	if _, ok := m.active[source]; !ok {
+		return
+	}
+	delete(m.active, source)
+	for i, s := range m.order {
+		if s == source {
+			m.order = append(m.order[:i], m.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// clear moves the cursor back over the live lines and erases them.
+func (m *MultiBar) clear() {
+	if m.drawn > 0 {
+		fmt.Fprintf(m.w, "\033[%dA\r\033[J", m.drawn)
+	}
+	m.drawn = 0
+}
+
+// redraw replaces the live lines with the current state.
+func (m *MultiBar) redraw() {
+	select {
+	case <-m.stop:
+		return
+	default:
+	}
+	now := m.now()
+	var b strings.Builder
+	for _, source := range m.order {
+		b.WriteString(m.workerLine(m.active[source], now))
+		b.WriteString("\033[K\n")
+	}
+	b.WriteString(m.overallLine(now))
+	b.WriteString("\033[K\n")
+
+	m.clear()
+	io.WriteString(m.w, b.String())
+	m.drawn = len(m.order) + 1
+	m.drawnAt = now
+}
+
+func (m *MultiBar) workerLine(w *worker, now time.Time) string {
+	name := w.name
+	if len(name) > nameWidth {
+		name = name[:nameWidth-1] + "…"
+	}
+	if !w.indexing.IsZero() {
+		return fmt.Sprintf("  %-*s indexing %s  %s", nameWidth, name, now.Sub(w.indexing).Round(time.Second), w.operation)
+	}
+	if w.total <= 0 {
+		return fmt.Sprintf("  %-*s starting %s", nameWidth, name, now.Sub(w.started).Round(time.Second))
+	}
+	return fmt.Sprintf("  %-*s %s %3d%% %s / %s", nameWidth, name,
+		bar(w.sent, w.total, barWidth/2), w.sent*100/w.total, FormatBytes(w.sent), FormatBytes(w.total))
+}
+
+func (m *MultiBar) overallLine(now time.Time) string {
+	finished := m.done + m.failed
+	elapsed := now.Sub(m.start)
+	line := fmt.Sprintf("%s %d/%d files", bar(int64(finished), int64(m.total), barWidth), finished, m.total)
+	if m.failed > 0 {
+		line += fmt.Sprintf(", %d failed", m.failed)
+	}
+	if secs := elapsed.Seconds(); secs > 0 && m.bytes > 0 {
+		line += fmt.Sprintf("  %s/s", FormatBytes(int64(float64(m.bytes)/secs)))
+	}
+	if finished > 0 && finished < m.total {
+		eta := elapsed / time.Duration(finished) * time.Duration(m.total-finished)
+		line += fmt.Sprintf("  ETA %s", eta.Round(time.Second))
+	}
+	return line
+}
+
+// bar draws a bracketed bar of width cells filled in proportion to n/total.
+func bar(n, total int64, width int) string {
+	filled := 0
+	if total > 0 {
+		filled = int(n * int64(width) / total)
+	}
+	if filled > width {
+		filled = width
+	}
+	return "[" + strings.Repeat("=", filled) + strings.Repeat(" ", width-filled) + "]"
+}
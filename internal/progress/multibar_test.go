@@ -0,0 +1,71 @@
+package progress
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/gemini"
+)
+
+// fakeClock advances by step on every reading.
+func fakeClock(step time.Duration) func() time.Time {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		now = now.Add(step)
+		return now
+	}
+}
+
+func TestMultiBar(t *testing.T) {
+	var buf bytes.Buffer
+	m := newMultiBar(&buf, 3, fakeClock(time.Second))
+
+	m.Report(gemini.ProgressEvent{Type: gemini.ProgressUploadStarted, Source: "/docs/a.txt", TotalBytes: 100})
+	m.Report(gemini.ProgressEvent{Type: gemini.ProgressUploadStarted, Source: "/docs/b.txt", TotalBytes: 200})
+	m.Report(gemini.ProgressEvent{Type: gemini.ProgressBytesSent, Source: "/docs/b.txt", BytesSent: 100, TotalBytes: 200})
+	m.Report(gemini.ProgressEvent{Type: gemini.ProgressOperationCreated, Source: "/docs/a.txt", Operation: "op/a"})
+	m.Report(gemini.ProgressEvent{Type: gemini.ProgressPolling, Source: "/docs/a.txt", Operation: "op/a"})
+
+	last := lastFrame(buf.String())
+	for _, want := range []string{"a.txt", "indexing 2s  op/a", "b.txt", " 50% 100 B / 200 B", "0/3 files"} {
+		if !strings.Contains(last, want) {
+			t.Errorf("live lines missing %q:\n%s", want, last)
+		}
+	}
+
+	m.Report(gemini.ProgressEvent{Type: gemini.ProgressDone, Source: "/docs/a.txt", Document: "doc/a"})
+	m.Complete("/docs/a.txt", nil)
+	m.Report(gemini.ProgressEvent{Type: gemini.ProgressFailed, Source: "/docs/b.txt"})
+	m.Complete("/docs/b.txt", errors.New("boom"))
+
+	out := buf.String()
+	for _, want := range []string{"[1/3] ✓ Finished: a.txt\n", "[2/3] ✗ Failed: b.txt (boom)\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%q", want, out)
+		}
+	}
+	last = lastFrame(out)
+	if strings.Contains(last, "  a.txt") || strings.Contains(last, "  b.txt") {
+		t.Errorf("finished files still shown as in flight:\n%s", last)
+	}
+	for _, want := range []string{"2/3 files, 1 failed", "B/s", "ETA "} {
+		if !strings.Contains(last, want) {
+			t.Errorf("overall line missing %q:\n%s", want, last)
+		}
+	}
+
+	close(m.stop)
+	m.clear()
+	if !strings.HasSuffix(buf.String(), "\033[1A\r\033[J") {
+		t.Errorf("Stop did not erase the live lines: %q", buf.String())
+	}
+}
+
+// lastFrame returns the text drawn after the final clear sequence.
+func lastFrame(out string) string {
+	i := strings.LastIndex(out, "\033[J")
+	return strings.ReplaceAll(out[i+len("\033[J"):], "\033[K", "")
+}
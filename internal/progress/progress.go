@@ -0,0 +1,206 @@
+// Package progress renders upload and import progress events from the gemini
+// client: a redrawn status line for terminals, plain log lines, and JSON Lines
+// for machines. Every reporter is safe for concurrent use.
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/gemini"
+)
+
+// IsTerminal reports whether f is an interactive terminal.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// FormatBytes formats a byte count with a binary unit, e.g. "1.5 MiB".
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// Percent returns how much of the upload has been sent, from 0 to 100.
+func Percent(e gemini.ProgressEvent) int {
+	if e.TotalBytes <= 0 {
+		return 0
+	}
+	return int(e.BytesSent * 100 / e.TotalBytes)
+}
+
+// Describe returns a one-line, human readable description of e.
+func Describe(e gemini.ProgressEvent) string {
+	name := filepath.Base(e.Source)
+	switch e.Type {
+	case gemini.ProgressUploadStarted:
+		if e.Kind == gemini.OperationTypeImport {
+			return fmt.Sprintf("Importing %s into store %s", e.Source, e.Store)
+		}
+		if e.Store != "" {
+			return fmt.Sprintf("Uploading %s to store %s (%s)", name, e.Store, FormatBytes(e.TotalBytes))
+		}
+		return fmt.Sprintf("Uploading %s (%s)", name, FormatBytes(e.TotalBytes))
+	case gemini.ProgressBytesSent:
+		return fmt.Sprintf("Uploading %s: %s / %s (%d%%)", name, FormatBytes(e.BytesSent), FormatBytes(e.TotalBytes), Percent(e))
+	case gemini.ProgressOperationCreated:
+		return fmt.Sprintf("Indexing %s (operation %s)", name, e.Operation)
+	case gemini.ProgressPolling:
+		return fmt.Sprintf("Indexing %s... (%s elapsed)", name, e.Elapsed.Round(time.Second))
+	case gemini.ProgressDone:
+		switch {
+		case e.Document != "":
+			return fmt.Sprintf("✓ Indexed %s as %s", name, e.Document)
+		case e.File != "":
+			return fmt.Sprintf("✓ Uploaded %s as %s", name, e.File)
+		}
+		return fmt.Sprintf("✓ Finished %s", name)
+	case gemini.ProgressFailed:
+		return fmt.Sprintf("✗ Failed %s: %v", name, e.Err)
+	}
+	return fmt.Sprintf("%s %s", e.Type, name)
+}
+
+// barWidth is the number of cells in the terminal progress bar.
+const barWidth = 24
+
+// Bar redraws a single status line on a terminal: a progress bar while the
+// file uploads, then the elapsed indexing time. Finished and failed files are
+// printed on their own line.
+type Bar struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewBar creates a Bar that writes to w, which should be a terminal.
+func NewBar(w io.Writer) *Bar {
+	return &Bar{w: w}
+}
+
+// Report implements gemini.ProgressReporter.
+func (b *Bar) Report(e gemini.ProgressEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch e.Type {
+	case gemini.ProgressDone, gemini.ProgressFailed:
+		fmt.Fprintf(b.w, "\r\033[K%s\n", Describe(e))
+	case gemini.ProgressBytesSent, gemini.ProgressUploadStarted:
+		if e.Kind == gemini.OperationTypeImport {
+			fmt.Fprintf(b.w, "\r\033[K%s", Describe(e))
+			return
+		}
+		fmt.Fprintf(b.w, "\r\033[K%s %s %3d%% %s / %s",
+			filepath.Base(e.Source), bar(e.BytesSent, e.TotalBytes, barWidth),
+			Percent(e), FormatBytes(e.BytesSent), FormatBytes(e.TotalBytes))
+	default:
+		fmt.Fprintf(b.w, "\r\033[K%s", Describe(e))
+	}
+}
+
+// Log writes one line per state change. Byte counts are logged in 25% steps
+// so large uploads don't flood the output.
+type Log struct {
+	mu   sync.Mutex
+	w    io.Writer
+	last map[string]int
+}
+
+// NewLog creates a Log that writes to w.
+func NewLog(w io.Writer) *Log {
+	return &Log{w: w, last: make(map[string]int)}
+}
+
+// Report implements gemini.ProgressReporter.
+func (l *Log) Report(e gemini.ProgressEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e.Type == gemini.ProgressBytesSent {
+		step := Percent(e) / 25
+		if step <= l.last[e.Source] {
+			return
+		}
+		l.last[e.Source] = step
+	}
+	if e.Type == gemini.ProgressDone || e.Type == gemini.ProgressFailed {
+		delete(l.last, e.Source)
+	}
+	fmt.Fprintf(l.w, "%s %s\n", e.Time.Format(time.TimeOnly), Describe(e))
+}
+
+// Event is the JSON Lines encoding of a gemini.ProgressEvent.
+type Event struct {
+	Time       time.Time `json:"time"`
+	Event      string    `json:"event"`
+	Source     string    `json:"source,omitempty"`
+	Store      string    `json:"store,omitempty"`
+	Operation  string    `json:"operation,omitempty"`
+	BytesSent  int64     `json:"bytesSent,omitempty"`
+	TotalBytes int64     `json:"totalBytes,omitempty"`
+	ElapsedMS  int64     `json:"elapsedMs,omitempty"`
+	File       string    `json:"file,omitempty"`
+	Document   string    `json:"document,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// NewEvent converts a progress event to its JSON form.
+func NewEvent(e gemini.ProgressEvent) Event {
+	ev := Event{
+		Time:       e.Time.UTC(),
+		Event:      string(e.Type),
+		Source:     e.Source,
+		Store:      e.Store,
+		Operation:  e.Operation,
+		BytesSent:  e.BytesSent,
+		TotalBytes: e.TotalBytes,
+		ElapsedMS:  e.Elapsed.Milliseconds(),
+		File:       e.File,
+		Document:   e.Document,
+	}
+	if e.Err != nil {
+		ev.Error = e.Err.Error()
+	}
+	return ev
+}
+
+// JSONLines writes each event as a JSON object on its own line.
+type JSONLines struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONLines creates a JSONLines reporter that writes to w.
+func NewJSONLines(w io.Writer) *JSONLines {
+	return &JSONLines{enc: json.NewEncoder(w)}
+}
+
+// Report implements gemini.ProgressReporter.
+func (j *JSONLines) Report(e gemini.ProgressEvent) {
+	j.Write(NewEvent(e))
+}
+
+// Write encodes v as one line. It lets callers interleave their own records
+// with progress events on the same stream.
+func (j *JSONLines) Write(v any) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.enc.Encode(v)
+}
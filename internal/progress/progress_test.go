@@ -0,0 +1,117 @@
+package progress
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/gemini"
+)
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1536, "1.5 KiB"},
+		{5 * 1024 * 1024, "5.0 MiB"},
+	}
+	for _, tt := range tests {
+		if got := FormatBytes(tt.n); got != tt.want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		name  string
+		event gemini.ProgressEvent
+		want  string
+	}{
+		{"upload to store", gemini.ProgressEvent{Type: gemini.ProgressUploadStarted, Kind: gemini.OperationTypeUpload, Source: "/tmp/a.pdf", Store: "fileSearchStores/s", TotalBytes: 2048}, "Uploading a.pdf to store fileSearchStores/s (2.0 KiB)"},
+		{"upload to files", gemini.ProgressEvent{Type: gemini.ProgressUploadStarted, Kind: gemini.OperationTypeUpload, Source: "/tmp/a.pdf", TotalBytes: 10}, "Uploading a.pdf (10 B)"},
+		{"empty upload", gemini.ProgressEvent{Type: gemini.ProgressUploadStarted, Kind: gemini.OperationTypeUpload, Source: "/tmp/empty.txt", Store: "fileSearchStores/s"}, "Uploading empty.txt to store fileSearchStores/s (0 B)"},
+		{"import", gemini.ProgressEvent{Type: gemini.ProgressUploadStarted, Kind: gemini.OperationTypeImport, Source: "files/abc", Store: "fileSearchStores/s"}, "Importing files/abc into store fileSearchStores/s"},
+		{"bytes", gemini.ProgressEvent{Type: gemini.ProgressBytesSent, Source: "a.pdf", BytesSent: 512, TotalBytes: 2048}, "Uploading a.pdf: 512 B / 2.0 KiB (25%)"},
+		{"operation", gemini.ProgressEvent{Type: gemini.ProgressOperationCreated, Source: "a.pdf", Operation: "op/1"}, "Indexing a.pdf (operation op/1)"},
+		{"polling", gemini.ProgressEvent{Type: gemini.ProgressPolling, Source: "a.pdf", Elapsed: 3400 * time.Millisecond}, "Indexing a.pdf... (3s elapsed)"},
+		{"indexed", gemini.ProgressEvent{Type: gemini.ProgressDone, Source: "a.pdf", Document: "doc/1"}, "✓ Indexed a.pdf as doc/1"},
+		{"uploaded", gemini.ProgressEvent{Type: gemini.ProgressDone, Source: "a.pdf", File: "files/1"}, "✓ Uploaded a.pdf as files/1"},
+		{"failed", gemini.ProgressEvent{Type: gemini.ProgressFailed, Source: "a.pdf", Err: errors.New("boom")}, "✗ Failed a.pdf: boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Describe(tt.event); got != tt.want {
+				t.Errorf("Describe() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// uploadEvents simulates a 100 byte upload sent in 10 byte reads.
+func uploadEvents() []gemini.ProgressEvent {
+	events := []gemini.ProgressEvent{{Type: gemini.ProgressUploadStarted, Source: "a.txt", TotalBytes: 100}}
+	for sent := int64(10); sent <= 100; sent += 10 {
+		events = append(events, gemini.ProgressEvent{Type: gemini.ProgressBytesSent, Source: "a.txt", BytesSent: sent, TotalBytes: 100})
+	}
+	return append(events,
+		gemini.ProgressEvent{Type: gemini.ProgressOperationCreated, Source: "a.txt", Operation: "op/1"},
+		gemini.ProgressEvent{Type: gemini.ProgressPolling, Source: "a.txt", Operation: "op/1"},
+		gemini.ProgressEvent{Type: gemini.ProgressDone, Source: "a.txt", Operation: "op/1", Document: "doc/1"},
+	)
+}
+
+func TestLog(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLog(&buf)
+	for _, e := range uploadEvents() {
+		l.Report(e)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	// started, 4 byte steps (25/50/75/100%), operation, polling, done
+	if len(lines) != 8 {
+		t.Fatalf("expected 8 lines, got %d:\n%s", len(lines), buf.String())
+	}
+	if !strings.HasSuffix(lines[7], "✓ Indexed a.txt as doc/1") {
+		t.Errorf("unexpected last line: %q", lines[7])
+	}
+}
+
+func TestJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	j := NewJSONLines(&buf)
+	j.Report(gemini.ProgressEvent{Type: gemini.ProgressFailed, Source: "a.txt", Elapsed: 1500 * time.Millisecond, Err: errors.New("boom")})
+	j.Write(map[string]string{"event": "summary"})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", buf.String())
+	}
+	var ev Event
+	if err := json.Unmarshal([]byte(lines[0]), &ev); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if ev.Event != "failed" || ev.Error != "boom" || ev.ElapsedMS != 1500 || ev.Source != "a.txt" {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+}
+
+func TestBar(t *testing.T) {
+	var buf bytes.Buffer
+	b := NewBar(&buf)
+	for _, e := range uploadEvents() {
+		b.Report(e)
+	}
+	out := buf.String()
+	for _, want := range []string{"a.txt [" + strings.Repeat("=", barWidth) + "] 100%", "Indexing a.txt", "✓ Indexed a.txt as doc/1\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%q", want, out)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package progress
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/gemini"
+)
+
+// Lifecycle events written by a Stream.
+const (
+	EventQueued   = "queued"
+	EventStarted  = "started"
+	EventUploaded = "uploaded"
+	EventIndexing = "indexing"
+	EventIndexed  = "indexed"
+	EventFailed   = "failed"
+	EventPending  = "pending"
+	EventDone     = "done"
+	EventSummary  = "summary"
+)
+
+// StreamEvent is one line of a Stream. Source is the file as the user named
+// it; File, Store, Operation and Document are API resource names.
+type StreamEvent struct {
+	Time      time.Time `json:"time"`
+	Event     string    `json:"event"`
+	Source    string    `json:"source,omitempty"`
+	File      string    `json:"file,omitempty"`
+	Store     string    `json:"store,omitempty"`
+	Operation string    `json:"operation,omitempty"`
+	Document  string    `json:"document,omitempty"`
+	Bytes     int64     `json:"bytes,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Summary is the last line of a batch Stream.
+type Summary struct {
+	Time      time.Time `json:"time"`
+	Event     string    `json:"event"`
+	Total     int       `json:"total"`
+	Succeeded int       `json:"succeeded"`
+	Failed    int       `json:"failed"`
+}
+
+// Stream writes one JSON line per state change of each file in a batch, so
+// scripts can react to failures as they happen. Unlike JSONLines it collapses
+// byte counts and polls into the queued, started, uploaded, indexing, indexed
+// and failed transitions.
+type Stream struct {
+	out    *JSONLines
+	now    func() time.Time
+	mu     sync.Mutex
+	failed map[string]bool
+}
+
+// NewStream creates a Stream that writes to w.
+func NewStream(w io.Writer) *Stream {
+	return &Stream{out: NewJSONLines(w), now: time.Now, failed: make(map[string]bool)}
+}
+
+// Emit writes e, stamping it with the current time if it has none.
+func (s *Stream) Emit(e StreamEvent) {
+	if e.Time.IsZero() {
+		e.Time = s.now().UTC()
+	}
+	s.out.Write(e)
+}
+
+// Queued records that source is waiting for a worker.
+func (s *Stream) Queued(source string) {
+	s.Emit(StreamEvent{Event: EventQueued, Source: source})
+}
+
+// Reporter returns a gemini.ProgressReporter that writes the events of the
+// upload or import of source.
+func (s *Stream) Reporter(source string) gemini.ProgressReporter {
+	return gemini.ProgressFunc(func(e gemini.ProgressEvent) {
+		ev := StreamEvent{
+			Time:      e.Time.UTC(),
+			Source:    source,
+			File:      e.File,
+			Store:     e.Store,
+			Operation: e.Operation,
+		}
+		switch e.Type {
+		case gemini.ProgressUploadStarted:
+			ev.Event = EventStarted
+			ev.Bytes = e.TotalBytes
+		case gemini.ProgressOperationCreated:
+			if e.Kind == gemini.OperationTypeUpload {
+				// The bytes are sent once the indexing operation exists
+				uploaded := ev
+				uploaded.Event = EventUploaded
+				uploaded.Bytes = e.TotalBytes
+				s.Emit(uploaded)
+			}
+			ev.Event = EventIndexing
+		case gemini.ProgressDone:
+			ev.Event = EventIndexed
+			ev.Document = e.Document
+			if e.Store == "" {
+				ev.Event = EventUploaded
+				ev.Bytes = e.TotalBytes
+			}
+		case gemini.ProgressFailed:
+			ev.Event = EventFailed
+			if e.Err != nil {
+				ev.Error = e.Err.Error()
+			}
+			s.mu.Lock()
+			s.failed[source] = true
+			s.mu.Unlock()
+		default:
+			return
+		}
+		s.Emit(ev)
+	})
+}
+
+// Finished records the outcome of source. Failures that happened before the
+// upload or import started, such as an unknown file name, are written here.
+func (s *Stream) Finished(source string, err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	reported := s.failed[source]
+	s.failed[source] = true
+	s.mu.Unlock()
+	if !reported {
+		s.Emit(StreamEvent{Event: EventFailed, Source: source, Error: err.Error()})
+	}
+}
+
+// Summary writes the batch totals.
+func (s *Stream) Summary(total, succeeded, failed int) {
+	s.out.Write(Summary{Time: s.now().UTC(), Event: EventSummary, Total: total, Succeeded: succeeded, Failed: failed})
+}
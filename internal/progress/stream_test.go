@@ -0,0 +1,107 @@
+package progress
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mikesmitty/file-search/internal/gemini"
+)
+
+func TestStream(t *testing.T) {
+	store := gemini.ProgressEvent{Kind: gemini.OperationTypeUpload, Store: "stores/s", TotalBytes: 100}
+	at := func(typ gemini.ProgressEventType, e gemini.ProgressEvent) gemini.ProgressEvent {
+		e.Type = typ
+		return e
+	}
+
+	tests := []struct {
+		name     string
+		events   []gemini.ProgressEvent
+		finished error
+		want     []string
+	}{
+		{
+			name: "store upload",
+			events: []gemini.ProgressEvent{
+				at(gemini.ProgressUploadStarted, store),
+				at(gemini.ProgressBytesSent, store),
+				at(gemini.ProgressOperationCreated, gemini.ProgressEvent{Kind: gemini.OperationTypeUpload, Store: "stores/s", TotalBytes: 100, Operation: "op/1"}),
+				at(gemini.ProgressPolling, store),
+				at(gemini.ProgressDone, gemini.ProgressEvent{Store: "stores/s", Operation: "op/1", Document: "doc/1"}),
+			},
+			want: []string{"queued", "started", "uploaded", "indexing", "indexed"},
+		},
+		{
+			name: "files upload",
+			events: []gemini.ProgressEvent{
+				at(gemini.ProgressUploadStarted, gemini.ProgressEvent{Kind: gemini.OperationTypeUpload, TotalBytes: 10}),
+				at(gemini.ProgressDone, gemini.ProgressEvent{Kind: gemini.OperationTypeUpload, TotalBytes: 10, File: "files/f"}),
+			},
+			want: []string{"queued", "started", "uploaded"},
+		},
+		{
+			name: "import fails",
+			events: []gemini.ProgressEvent{
+				at(gemini.ProgressUploadStarted, gemini.ProgressEvent{Kind: gemini.OperationTypeImport, Store: "stores/s", File: "files/f"}),
+				at(gemini.ProgressOperationCreated, gemini.ProgressEvent{Kind: gemini.OperationTypeImport, Store: "stores/s", File: "files/f", Operation: "op/2"}),
+				at(gemini.ProgressFailed, gemini.ProgressEvent{Kind: gemini.OperationTypeImport, Store: "stores/s", File: "files/f", Operation: "op/2", Err: errors.New("bad file")}),
+			},
+			finished: errors.New("bad file"),
+			want:     []string{"queued", "started", "indexing", "failed"},
+		},
+		{
+			name:     "fails before starting",
+			finished: errors.New("file not found"),
+			want:     []string{"queued", "failed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			s := NewStream(&buf)
+			s.Queued("a.txt")
+			r := s.Reporter("a.txt")
+			for _, e := range tt.events {
+				r.Report(e)
+			}
+			s.Finished("a.txt", tt.finished)
+
+			var got []string
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				var ev StreamEvent
+				if err := json.Unmarshal([]byte(line), &ev); err != nil {
+					t.Fatalf("invalid JSON line %q: %v", line, err)
+				}
+				if ev.Source != "a.txt" || ev.Time.IsZero() {
+					t.Errorf("event missing source or time: %s", line)
+				}
+				if ev.Event == EventIndexing && ev.Operation == "" {
+					t.Errorf("indexing event has no operation: %s", line)
+				}
+				if ev.Event == EventFailed && ev.Error == "" {
+					t.Errorf("failed event has no error: %s", line)
+				}
+				got = append(got, ev.Event)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("events = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamSummary(t *testing.T) {
+	var buf bytes.Buffer
+	NewStream(&buf).Summary(3, 2, 1)
+	var got Summary
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Event != EventSummary || got.Total != 3 || got.Succeeded != 2 || got.Failed != 1 {
+		t.Errorf("summary = %+v", got)
+	}
+}
@@ -0,0 +1,361 @@
+// Package querycache stores query responses on disk so repeated questions
+// are answered without calling the API.
+package querycache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/constants"
+	"github.com/mikesmitty/file-search/internal/gemini"
+	"google.golang.org/genai"
+)
+
+// Key identifies the answers that can be shared between queries.
+type Key struct {
+	Model string `json:"model"`
+	// Stores are the resource names of the stores searched, sorted.
+	Stores         []string `json:"stores"`
+	MetadataFilter string   `json:"metadataFilter,omitempty"`
+	// Query is normalized: case-folded, with runs of whitespace collapsed.
+	Query string `json:"query"`
+	// Options are any generation settings that change the answer.
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// NewKey returns the normalized key for a query.
+func NewKey(model string, stores []string, metadataFilter, query string, options map[string]string) Key {
+	sorted := make([]string, 0, len(stores))
+	for _, s := range stores {
+		if s != "" {
+			sorted = append(sorted, s)
+		}
+	}
+	slices.Sort(sorted)
+	return Key{
+		Model:          strings.TrimPrefix(model, "models/"),
+		Stores:         slices.Compact(sorted),
+		MetadataFilter: strings.TrimSpace(metadataFilter),
+		Query:          strings.ToLower(strings.Join(strings.Fields(query), " ")),
+		Options:        options,
+	}
+}
+
+// id is the key's file name: a hash of its JSON encoding, in which map
+// keys are sorted.
+func (k Key) id() string {
+	data, _ := json.Marshal(k)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Entry is a cached response.
+type Entry struct {
+	Key      Key                            `json:"key"`
+	Created  time.Time                      `json:"created"`
+	Response *genai.GenerateContentResponse `json:"response"`
+}
+
+// Cache is a directory of cached responses, one JSON file per key. It is
+// safe for concurrent use within a process; concurrent processes may both
+// miss and write the same entry, which is harmless.
+type Cache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+	mu  sync.Mutex
+}
+
+// New returns a cache in dir whose entries expire after ttl. The directory
+// is created on the first write.
+func New(dir string, ttl time.Duration) *Cache {
+	return &Cache{dir: dir, ttl: ttl, now: time.Now}
+}
+
+// Dir returns the cache directory.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Get returns the unexpired entry for key. Expired entries are removed.
+func (c *Cache) Get(key Key) (*Entry, bool) {
+	path := filepath.Join(c.dir, key.id()+".json")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, err := readEntry(path)
+	if err != nil {
+		return nil, false
+	}
+	if c.now().Sub(e.Created) >= c.ttl {
+		os.Remove(path)
+		return nil, false
+	}
+	return e, true
+}
+
+// Put stores resp under key.
+func (c *Cache) Put(key Key, resp *genai.GenerateContentResponse) error {
+	data, err := json.Marshal(&Entry{Key: key, Created: c.now().UTC(), Response: resp})
+	if err != nil {
+		return fmt.Errorf("failed to encode cached response: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create query cache directory: %w", err)
+	}
+	// Write then rename, so readers never see a partial entry
+	tmp, err := os.CreateTemp(c.dir, ".entry-*")
+	if err != nil {
+		return fmt.Errorf("failed to write query cache: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write query cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write query cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(c.dir, key.id()+".json")); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write query cache: %w", err)
+	}
+	return nil
+}
+
+// Purge removes the entries that searched store, or every entry if store
+// is empty, and returns how many it removed.
+func (c *Cache) Purge(store string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names, err := os.ReadDir(c.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read query cache: %w", err)
+	}
+	removed := 0
+	for _, de := range names {
+		if de.IsDir() || !strings.HasSuffix(de.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(c.dir, de.Name())
+		if store != "" {
+			// Unreadable entries are removed along with the store's
+			if e, err := readEntry(path); err == nil && !slices.Contains(e.Key.Stores, store) {
+				continue
+			}
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, fmt.Errorf("failed to remove cached response: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func readEntry(path string) (*Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Status reports whether a query was answered from the cache.
+type Status struct {
+	Hit bool
+	// Created is when the cached response was stored.
+	Created time.Time
+}
+
+type statusKey struct{}
+
+// WithStatus returns a context whose query records its cache use in s.
+func WithStatus(ctx context.Context, s *Status) context.Context {
+	return context.WithValue(ctx, statusKey{}, s)
+}
+
+type bypassKey struct{}
+
+// Bypass returns a context whose query skips the cache lookup and asks the
+// API; the fresh response still replaces the cached one.
+func Bypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+// Options configures Middleware.
+type Options struct {
+	// OnError is called when the cache can't be written or invalidated. The
+	// call has already run, so its result is returned regardless.
+	OnError func(error)
+}
+
+// Middleware returns a gemini.Middleware that answers queries from c and
+// caches successful responses. Cached responses are returned without their
+// usage metadata. Uploads, imports and deletes invalidate the
+// entries for their store, even when they fail, since an operation may
+// still complete. An upload or import whose wait is canceled leaves its
+// operation running: until it finishes, queries of its store bypass the
+// cache, and when GetOperation sees it done the store's entries are
+// invalidated again.
+func (c *Cache) Middleware(opts *Options) gemini.Middleware {
+	if opts == nil {
+		opts = &Options{}
+	}
+	return func(next gemini.API) gemini.API {
+		return &cachedAPI{API: next, cache: c, opts: opts, pending: make(map[string]pendingOperation)}
+	}
+}
+
+type cachedAPI struct {
+	gemini.API
+	cache *Cache
+	opts  *Options
+
+	mu sync.Mutex
+	// pending are the running operations, by name, whose waits were canceled
+	pending map[string]pendingOperation
+}
+
+// pendingOperation is an upload or import still indexing into store.
+type pendingOperation struct {
+	store string
+	typ   gemini.OperationType
+}
+
+func (a *cachedAPI) report(err error) {
+	if err != nil && a.opts.OnError != nil {
+		a.opts.OnError(err)
+	}
+}
+
+func (a *cachedAPI) invalidate(store string) {
+	if store == "" {
+		return
+	}
+	_, err := a.cache.Purge(store)
+	a.report(err)
+}
+
+// track remembers the operation of an upload or import into store whose
+// wait was canceled, since the store changes when it finishes.
+func (a *cachedAPI) track(store string, typ gemini.OperationType, err error) {
+	var canceled *gemini.OperationCanceledError
+	if store == "" || !errors.As(err, &canceled) {
+		return
+	}
+	a.mu.Lock()
+	a.pending[canceled.Name] = pendingOperation{store: store, typ: typ}
+	a.mu.Unlock()
+}
+
+// indexing reports whether store has operations still running, checking
+// on each one first.
+func (a *cachedAPI) indexing(ctx context.Context, store string) bool {
+	a.mu.Lock()
+	ops := make(map[string]gemini.OperationType)
+	for name, op := range a.pending {
+		if op.store == store {
+			ops[name] = op.typ
+		}
+	}
+	a.mu.Unlock()
+
+	running := false
+	for name, typ := range ops {
+		status, err := a.GetOperation(ctx, name, typ)
+		if err != nil || !status.Done {
+			running = true
+		}
+	}
+	return running
+}
+
+func (a *cachedAPI) GetOperation(ctx context.Context, operationName string, operationType gemini.OperationType) (*gemini.OperationStatus, error) {
+	status, err := a.API.GetOperation(ctx, operationName, operationType)
+	if err == nil && status.Done {
+		a.mu.Lock()
+		op, ok := a.pending[operationName]
+		delete(a.pending, operationName)
+		a.mu.Unlock()
+		if ok {
+			a.invalidate(op.store)
+		}
+	}
+	return status, err
+}
+
+func (a *cachedAPI) Query(ctx context.Context, text string, storeName string, modelName string, metadataFilter string) (*genai.GenerateContentResponse, error) {
+	// Answers change as the operations finish, so neither use nor keep a cached one
+	if storeName != "" && a.indexing(ctx, storeName) {
+		return a.API.Query(ctx, text, storeName, modelName, metadataFilter)
+	}
+	key := NewKey(modelName, []string{storeName}, metadataFilter, text, nil)
+	if bypass, _ := ctx.Value(bypassKey{}).(bool); !bypass {
+		if e, ok := a.cache.Get(key); ok {
+			if s, ok := ctx.Value(statusKey{}).(*Status); ok {
+				s.Hit, s.Created = true, e.Created
+			}
+			// A cached answer uses no tokens, so it carries no usage for the
+			// middleware and callers that count them
+			resp := *e.Response
+			resp.UsageMetadata = nil
+			return &resp, nil
+		}
+	}
+	resp, err := a.API.Query(ctx, text, storeName, modelName, metadataFilter)
+	if err != nil {
+		return nil, err
+	}
+	a.report(a.cache.Put(key, resp))
+	return resp, nil
+}
+
+func (a *cachedAPI) UploadFile(ctx context.Context, path string, opts *gemini.UploadFileOptions) (*genai.File, error) {
+	file, err := a.API.UploadFile(ctx, path, opts)
+	if opts != nil {
+		a.invalidate(opts.StoreName)
+		a.track(opts.StoreName, gemini.OperationTypeUpload, err)
+	}
+	return file, err
+}
+
+func (a *cachedAPI) ImportFile(ctx context.Context, fileID, storeID string, opts *gemini.ImportFileOptions) error {
+	err := a.API.ImportFile(ctx, fileID, storeID, opts)
+	a.invalidate(storeID)
+	a.track(storeID, gemini.OperationTypeImport, err)
+	return err
+}
+
+func (a *cachedAPI) DeleteDocument(ctx context.Context, name string, force bool) error {
+	err := a.API.DeleteDocument(ctx, name, force)
+	if store, _, ok := strings.Cut(name, constants.DocumentResourcePrefix); ok {
+		a.invalidate(store)
+	}
+	return err
+}
+
+func (a *cachedAPI) DeleteStore(ctx context.Context, name string, force bool) error {
+	err := a.API.DeleteStore(ctx, name, force)
+	a.invalidate(name)
+	return err
+}
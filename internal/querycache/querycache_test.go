@@ -0,0 +1,277 @@
+package querycache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/gemini"
+	"google.golang.org/genai"
+)
+
+// stubAPI counts queries and answers each with its text; any call it does
+// not implement panics.
+type stubAPI struct {
+	gemini.API
+	queries int
+}
+
+func (s *stubAPI) Query(ctx context.Context, text, storeName, modelName, metadataFilter string) (*genai.GenerateContentResponse, error) {
+	s.queries++
+	if text == "fail" {
+		return nil, errors.New("quota exceeded")
+	}
+	return &genai.GenerateContentResponse{
+		ResponseID:    text,
+		Candidates:    []*genai.Candidate{{Content: genai.NewContentFromText(text, genai.RoleModel)}},
+		UsageMetadata: &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 10, TotalTokenCount: 20},
+	}, nil
+}
+
+func (s *stubAPI) UploadFile(ctx context.Context, path string, opts *gemini.UploadFileOptions) (*genai.File, error) {
+	return &genai.File{Name: "files/abc"}, nil
+}
+
+func (s *stubAPI) ImportFile(ctx context.Context, fileID, storeID string, opts *gemini.ImportFileOptions) error {
+	return errors.New("import failed")
+}
+
+func (s *stubAPI) DeleteDocument(ctx context.Context, name string, force bool) error {
+	return nil
+}
+
+func (s *stubAPI) DeleteStore(ctx context.Context, name string, force bool) error {
+	return nil
+}
+
+func TestNewKey(t *testing.T) {
+	a := NewKey("models/gemini-2.5-flash", []string{"fileSearchStores/b", "", "fileSearchStores/a", "fileSearchStores/b"}, " kind = \"doc\" ", "  What is\tthe   Answer? ", nil)
+	b := NewKey("gemini-2.5-flash", []string{"fileSearchStores/a", "fileSearchStores/b"}, "kind = \"doc\"", "what is the answer?", nil)
+	if a.id() != b.id() {
+		t.Errorf("normalized keys differ:\n%+v\n%+v", a, b)
+	}
+
+	for name, k := range map[string]Key{
+		"model":   NewKey("gemini-2.5-pro", b.Stores, b.MetadataFilter, b.Query, nil),
+		"stores":  NewKey(b.Model, []string{"fileSearchStores/a"}, b.MetadataFilter, b.Query, nil),
+		"filter":  NewKey(b.Model, b.Stores, "", b.Query, nil),
+		"query":   NewKey(b.Model, b.Stores, b.MetadataFilter, "what is the question?", nil),
+		"options": NewKey(b.Model, b.Stores, b.MetadataFilter, b.Query, map[string]string{"temperature": "0.2"}),
+	} {
+		if k.id() == b.id() {
+			t.Errorf("a different %s gives the same key", name)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	cache := New(t.TempDir(), time.Hour)
+	clock := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return clock }
+	stub := &stubAPI{}
+	api := gemini.Chain(stub, cache.Middleware(nil))
+
+	query := func(ctx context.Context, text, store string) (*genai.GenerateContentResponse, Status) {
+		t.Helper()
+		var st Status
+		resp, err := api.Query(WithStatus(ctx, &st), text, store, "gemini-2.5-flash", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, st
+	}
+
+	ctx := context.Background()
+	if resp, st := query(ctx, "What is X?", "fileSearchStores/a"); st.Hit || resp.UsageMetadata == nil {
+		t.Errorf("first query: hit=%v, usage=%v", st.Hit, resp.UsageMetadata)
+	}
+	resp, st := query(ctx, "what is  x?", "fileSearchStores/a")
+	if !st.Hit || !st.Created.Equal(clock) || stub.queries != 1 {
+		t.Errorf("repeat query: hit=%v created=%v, %d API queries", st.Hit, st.Created, stub.queries)
+	}
+	if resp.ResponseID != "What is X?" {
+		t.Errorf("cached response = %q, want the first answer", resp.ResponseID)
+	}
+	if resp.UsageMetadata != nil {
+		t.Errorf("cached response has usage %+v, want none", resp.UsageMetadata)
+	}
+	if _, st := query(ctx, "What is X?", "fileSearchStores/b"); st.Hit {
+		t.Error("a query of another store was a cache hit")
+	}
+
+	// Bypass asks the API and refreshes the entry
+	clock = clock.Add(30 * time.Minute)
+	if _, st := query(Bypass(ctx), "What is X?", "fileSearchStores/a"); st.Hit || stub.queries != 3 {
+		t.Errorf("bypassed query: hit=%v, %d API queries", st.Hit, stub.queries)
+	}
+	clock = clock.Add(45 * time.Minute)
+	if _, st := query(ctx, "What is X?", "fileSearchStores/a"); !st.Hit {
+		t.Error("entry refreshed by a bypassed query has expired")
+	}
+
+	// Entries expire after the TTL
+	clock = clock.Add(time.Hour)
+	if _, st := query(ctx, "What is X?", "fileSearchStores/a"); st.Hit || stub.queries != 4 {
+		t.Errorf("expired entry: hit=%v, %d API queries", st.Hit, stub.queries)
+	}
+
+	// Failures aren't cached
+	for range 2 {
+		if _, err := api.Query(ctx, "fail", "", "gemini-2.5-flash", ""); err == nil {
+			t.Fatal("expected the query to fail")
+		}
+	}
+	if stub.queries != 6 {
+		t.Errorf("%d API queries after two failures, want 6", stub.queries)
+	}
+}
+
+func TestMiddlewareInvalidation(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		call func(api gemini.API) error
+	}{
+		{"upload", func(api gemini.API) error {
+			_, err := api.UploadFile(context.Background(), "notes.md", &gemini.UploadFileOptions{StoreName: "fileSearchStores/a"})
+			return err
+		}},
+		{"failed import", func(api gemini.API) error {
+			api.ImportFile(context.Background(), "files/abc", "fileSearchStores/a", nil)
+			return nil
+		}},
+		{"delete document", func(api gemini.API) error {
+			return api.DeleteDocument(context.Background(), "fileSearchStores/a/documents/doc-1", false)
+		}},
+		{"delete store", func(api gemini.API) error {
+			return api.DeleteStore(context.Background(), "fileSearchStores/a", true)
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubAPI{}
+			api := gemini.Chain(stub, New(t.TempDir(), time.Hour).Middleware(nil))
+			for _, store := range []string{"fileSearchStores/a", "fileSearchStores/b", "fileSearchStores/a"} {
+				if _, err := api.Query(context.Background(), "q", store, "gemini-2.5-flash", ""); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := tt.call(api); err != nil {
+				t.Fatal(err)
+			}
+
+			var a, b Status
+			api.Query(WithStatus(context.Background(), &a), "q", "fileSearchStores/a", "gemini-2.5-flash", "")
+			api.Query(WithStatus(context.Background(), &b), "q", "fileSearchStores/b", "gemini-2.5-flash", "")
+			if a.Hit || !b.Hit {
+				t.Errorf("after %s: store a hit=%v (want false), store b hit=%v (want true)", tt.name, a.Hit, b.Hit)
+			}
+		})
+	}
+}
+
+// indexingAPI is a stubAPI whose uploads are canceled while indexing, and
+// whose answers include the document once its operation is done.
+type indexingAPI struct {
+	stubAPI
+	done bool
+}
+
+func (s *indexingAPI) Query(ctx context.Context, text, storeName, modelName, metadataFilter string) (*genai.GenerateContentResponse, error) {
+	if s.done {
+		text += " (with the new document)"
+	}
+	return s.stubAPI.Query(ctx, text, storeName, modelName, metadataFilter)
+}
+
+func (s *indexingAPI) UploadFile(ctx context.Context, path string, opts *gemini.UploadFileOptions) (*genai.File, error) {
+	return nil, &gemini.OperationCanceledError{Name: "fileSearchStores/a/upload/operations/op-1", Err: context.Canceled}
+}
+
+func (s *indexingAPI) GetOperation(ctx context.Context, operationName string, operationType gemini.OperationType) (*gemini.OperationStatus, error) {
+	return &gemini.OperationStatus{Name: operationName, Type: operationType, Done: s.done}, nil
+}
+
+func TestMiddlewareCanceledUpload(t *testing.T) {
+	cache := New(t.TempDir(), time.Hour)
+	stub := &indexingAPI{}
+	api := gemini.Chain(stub, cache.Middleware(nil))
+	ctx := context.Background()
+	query := func() string {
+		t.Helper()
+		resp, err := api.Query(ctx, "q", "fileSearchStores/a", "gemini-2.5-flash", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Text()
+	}
+
+	query()
+	if _, err := api.UploadFile(ctx, "doc.txt", &gemini.UploadFileOptions{StoreName: "fileSearchStores/a"}); err == nil {
+		t.Fatal("expected the canceled upload's error")
+	}
+
+	// Answers while the operation runs are not cached
+	before := stub.queries
+	query()
+	query()
+	if stub.queries != before+2 {
+		t.Errorf("queries while indexing reached the API %d times, want 2", stub.queries-before)
+	}
+
+	// Once it finishes the new answer is fetched, then cached
+	stub.done = true
+	if _, err := api.GetOperation(ctx, "fileSearchStores/a/upload/operations/op-1", gemini.OperationTypeUpload); err != nil {
+		t.Fatal(err)
+	}
+	before = stub.queries
+	if got := query(); got != "q (with the new document)" {
+		t.Errorf("answer after indexing = %q", got)
+	}
+	query()
+	if stub.queries != before+1 {
+		t.Errorf("queries after indexing reached the API %d times, want 1", stub.queries-before)
+	}
+}
+
+func TestMiddlewareCanceledUploadWithoutGetOperation(t *testing.T) {
+	cache := New(t.TempDir(), time.Hour)
+	stub := &indexingAPI{}
+	api := gemini.Chain(stub, cache.Middleware(nil))
+	ctx := context.Background()
+
+	api.UploadFile(ctx, "doc.txt", &gemini.UploadFileOptions{StoreName: "fileSearchStores/a"})
+	api.Query(ctx, "q", "fileSearchStores/a", "gemini-2.5-flash", "")
+	// Nobody asks about the operation, but the next query checks on it
+	stub.done = true
+	resp, err := api.Query(ctx, "q", "fileSearchStores/a", "gemini-2.5-flash", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Text(); got != "q (with the new document)" {
+		t.Errorf("answer after indexing = %q", got)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	cache := New(t.TempDir(), time.Hour)
+	if n, err := cache.Purge(""); err != nil || n != 0 {
+		t.Fatalf("Purge of an empty cache = %d, %v", n, err)
+	}
+	resp := &genai.GenerateContentResponse{}
+	for _, k := range []Key{
+		NewKey("m", []string{"fileSearchStores/a"}, "", "one", nil),
+		NewKey("m", []string{"fileSearchStores/a"}, "", "two", nil),
+		NewKey("m", []string{"fileSearchStores/b"}, "", "one", nil),
+		NewKey("m", nil, "", "one", nil),
+	} {
+		if err := cache.Put(k, resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, err := cache.Purge("fileSearchStores/a"); err != nil || n != 2 {
+		t.Errorf("Purge(store a) = %d, %v; want 2", n, err)
+	}
+	if n, err := cache.Purge(""); err != nil || n != 2 {
+		t.Errorf("Purge(all) = %d, %v; want 2", n, err)
+	}
+}
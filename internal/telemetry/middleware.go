@@ -0,0 +1,194 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/gemini"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genai"
+)
+
+// Attribute keys recorded on API call spans and metrics.
+const (
+	MethodKey    = attribute.Key("filesearch.method")
+	ResourceKey  = attribute.Key("filesearch.resource")
+	StatusKey    = attribute.Key("filesearch.status")
+	RequestsKey  = attribute.Key("filesearch.http.requests")
+	RetriesKey   = attribute.Key("filesearch.http.retries")
+	OperationKey = attribute.Key("filesearch.operation")
+	ModelKey     = attribute.Key("gen_ai.request.model")
+	TokenTypeKey = attribute.Key("gen_ai.token.type")
+)
+
+// Values of StatusKey.
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// instruments holds the metric instruments shared by the middleware and transport.
+type instruments struct {
+	tracer            trace.Tracer
+	callDuration      metric.Float64Histogram
+	uploadBytes       metric.Int64Counter
+	operationDuration metric.Float64Histogram
+	queryTokens       metric.Int64Counter
+}
+
+func newInstruments() *instruments {
+	meter := otel.Meter(InstrumentationName)
+	// Instrument creation only fails for invalid names, so errors are ignored
+	// and the no-op instruments returned alongside them are used.
+	callDuration, _ := meter.Float64Histogram("filesearch.client.duration",
+		metric.WithDescription("Duration of File Search API calls, including pagination and indexing waits"),
+		metric.WithUnit("s"))
+	uploadBytes, _ := meter.Int64Counter("filesearch.upload.bytes",
+		metric.WithDescription("Bytes sent by file uploads"),
+		metric.WithUnit("By"))
+	operationDuration, _ := meter.Float64Histogram("filesearch.operation.duration",
+		metric.WithDescription("Time from an indexing operation's creation until it finished"),
+		metric.WithUnit("s"))
+	queryTokens, _ := meter.Int64Counter("filesearch.query.tokens",
+		metric.WithDescription("Tokens used by queries"),
+		metric.WithUnit("{token}"))
+	return &instruments{
+		tracer:            otel.Tracer(InstrumentationName),
+		callDuration:      callDuration,
+		uploadBytes:       uploadBytes,
+		operationDuration: operationDuration,
+		queryTokens:       queryTokens,
+	}
+}
+
+// Middleware returns a gemini.Middleware that wraps every API call in a span
+// and records its duration, plus upload bytes, indexing operation durations
+// and query token usage. Use it with Transport to see the HTTP requests
+// (pages, upload chunks, polls and retries) each call makes.
+func Middleware() gemini.Middleware {
+	inst := newInstruments()
+	return gemini.Intercept(func(ctx context.Context, call *gemini.Call, next func(ctx context.Context) error) error {
+		attrs := []attribute.KeyValue{MethodKey.String(call.Method)}
+		if resource := callResource(call); resource != "" {
+			attrs = append(attrs, ResourceKey.String(resource))
+		}
+		ctx, span := inst.tracer.Start(ctx, "gemini."+call.Method, trace.WithAttributes(attrs...), trace.WithSpanKind(trace.SpanKindClient))
+		defer span.End()
+
+		stats := &callStats{}
+		ctx = context.WithValue(ctx, callStatsKey{}, stats)
+		var obs *operationObserver
+		if call.Method == "UploadFile" || call.Method == "ImportFile" {
+			obs = &operationObserver{span: span}
+			ctx = gemini.WithProgressObserver(ctx, obs)
+		}
+
+		start := time.Now()
+		err := next(ctx)
+		elapsed := time.Since(start).Seconds()
+
+		status := StatusOK
+		if err != nil {
+			status = StatusError
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		requests, retries := stats.counts()
+		span.SetAttributes(StatusKey.String(status), RequestsKey.Int(requests), RetriesKey.Int(retries))
+		inst.callDuration.Record(ctx, elapsed, metric.WithAttributes(MethodKey.String(call.Method), StatusKey.String(status)))
+
+		if obs != nil {
+			obs.record(ctx, inst, call.Method, status)
+		}
+		if resp, ok := call.Result.(*genai.GenerateContentResponse); ok && resp != nil && resp.UsageMetadata != nil {
+			model, _ := call.Args[2].(string)
+			recordTokens(ctx, inst, span, model, resp.UsageMetadata)
+		}
+		return err
+	})
+}
+
+// callResource returns the store, file, document or operation a call acts
+// on. Query text and local paths are left out.
+func callResource(call *gemini.Call) string {
+	arg := func(i int) string {
+		if i < len(call.Args) {
+			s, _ := call.Args[i].(string)
+			return s
+		}
+		return ""
+	}
+	switch call.Method {
+	case "Query", "ImportFile":
+		return arg(1)
+	case "UploadFile":
+		if len(call.Args) > 1 {
+			if opts, ok := call.Args[1].(*gemini.UploadFileOptions); ok && opts != nil {
+				return opts.StoreName
+			}
+		}
+		return ""
+	case "CreateStore":
+		return ""
+	}
+	return arg(0)
+}
+
+func recordTokens(ctx context.Context, inst *instruments, span trace.Span, model string, usage *genai.GenerateContentResponseUsageMetadata) {
+	span.SetAttributes(
+		ModelKey.String(model),
+		attribute.Int("gen_ai.usage.input_tokens", int(usage.PromptTokenCount)),
+		attribute.Int("gen_ai.usage.output_tokens", int(usage.CandidatesTokenCount)),
+	)
+	inst.queryTokens.Add(ctx, int64(usage.PromptTokenCount), metric.WithAttributes(ModelKey.String(model), TokenTypeKey.String("input")))
+	inst.queryTokens.Add(ctx, int64(usage.CandidatesTokenCount), metric.WithAttributes(ModelKey.String(model), TokenTypeKey.String("output")))
+}
+
+// operationObserver follows an upload or import's progress events to time
+// its indexing operation and count the bytes sent.
+type operationObserver struct {
+	span trace.Span
+
+	mu        sync.Mutex
+	operation string
+	created   time.Time
+	finished  time.Time
+	bytesSent int64
+}
+
+func (o *operationObserver) Report(e gemini.ProgressEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	switch e.Type {
+	case gemini.ProgressBytesSent:
+		o.bytesSent = e.BytesSent
+	case gemini.ProgressOperationCreated:
+		o.operation, o.created = e.Operation, e.Time
+		o.span.AddEvent("operation created", trace.WithAttributes(OperationKey.String(e.Operation)))
+	case gemini.ProgressDone, gemini.ProgressFailed:
+		o.finished = e.Time
+		if !o.created.IsZero() {
+			o.span.AddEvent("operation finished", trace.WithAttributes(OperationKey.String(o.operation)))
+		}
+	}
+}
+
+func (o *operationObserver) record(ctx context.Context, inst *instruments, method, status string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.bytesSent > 0 {
+		inst.uploadBytes.Add(ctx, o.bytesSent)
+	}
+	if o.operation != "" {
+		o.span.SetAttributes(OperationKey.String(o.operation))
+	}
+	if !o.created.IsZero() && !o.finished.IsZero() {
+		inst.operationDuration.Record(ctx, o.finished.Sub(o.created).Seconds(),
+			metric.WithAttributes(MethodKey.String(method), StatusKey.String(status)))
+	}
+}
@@ -0,0 +1,122 @@
+// Package telemetry exports OpenTelemetry traces and metrics for File Search
+// API calls and MCP tool calls.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+// InstrumentationName names the tracer and meter used for all instruments.
+const InstrumentationName = "github.com/mikesmitty/file-search"
+
+// Exporters accepted by Setup.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Options configures Setup.
+type Options struct {
+	// Exporter is ExporterNone (the default), ExporterStdout or ExporterOTLP.
+	Exporter string
+	// Endpoint is the OTLP/HTTP base URL, e.g. http://collector:4318. When
+	// empty, the standard OTEL_EXPORTER_OTLP_* environment variables apply.
+	Endpoint string
+	// Writer receives the stdout exporter's output (default: os.Stderr, which
+	// keeps stdout free for command output and the MCP stdio transport).
+	Writer io.Writer
+	// ServiceVersion is reported as the service.version resource attribute.
+	ServiceVersion string
+}
+
+// ParseExporter validates an exporter name. An empty name is ExporterNone.
+func ParseExporter(s string) (string, error) {
+	switch s = strings.ToLower(s); s {
+	case "", "off", ExporterNone:
+		return ExporterNone, nil
+	case ExporterStdout, ExporterOTLP:
+		return s, nil
+	}
+	return "", fmt.Errorf("invalid telemetry exporter: %s (must be 'none', 'stdout' or 'otlp')", s)
+}
+
+// Setup installs global tracer and meter providers that export through the
+// configured exporter. The returned function flushes and stops them, and
+// must be called before the process exits. With ExporterNone nothing is
+// installed, so the instrumentation stays no-op.
+func Setup(ctx context.Context, opts *Options) (func(context.Context) error, error) {
+	noop := func(context.Context) error { return nil }
+	if opts == nil {
+		opts = &Options{}
+	}
+	exporter, err := ParseExporter(opts.Exporter)
+	if err != nil || exporter == ExporterNone {
+		return noop, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", "file-search"),
+		attribute.String("service.version", opts.ServiceVersion),
+	))
+	if err != nil {
+		return noop, fmt.Errorf("failed to create telemetry resource: %w", err)
+	}
+
+	var spanExporter sdktrace.SpanExporter
+	var metricExporter sdkmetric.Exporter
+	switch exporter {
+	case ExporterStdout:
+		w := opts.Writer
+		if w == nil {
+			w = os.Stderr
+		}
+		if spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(w)); err != nil {
+			return noop, fmt.Errorf("failed to create trace exporter: %w", err)
+		}
+		if metricExporter, err = stdoutmetric.New(stdoutmetric.WithWriter(w)); err != nil {
+			return noop, fmt.Errorf("failed to create metric exporter: %w", err)
+		}
+	case ExporterOTLP:
+		var traceOpts []otlptracehttp.Option
+		var metricOpts []otlpmetrichttp.Option
+		if opts.Endpoint != "" {
+			base := strings.TrimRight(opts.Endpoint, "/")
+			traceOpts = append(traceOpts, otlptracehttp.WithEndpointURL(base+"/v1/traces"))
+			metricOpts = append(metricOpts, otlpmetrichttp.WithEndpointURL(base+"/v1/metrics"))
+		}
+		if spanExporter, err = otlptracehttp.New(ctx, traceOpts...); err != nil {
+			return noop, fmt.Errorf("failed to create trace exporter: %w", err)
+		}
+		if metricExporter, err = otlpmetrichttp.New(ctx, metricOpts...); err != nil {
+			return noop, fmt.Errorf("failed to create metric exporter: %w", err)
+		}
+	}
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(spanExporter), sdktrace.WithResource(res))
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)), sdkmetric.WithResource(res))
+	otel.SetTracerProvider(tp)
+	otel.SetMeterProvider(mp)
+	return func(ctx context.Context) error {
+		otel.SetTracerProvider(tracenoop.NewTracerProvider())
+		otel.SetMeterProvider(metricnoop.NewMeterProvider())
+		return errors.Join(tp.Shutdown(ctx), mp.Shutdown(ctx))
+	}, nil
+}
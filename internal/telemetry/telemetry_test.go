@@ -0,0 +1,282 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/gemini"
+	"github.com/mikesmitty/file-search/internal/gemini/fake"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/genai"
+)
+
+// recorder installs in-memory trace and metric providers for one test.
+type recorder struct {
+	spans  *tracetest.SpanRecorder
+	reader *sdkmetric.ManualReader
+}
+
+func newRecorder(t *testing.T) *recorder {
+	t.Helper()
+	r := &recorder{spans: tracetest.NewSpanRecorder(), reader: sdkmetric.NewManualReader()}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(r.spans))
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(r.reader))
+	prevTP, prevMP := otel.GetTracerProvider(), otel.GetMeterProvider()
+	otel.SetTracerProvider(tp)
+	otel.SetMeterProvider(mp)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevTP)
+		otel.SetMeterProvider(prevMP)
+	})
+	return r
+}
+
+// span returns the first ended span with the given name.
+func (r *recorder) span(t *testing.T, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+	for _, s := range r.spans.Ended() {
+		if s.Name() == name {
+			return s
+		}
+	}
+	t.Fatalf("no %q span recorded", name)
+	return nil
+}
+
+// metric returns the collected data for the named instrument.
+func (r *recorder) metric(t *testing.T, name string) metricdata.Aggregation {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := r.reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return m.Data
+			}
+		}
+	}
+	t.Fatalf("no %q metric recorded", name)
+	return nil
+}
+
+func attr(s sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range s.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestMiddlewareUpload(t *testing.T) {
+	rec := newRecorder(t)
+	srv := fake.NewServer(&fake.Options{IndexingDelay: 20 * time.Millisecond})
+	defer srv.Close()
+
+	ctx := context.Background()
+	client, err := gemini.NewClientWithConfig(ctx, &gemini.ClientConfig{
+		APIKey:       "test-key",
+		BaseURL:      srv.URL,
+		HTTPClient:   &http.Client{Transport: Transport(nil)},
+		PollInterval: 5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := gemini.Chain(client, Middleware())
+
+	store, err := api.CreateStore(ctx, "Telemetry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := []byte("some notes about tracing")
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var events int
+	progress := gemini.ProgressFunc(func(gemini.ProgressEvent) { events++ })
+	if _, err := api.UploadFile(ctx, path, &gemini.UploadFileOptions{StoreName: store.Name, Progress: progress}); err != nil {
+		t.Fatal(err)
+	}
+	if events == 0 {
+		t.Error("the caller's progress reporter was not called")
+	}
+
+	upload := rec.span(t, "gemini.UploadFile")
+	if got := attr(upload, ResourceKey).AsString(); got != store.Name {
+		t.Errorf("resource = %q, want %q", got, store.Name)
+	}
+	if got := attr(upload, StatusKey).AsString(); got != StatusOK {
+		t.Errorf("status = %q, want %q", got, StatusOK)
+	}
+	if attr(upload, OperationKey).AsString() == "" {
+		t.Error("upload span has no operation")
+	}
+	if got := attr(upload, RequestsKey).AsInt64(); got < 3 {
+		t.Errorf("requests = %d, want the upload, its chunks and at least one poll", got)
+	}
+	if got := attr(upload, RetriesKey).AsInt64(); got != 0 {
+		t.Errorf("retries = %d, want 0", got)
+	}
+
+	var children int
+	for _, s := range rec.spans.Ended() {
+		if s.Parent().SpanID() == upload.SpanContext().SpanID() {
+			children++
+			if got := attr(s, "url.path").AsString(); got == "" {
+				t.Errorf("HTTP span %q has no path", s.Name())
+			}
+		}
+	}
+	if children == 0 {
+		t.Error("no HTTP spans nested under the upload")
+	}
+
+	bytes, ok := rec.metric(t, "filesearch.upload.bytes").(metricdata.Sum[int64])
+	if !ok || len(bytes.DataPoints) != 1 || bytes.DataPoints[0].Value != int64(len(content)) {
+		t.Errorf("upload bytes = %+v, want %d", bytes, len(content))
+	}
+	duration, ok := rec.metric(t, "filesearch.operation.duration").(metricdata.Histogram[float64])
+	if !ok || len(duration.DataPoints) != 1 || duration.DataPoints[0].Count != 1 {
+		t.Errorf("operation duration = %+v, want one observation", duration)
+	}
+}
+
+// stubAPI implements the calls the tests make; any other call panics.
+type stubAPI struct {
+	gemini.API
+	url string
+}
+
+// Query sends the same request until it succeeds, as a retry after a
+// transient error would, and returns fixed token usage.
+func (s stubAPI) Query(ctx context.Context, text, storeName, modelName, metadataFilter string) (*genai.GenerateContentResponse, error) {
+	client := &http.Client{Transport: Transport(nil)}
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url+"/v1beta/models/"+modelName+":generateContent?key=secret", nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			break
+		}
+	}
+	return &genai.GenerateContentResponse{UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:     120,
+		CandidatesTokenCount: 30,
+	}}, nil
+}
+
+func (stubAPI) DeleteStore(ctx context.Context, name string, force bool) error {
+	return errors.New("store is not empty")
+}
+
+func TestMiddlewareQuery(t *testing.T) {
+	rec := newRecorder(t)
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls++; calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	api := gemini.Chain(stubAPI{url: srv.URL}, Middleware())
+
+	if _, err := api.Query(context.Background(), "what is tracing?", "fileSearchStores/kb-1", "gemini-2.5-flash", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	query := rec.span(t, "gemini.Query")
+	if got := attr(query, RequestsKey).AsInt64(); got != 2 {
+		t.Errorf("requests = %d, want 2", got)
+	}
+	if got := attr(query, RetriesKey).AsInt64(); got != 1 {
+		t.Errorf("retries = %d, want 1", got)
+	}
+	if got := attr(query, ModelKey).AsString(); got != "gemini-2.5-flash" {
+		t.Errorf("model = %q", got)
+	}
+	for _, kv := range query.Attributes() {
+		if kv.Value.AsString() == "what is tracing?" {
+			t.Errorf("query text recorded in %s", kv.Key)
+		}
+	}
+	for _, s := range rec.spans.Ended() {
+		for _, kv := range s.Attributes() {
+			if kv.Key == "url.path" && kv.Value.AsString() != "/v1beta/models/gemini-2.5-flash:generateContent" {
+				t.Errorf("url.path = %q, want the path without the query string", kv.Value.AsString())
+			}
+		}
+	}
+
+	tokens, ok := rec.metric(t, "filesearch.query.tokens").(metricdata.Sum[int64])
+	if !ok {
+		t.Fatal("query tokens is not a sum")
+	}
+	got := map[string]int64{}
+	for _, dp := range tokens.DataPoints {
+		typ, _ := dp.Attributes.Value(TokenTypeKey)
+		got[typ.AsString()] = dp.Value
+	}
+	if got["input"] != 120 || got["output"] != 30 {
+		t.Errorf("query tokens = %v, want input 120 and output 30", got)
+	}
+}
+
+func TestMiddlewareError(t *testing.T) {
+	rec := newRecorder(t)
+	api := gemini.Chain(stubAPI{}, Middleware())
+	if err := api.DeleteStore(context.Background(), "fileSearchStores/kb-1", false); err == nil {
+		t.Fatal("expected the delete to fail")
+	}
+	span := rec.span(t, "gemini.DeleteStore")
+	if span.Status().Code != codes.Error || span.Status().Description != "store is not empty" {
+		t.Errorf("status = %+v, want the error", span.Status())
+	}
+	if got := attr(span, StatusKey).AsString(); got != StatusError {
+		t.Errorf("status attribute = %q, want %q", got, StatusError)
+	}
+	if got := attr(span, ResourceKey).AsString(); got != "fileSearchStores/kb-1" {
+		t.Errorf("resource = %q", got)
+	}
+}
+
+func TestParseExporter(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "", want: ExporterNone},
+		{in: "off", want: ExporterNone},
+		{in: "STDOUT", want: ExporterStdout},
+		{in: "otlp", want: ExporterOTLP},
+		{in: "jaeger", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseExporter(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseExporter(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package telemetry
+
+import (
+	"net/http"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type callStatsKey struct{}
+
+// callStats counts the HTTP requests made by one API call.
+type callStats struct {
+	mu       sync.Mutex
+	requests int
+	retries  int
+	failed   map[string]bool
+}
+
+// requestKey identifies a request for retry detection: pages and upload
+// chunks differ in their page token or offset, while operation polls repeat
+// a request that succeeded.
+func requestKey(req *http.Request) string {
+	return req.Method + " " + req.URL.String() + " " + req.Header.Get("X-Goog-Upload-Offset")
+}
+
+// start counts a request, and a retry if the same request last failed.
+func (s *callStats) start(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	if s.failed[key] {
+		s.retries++
+	}
+}
+
+// finish records whether a request failed with a transport error, a 429 or
+// a 5xx response, the failures the SDK retries.
+func (s *callStats) finish(key string, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failed == nil {
+		s.failed = map[string]bool{}
+	}
+	s.failed[key] = failed
+}
+
+func (s *callStats) counts() (requests, retries int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests, s.retries
+}
+
+// Transport wraps base (http.DefaultTransport if nil) so each HTTP request
+// gets a span, nested under the API call's span when Middleware is in use.
+// Only the method, host and path are recorded: never the query string or
+// headers, which may carry credentials.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base, tracer: otel.Tracer(InstrumentationName)}
+}
+
+type transport struct {
+	base   http.RoundTripper
+	tracer trace.Tracer
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	stats, _ := req.Context().Value(callStatsKey{}).(*callStats)
+	key := requestKey(req)
+	if stats != nil {
+		stats.start(key)
+	}
+	ctx, span := t.tracer.Start(req.Context(), "HTTP "+req.Method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("http.request.method", req.Method),
+		attribute.String("server.address", req.URL.Host),
+		attribute.String("url.path", req.URL.Path),
+	))
+	defer span.End()
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if stats != nil {
+		stats.finish(key, err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,73 @@
+package usage
+
+import (
+	"sort"
+	"strings"
+)
+
+// Price is what a model charges, in US dollars per million tokens.
+type Price struct {
+	Input float64 `json:"input" mapstructure:"input"`
+	// CachedInput applies to prompt tokens served from the context cache.
+	CachedInput float64 `json:"cachedInput" mapstructure:"cached_input"`
+	// Output applies to response and thinking tokens.
+	Output float64 `json:"output" mapstructure:"output"`
+}
+
+// PriceTable maps model names to prices. A model without an exact entry
+// uses the longest entry that prefixes its name, so "gemini-2.5-flash"
+// also prices "gemini-2.5-flash-001".
+type PriceTable map[string]Price
+
+// DefaultPrices are the Gemini API's paid-tier list prices for prompts up
+// to 200k tokens, as published when this table was last updated. Override
+// them in the config file's usage_prices to match your contract.
+var DefaultPrices = PriceTable{
+	"gemini-3-pro-preview":  {Input: 2.00, CachedInput: 0.20, Output: 12.00},
+	"gemini-2.5-pro":        {Input: 1.25, CachedInput: 0.125, Output: 10.00},
+	"gemini-2.5-flash":      {Input: 0.30, CachedInput: 0.03, Output: 2.50},
+	"gemini-2.5-flash-lite": {Input: 0.10, CachedInput: 0.01, Output: 0.40},
+	"gemini-2.0-flash":      {Input: 0.10, CachedInput: 0.025, Output: 0.40},
+	"gemini-2.0-flash-lite": {Input: 0.075, Output: 0.30},
+}
+
+// Merge returns a table with the entries of t overridden by those of over.
+func (t PriceTable) Merge(over PriceTable) PriceTable {
+	merged := make(PriceTable, len(t)+len(over))
+	for model, p := range t {
+		merged[model] = p
+	}
+	for model, p := range over {
+		merged[strings.ToLower(model)] = p
+	}
+	return merged
+}
+
+// Lookup returns the price of model.
+func (t PriceTable) Lookup(model string) (Price, bool) {
+	model = strings.ToLower(strings.TrimPrefix(model, "models/"))
+	if p, ok := t[model]; ok {
+		return p, true
+	}
+	names := make([]string, 0, len(t))
+	for name := range t {
+		names = append(names, name)
+	}
+	// Longest first, so gemini-2.5-flash-lite-001 matches gemini-2.5-flash-lite
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	for _, name := range names {
+		if strings.HasPrefix(model, name) {
+			return t[name], true
+		}
+	}
+	return Price{}, false
+}
+
+// Cost estimates what r cost at p. Tool use tokens, the store chunks
+// retrieved for the answer, are billed as input; thinking tokens as output.
+func (p Price) Cost(r *Record) float64 {
+	cached := min(r.CachedTokens, r.PromptTokens)
+	input := float64(r.PromptTokens-cached+r.ToolUseTokens) * p.Input
+	output := float64(r.ResponseTokens+r.ThinkingTokens) * p.Output
+	return (input + float64(cached)*p.CachedInput + output) / 1e6
+}
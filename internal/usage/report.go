@@ -0,0 +1,128 @@
+package usage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Keys a report can be grouped by.
+const (
+	ByDay   = "day"
+	ByMonth = "month"
+	ByModel = "model"
+	ByStore = "store"
+)
+
+// ParseGroupBy validates a comma-separated list of report keys.
+func ParseGroupBy(s string) ([]string, error) {
+	var keys []string
+	for _, key := range strings.Split(s, ",") {
+		switch key = strings.ToLower(strings.TrimSpace(key)); key {
+		case "":
+		case ByDay, ByMonth, ByModel, ByStore:
+			keys = append(keys, key)
+		default:
+			return nil, fmt.Errorf("invalid report grouping: %s (must be '%s', '%s', '%s' or '%s')", key, ByDay, ByMonth, ByModel, ByStore)
+		}
+	}
+	return keys, nil
+}
+
+// Row sums the usage of the records in one group. Only the fields the
+// report is grouped by are set.
+type Row struct {
+	Day            string `json:"day,omitempty"`
+	Month          string `json:"month,omitempty"`
+	Model          string `json:"model,omitempty"`
+	Store          string `json:"store,omitempty"`
+	Queries        int    `json:"queries"`
+	PromptTokens   int64  `json:"promptTokens"`
+	CachedTokens   int64  `json:"cachedTokens"`
+	ToolUseTokens  int64  `json:"toolUseTokens"`
+	ResponseTokens int64  `json:"responseTokens"`
+	ThinkingTokens int64  `json:"thinkingTokens"`
+	TotalTokens    int64  `json:"totalTokens"`
+	// EstimatedCost is in US dollars and leaves out unpriced queries.
+	EstimatedCost float64 `json:"estimatedCost"`
+	// UnpricedQueries counts queries whose model has no price.
+	UnpricedQueries int `json:"unpricedQueries,omitempty"`
+}
+
+func (row *Row) add(r *Record, prices PriceTable) {
+	row.Queries++
+	row.PromptTokens += r.PromptTokens
+	row.CachedTokens += r.CachedTokens
+	row.ToolUseTokens += r.ToolUseTokens
+	row.ResponseTokens += r.ResponseTokens
+	row.ThinkingTokens += r.ThinkingTokens
+	row.TotalTokens += r.TotalTokens
+	if p, ok := prices.Lookup(r.Model); ok {
+		row.EstimatedCost += p.Cost(r)
+	} else {
+		row.UnpricedQueries++
+	}
+}
+
+// Report is the usage summary printed by "usage report".
+type Report struct {
+	Since   time.Time `json:"since,omitzero"`
+	Until   time.Time `json:"until,omitzero"`
+	GroupBy []string  `json:"groupBy,omitempty"`
+	Rows    []Row     `json:"rows"`
+	Total   Row       `json:"total"`
+	// UnpricedModels are the models with no entry in the price table.
+	UnpricedModels []string `json:"unpricedModels,omitempty"`
+}
+
+// Summarize groups records by the given keys, in that order, and totals
+// them. Days and months are in loc (default: UTC). Rows are sorted by
+// their keys.
+func Summarize(records []Record, groupBy []string, prices PriceTable, loc *time.Location) *Report {
+	if loc == nil {
+		loc = time.UTC
+	}
+	report := &Report{GroupBy: groupBy, Rows: []Row{}}
+	rows := map[string]*Row{}
+	var order []string
+	unpriced := map[string]bool{}
+	for i := range records {
+		r := &records[i]
+		var key Row
+		for _, by := range groupBy {
+			switch by {
+			case ByDay:
+				key.Day = r.Time.In(loc).Format(time.DateOnly)
+			case ByMonth:
+				key.Month = r.Time.In(loc).Format("2006-01")
+			case ByModel:
+				key.Model = r.Model
+			case ByStore:
+				key.Store = r.Store
+			}
+		}
+		id := strings.Join([]string{key.Day, key.Month, key.Model, key.Store}, "\x00")
+		row, ok := rows[id]
+		if !ok {
+			row = &key
+			rows[id] = row
+			order = append(order, id)
+		}
+		row.add(r, prices)
+		report.Total.add(r, prices)
+		if _, ok := prices.Lookup(r.Model); !ok {
+			unpriced[r.Model] = true
+		}
+	}
+
+	sort.Strings(order)
+	for _, id := range order {
+		report.Rows = append(report.Rows, *rows[id])
+	}
+	for model := range unpriced {
+		report.UnpricedModels = append(report.UnpricedModels, model)
+	}
+	sort.Strings(report.UnpricedModels)
+	return report
+}
@@ -0,0 +1,80 @@
+package usage
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPriceTableLookup(t *testing.T) {
+	prices := DefaultPrices.Merge(PriceTable{"Custom-Model": {Input: 1}})
+	tests := []struct {
+		model string
+		want  Price
+		ok    bool
+	}{
+		{"gemini-2.5-flash", DefaultPrices["gemini-2.5-flash"], true},
+		{"models/gemini-2.5-flash-001", DefaultPrices["gemini-2.5-flash"], true},
+		{"gemini-2.5-flash-lite-preview-09-2025", DefaultPrices["gemini-2.5-flash-lite"], true},
+		{"custom-model", Price{Input: 1}, true},
+		{"gemini-1.0-pro", Price{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := prices.Lookup(tt.model)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Lookup(%q) = %+v, %v; want %+v, %v", tt.model, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestPriceCost(t *testing.T) {
+	p := Price{Input: 1, CachedInput: 0.25, Output: 4}
+	r := &Record{PromptTokens: 1_000_000, CachedTokens: 400_000, ToolUseTokens: 1_000_000, ResponseTokens: 500_000, ThinkingTokens: 250_000}
+	// 1.6M uncached input, 0.4M cached, 0.75M output
+	want := 1.6*1 + 0.4*0.25 + 0.75*4
+	if got := p.Cost(r); math.Abs(got-want) > 1e-9 {
+		t.Errorf("Cost = %v, want %v", got, want)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	prices := PriceTable{"gemini-2.5-flash": {Input: 1, Output: 2}}
+	day := func(d int) time.Time { return time.Date(2026, 9, d, 12, 0, 0, 0, time.UTC) }
+	records := []Record{
+		{Time: day(2), Model: "gemini-2.5-flash", Store: "fileSearchStores/kb", PromptTokens: 1_000_000, TotalTokens: 1_000_000},
+		{Time: day(1), Model: "gemini-2.5-flash", Store: "fileSearchStores/kb", ResponseTokens: 1_000_000, TotalTokens: 1_000_000},
+		{Time: day(1), Model: "gemini-x", Store: "fileSearchStores/kb", PromptTokens: 10, TotalTokens: 10},
+		{Time: day(2), Model: "gemini-2.5-flash", Store: "fileSearchStores/hr", PromptTokens: 500_000, TotalTokens: 500_000},
+	}
+
+	report := Summarize(records, []string{ByModel, ByStore}, prices, nil)
+	want := []Row{
+		{Model: "gemini-2.5-flash", Store: "fileSearchStores/hr", Queries: 1, PromptTokens: 500_000, TotalTokens: 500_000, EstimatedCost: 0.5},
+		{Model: "gemini-2.5-flash", Store: "fileSearchStores/kb", Queries: 2, PromptTokens: 1_000_000, ResponseTokens: 1_000_000, TotalTokens: 2_000_000, EstimatedCost: 3},
+		{Model: "gemini-x", Store: "fileSearchStores/kb", Queries: 1, PromptTokens: 10, TotalTokens: 10, UnpricedQueries: 1},
+	}
+	if !reflect.DeepEqual(report.Rows, want) {
+		t.Errorf("rows =\n%+v\nwant\n%+v", report.Rows, want)
+	}
+	if report.Total.Queries != 4 || report.Total.EstimatedCost != 3.5 || report.Total.UnpricedQueries != 1 {
+		t.Errorf("total = %+v", report.Total)
+	}
+	if !reflect.DeepEqual(report.UnpricedModels, []string{"gemini-x"}) {
+		t.Errorf("unpriced models = %v", report.UnpricedModels)
+	}
+
+	byDay := Summarize(records, []string{ByDay}, prices, time.UTC)
+	if len(byDay.Rows) != 2 || byDay.Rows[0].Day != "2026-09-01" || byDay.Rows[0].Queries != 2 || byDay.Rows[1].Queries != 2 {
+		t.Errorf("rows by day = %+v", byDay.Rows)
+	}
+}
+
+func TestParseGroupBy(t *testing.T) {
+	if got, err := ParseGroupBy(" Day, model ,"); err != nil || !reflect.DeepEqual(got, []string{ByDay, ByModel}) {
+		t.Errorf("ParseGroupBy = %v, %v", got, err)
+	}
+	if _, err := ParseGroupBy("model,week"); err == nil {
+		t.Error("expected an error for an unknown grouping")
+	}
+}
@@ -0,0 +1,137 @@
+// Package usage keeps a local ledger of the tokens each query uses and
+// summarizes it into reports with cost estimates.
+package usage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/gemini"
+	"github.com/mikesmitty/file-search/internal/jsonl"
+	"google.golang.org/genai"
+)
+
+// Record is one line of the ledger: the token usage of one query.
+type Record struct {
+	Time  time.Time `json:"time"`
+	Model string    `json:"model"`
+	// Store is the resource name of the store queried.
+	Store string `json:"store,omitempty"`
+	// PromptTokens includes CachedTokens.
+	PromptTokens   int64 `json:"promptTokens"`
+	CachedTokens   int64 `json:"cachedTokens,omitempty"`
+	ToolUseTokens  int64 `json:"toolUseTokens,omitempty"`
+	ResponseTokens int64 `json:"responseTokens"`
+	ThinkingTokens int64 `json:"thinkingTokens,omitempty"`
+	TotalTokens    int64 `json:"totalTokens"`
+}
+
+// FromResponse returns the usage of a query response, or nil if the
+// response carries no usage metadata. model is the model that was asked
+// for; the response's model version is preferred when present.
+func FromResponse(resp *genai.GenerateContentResponse, model, store string) *Record {
+	if resp == nil || resp.UsageMetadata == nil {
+		return nil
+	}
+	if resp.ModelVersion != "" {
+		model = resp.ModelVersion
+	}
+	u := resp.UsageMetadata
+	return &Record{
+		Model:          strings.TrimPrefix(model, "models/"),
+		Store:          store,
+		PromptTokens:   int64(u.PromptTokenCount),
+		CachedTokens:   int64(u.CachedContentTokenCount),
+		ToolUseTokens:  int64(u.ToolUsePromptTokenCount),
+		ResponseTokens: int64(u.CandidatesTokenCount),
+		ThinkingTokens: int64(u.ThoughtsTokenCount),
+		TotalTokens:    int64(u.TotalTokenCount),
+	}
+}
+
+// Ledger appends records to a JSON Lines file. It is safe for concurrent use.
+type Ledger struct {
+	path string
+	mu   sync.Mutex
+}
+
+// New returns a Ledger that writes to path. The file and its directory are
+// created on the first write.
+func New(path string) *Ledger {
+	return &Ledger{path: path}
+}
+
+// Path returns the ledger file's path.
+func (l *Ledger) Path() string {
+	return l.path
+}
+
+// Append writes r as a single line.
+func (l *Ledger) Append(r *Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := jsonl.Append(l.path, r); err != nil {
+		return fmt.Errorf("failed to write usage ledger: %w", err)
+	}
+	return nil
+}
+
+// Read returns the records in the ledger at path from since up to, but not
+// including, until, oldest first. A zero until means no upper bound, and a
+// missing ledger has no records. An unparseable last line, left by an
+// interrupted write, is ignored.
+func Read(path string, since, until time.Time) ([]Record, error) {
+	var records []Record
+	err := jsonl.Read(path, func(r Record) {
+		if r.Time.Before(since) || (!until.IsZero() && !r.Time.Before(until)) {
+			return
+		}
+		records = append(records, r)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage ledger: %w", err)
+	}
+	return records, nil
+}
+
+// Options configures Middleware.
+type Options struct {
+	// OnError is called when a record can't be written. The query has
+	// already run, so its result is returned regardless.
+	OnError func(error)
+	// Now returns the current time (default: time.Now).
+	Now func() time.Time
+}
+
+// Middleware returns a gemini.Middleware that records the token usage of
+// every successful query to l.
+func (l *Ledger) Middleware(opts *Options) gemini.Middleware {
+	if opts == nil {
+		opts = &Options{}
+	}
+	now := opts.Now
+	if now == nil {
+		now = time.Now
+	}
+	return gemini.Intercept(func(ctx context.Context, call *gemini.Call, next func(ctx context.Context) error) error {
+		err := next(ctx)
+		if err != nil || call.Method != "Query" {
+			return err
+		}
+		resp, _ := call.Result.(*genai.GenerateContentResponse)
+		store, _ := call.Args[1].(string)
+		model, _ := call.Args[2].(string)
+		r := FromResponse(resp, model, store)
+		if r == nil {
+			return nil
+		}
+		r.Time = now().UTC()
+		if werr := l.Append(r); werr != nil && opts.OnError != nil {
+			opts.OnError(werr)
+		}
+		return nil
+	})
+}
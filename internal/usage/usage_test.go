@@ -0,0 +1,124 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/gemini"
+	"google.golang.org/genai"
+)
+
+// stubAPI implements the calls the tests make; any other call panics.
+type stubAPI struct {
+	gemini.API
+}
+
+func (stubAPI) Query(ctx context.Context, text, storeName, modelName, metadataFilter string) (*genai.GenerateContentResponse, error) {
+	if text == "fail" {
+		return nil, errors.New("quota exceeded")
+	}
+	return &genai.GenerateContentResponse{
+		ModelVersion: "gemini-2.5-flash-001",
+		UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+			PromptTokenCount:        120,
+			CachedContentTokenCount: 100,
+			ToolUsePromptTokenCount: 400,
+			CandidatesTokenCount:    30,
+			ThoughtsTokenCount:      12,
+			TotalTokenCount:         562,
+		},
+	}, nil
+}
+
+func (stubAPI) ListStores(ctx context.Context) ([]*genai.FileSearchStore, error) {
+	return nil, nil
+}
+
+func TestMiddleware(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "usage.jsonl")
+	clock := time.Date(2026, 9, 30, 23, 30, 0, 0, time.UTC)
+	api := gemini.Chain(stubAPI{}, New(path).Middleware(&Options{Now: func() time.Time { return clock }}))
+
+	ctx := context.Background()
+	if _, err := api.Query(ctx, "how many vacation days?", "fileSearchStores/kb-1", "gemini-2.5-flash", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.Query(ctx, "fail", "fileSearchStores/kb-1", "gemini-2.5-flash", ""); err == nil {
+		t.Fatal("expected the query to fail")
+	}
+	if _, err := api.ListStores(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := Read(path, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Record{{
+		Time:           clock,
+		Model:          "gemini-2.5-flash-001",
+		Store:          "fileSearchStores/kb-1",
+		PromptTokens:   120,
+		CachedTokens:   100,
+		ToolUseTokens:  400,
+		ResponseTokens: 30,
+		ThinkingTokens: 12,
+		TotalTokens:    562,
+	}}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("records =\n%+v\nwant\n%+v", records, want)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("ledger mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.jsonl")
+	if records, err := Read(path, time.Time{}, time.Time{}); err != nil || records != nil {
+		t.Fatalf("Read of a missing ledger = %v, %v", records, err)
+	}
+
+	ledger := New(path)
+	base := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 4 {
+		if err := ledger.Append(&Record{Time: base.Add(time.Duration(i) * time.Hour), Model: "gemini-2.5-flash"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	records, err := Read(path, base.Add(time.Hour), base.Add(3*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || !records[0].Time.Equal(base.Add(time.Hour)) || !records[1].Time.Equal(base.Add(2*time.Hour)) {
+		t.Errorf("Read from 01:00 until 03:00 = %+v", records)
+	}
+
+	// A write cut short leaves a partial last line, which is skipped
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"time":"2026-09-01T04:00:00Z","mod`)
+	f.Close()
+	if records, err := Read(path, time.Time{}, time.Time{}); err != nil || len(records) != 4 {
+		t.Errorf("Read with a partial last line = %+v, %v", records, err)
+	}
+
+	if err := os.WriteFile(path, []byte("{not json}\n{}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(path, time.Time{}, time.Time{}); err == nil {
+		t.Error("expected an error for a corrupt record")
+	}
+}
@@ -23,7 +23,7 @@ func main() {
 
 	ctx := context.Background()
 	if err := cmd.Execute(ctx); err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(cmd.ErrorOutput(), err)
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,140 @@
+package filesearch
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/mikesmitty/file-search/internal/gemini"
+	"google.golang.org/genai"
+)
+
+var (
+	// ErrNotFound is returned when a store, file, document or operation does not exist.
+	ErrNotFound = gemini.ErrNotFound
+	// ErrUnsupported is returned when the backend cannot perform an operation,
+	// e.g. uploads on Vertex AI.
+	ErrUnsupported = gemini.ErrUnsupported
+	// ErrInvalidArgument is returned for malformed requests, e.g. an invalid metadata filter.
+	ErrInvalidArgument = errors.New("invalid argument")
+	// ErrFailedPrecondition is returned when a request conflicts with the resource's
+	// state, e.g. deleting a non-empty store without force.
+	ErrFailedPrecondition = errors.New("failed precondition")
+	// ErrPermissionDenied is returned when the credentials are missing, invalid or
+	// lack access to the resource.
+	ErrPermissionDenied = errors.New("permission denied")
+)
+
+// APIError is an error response from the File Search API. It matches the
+// sentinel error for its status with errors.Is.
+type APIError struct {
+	// Code is the HTTP status code.
+	Code int
+	// Status is the API status, e.g. "NOT_FOUND".
+	Status  string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Status != "" {
+		return fmt.Sprintf("%s (%d %s)", e.Message, e.Code, e.Status)
+	}
+	return fmt.Sprintf("%s (%d)", e.Message, e.Code)
+}
+
+// Is reports whether target is the sentinel error for e's status.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.Code == http.StatusNotFound
+	case ErrFailedPrecondition:
+		return e.Status == "FAILED_PRECONDITION"
+	case ErrInvalidArgument:
+		return e.Code == http.StatusBadRequest && e.Status != "FAILED_PRECONDITION"
+	case ErrPermissionDenied:
+		return e.Code == http.StatusUnauthorized || e.Code == http.StatusForbidden
+	}
+	return false
+}
+
+// OperationError is returned when an upload or import finishes but the document
+// fails to index.
+type OperationError struct {
+	// Name is the operation's resource name.
+	Name    string
+	Message string
+}
+
+func (e *OperationError) Error() string {
+	return fmt.Sprintf("operation %s failed: %s", e.Name, e.Message)
+}
+
+// OperationCanceledError is returned when the context is canceled while an
+// upload or import is indexing. The operation keeps running on the server;
+// Name identifies it. It wraps the context's error.
+type OperationCanceledError struct {
+	Name string
+	Err  error
+}
+
+func (e *OperationCanceledError) Error() string {
+	return fmt.Sprintf("stopped waiting for operation %s: %v (the operation is still running)", e.Name, e.Err)
+}
+
+func (e *OperationCanceledError) Unwrap() error { return e.Err }
+
+// BatchError is returned by UploadBatch when one or more files fail.
+type BatchError struct {
+	// Failed maps each failed path to its error.
+	Failed map[string]error
+}
+
+func (e *BatchError) Error() string {
+	paths := make([]string, 0, len(e.Failed))
+	for path := range e.Failed {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	msgs := make([]string, len(paths))
+	for i, path := range paths {
+		msgs[i] = fmt.Sprintf("%s: %v", path, e.Failed[path])
+	}
+	return fmt.Sprintf("%d file(s) failed: %s", len(paths), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the individual file errors, so errors.Is and errors.As match
+// if any file failed with the target error.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, err := range e.Failed {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// wrapError converts SDK errors into *APIError and the internal client's
+// operation errors into this package's. Other errors are returned unchanged.
+func wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var opErr *gemini.OperationError
+	if errors.As(err, &opErr) {
+		return &OperationError{Name: opErr.Name, Message: opErr.Message}
+	}
+	var canceledErr *gemini.OperationCanceledError
+	if errors.As(err, &canceledErr) {
+		return &OperationCanceledError{Name: canceledErr.Name, Err: canceledErr.Err}
+	}
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		return &APIError{Code: apiErr.Code, Status: apiErr.Status, Message: apiErr.Message}
+	}
+	var apiErrPtr *genai.APIError
+	if errors.As(err, &apiErrPtr) && apiErrPtr != nil {
+		return &APIError{Code: apiErrPtr.Code, Status: apiErrPtr.Status, Message: apiErrPtr.Message}
+	}
+	return err
+}
@@ -0,0 +1,129 @@
+package filesearch_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/gemini/fake"
+	"github.com/mikesmitty/file-search/pkg/filesearch"
+)
+
+// The examples run against an in-memory fake of the File Search API.
+var (
+	apiKey  = "example-key"
+	baseURL string
+)
+
+func TestMain(m *testing.M) {
+	srv := fake.NewServer(&fake.Options{APIKey: apiKey})
+	baseURL = srv.URL
+	code := m.Run()
+	srv.Close()
+	os.Exit(code)
+}
+
+func Example() {
+	ctx := context.Background()
+	client, err := filesearch.New(ctx,
+		filesearch.WithAPIKey(apiKey),
+		filesearch.WithBaseURL(baseURL),
+		filesearch.WithPollInterval(50*time.Millisecond),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	if _, err := client.CreateStore(ctx, "Handbook"); err != nil {
+		log.Fatal(err)
+	}
+	// Uploads to a store return once the document is indexed
+	if _, err := client.Upload(ctx, "testdata/handbook.txt", filesearch.ToStore("Handbook")); err != nil {
+		log.Fatal(err)
+	}
+
+	answer, err := client.Query(ctx, "How many vacation days roll over?", filesearch.InStore("Handbook"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, c := range answer.Citations {
+		fmt.Printf("[%d] %s\n", c.Index, c.Title)
+	}
+	// Output:
+	// [1] handbook.txt
+}
+
+func ExampleClient_UploadBatch() {
+	ctx := context.Background()
+	client, err := filesearch.New(ctx,
+		filesearch.WithAPIKey(apiKey),
+		filesearch.WithBaseURL(baseURL),
+		filesearch.WithPollInterval(50*time.Millisecond),
+		filesearch.WithConcurrency(1),
+		filesearch.WithProgress(func(p filesearch.Progress) {
+			if p.Stage == filesearch.StageDone || p.Stage == filesearch.StageFailed {
+				fmt.Printf("[%d/%d] %s %s\n", p.Current, p.Total, p.Stage, filepath.Base(p.Path))
+			}
+		}),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	if _, err := client.CreateStore(ctx, "Policies"); err != nil {
+		log.Fatal(err)
+	}
+	paths := []string{"testdata/handbook.txt", "testdata/security.txt"}
+	result, err := client.UploadBatch(ctx, paths,
+		filesearch.ToStore("Policies"),
+		filesearch.WithMetadata(map[string]string{"team": "people-ops"}),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("uploaded %d of %d\n", len(result.Succeeded), result.Total)
+	// Output:
+	// [1/2] done handbook.txt
+	// [2/2] done security.txt
+	// uploaded 2 of 2
+}
+
+func ExampleClient_DeleteStore() {
+	ctx := context.Background()
+	client, err := filesearch.New(ctx,
+		filesearch.WithAPIKey(apiKey),
+		filesearch.WithBaseURL(baseURL),
+		filesearch.WithPollInterval(50*time.Millisecond),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	if _, err := client.CreateStore(ctx, "Scratch"); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := client.Upload(ctx, "testdata/security.txt", filesearch.ToStore("Scratch")); err != nil {
+		log.Fatal(err)
+	}
+
+	err = client.DeleteStore(ctx, "Scratch", false)
+	fmt.Println("non-empty:", errors.Is(err, filesearch.ErrFailedPrecondition))
+
+	err = client.DeleteStore(ctx, "Scratch", true)
+	fmt.Println("forced:", err == nil)
+
+	err = client.DeleteStore(ctx, "Scratch", true)
+	fmt.Println("gone:", errors.Is(err, filesearch.ErrNotFound))
+	// Output:
+	// non-empty: true
+	// forced: true
+	// gone: true
+}
@@ -0,0 +1,476 @@
+// Package filesearch is a Go client for the Gemini File Search API.
+//
+// It wraps the client used by the file-search CLI: stores, files and documents
+// can be addressed by display name or resource name, uploads wait for indexing
+// to finish, batches of files are uploaded concurrently, and grounded answers
+// come back with their citations extracted.
+//
+//	client, err := filesearch.New(ctx, filesearch.WithAPIKey(key))
+//	if err != nil {
+//		return err
+//	}
+//	defer client.Close()
+//
+//	if _, err := client.Upload(ctx, "handbook.pdf", filesearch.ToStore("Docs")); err != nil {
+//		return err
+//	}
+//	answer, err := client.Query(ctx, "How many vacation days do I get?", filesearch.InStore("Docs"))
+//
+// Errors can be inspected with errors.Is against ErrNotFound, ErrInvalidArgument,
+// ErrFailedPrecondition, ErrPermissionDenied and ErrUnsupported, or with errors.As
+// for *APIError, *OperationError and *BatchError.
+//
+// This package follows semantic versioning with the module: exported identifiers
+// are not removed or changed incompatibly within a major version. Packages under
+// internal/ carry no such guarantee.
+package filesearch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/batch"
+	"github.com/mikesmitty/file-search/internal/gemini"
+	"github.com/mikesmitty/file-search/internal/grounding"
+	"google.golang.org/genai"
+)
+
+// Backend identifies the Google API that serves the client's requests.
+type Backend string
+
+const (
+	// BackendGemini is the Gemini Developer API.
+	BackendGemini Backend = "gemini"
+	// BackendVertex is Vertex AI. Stores are RAG corpora and only querying is supported.
+	BackendVertex Backend = "vertex"
+)
+
+// OperationType is the kind of long-running operation.
+type OperationType string
+
+const (
+	OperationTypeImport OperationType = "import"
+	OperationTypeUpload OperationType = "upload"
+)
+
+// OperationStatus is the status of an upload or import operation.
+type OperationStatus struct {
+	Name         string         `json:"name"`
+	Type         OperationType  `json:"type"`
+	Done         bool           `json:"done"`
+	Failed       bool           `json:"failed"`
+	ErrorMessage string         `json:"errorMessage,omitempty"`
+	Metadata     map[string]any `json:"metadata,omitempty"`
+	Parent       string         `json:"parent,omitempty"`
+	DocumentName string         `json:"documentName,omitempty"`
+}
+
+func newOperationStatus(s *gemini.OperationStatus) *OperationStatus {
+	if s == nil {
+		return nil
+	}
+	return &OperationStatus{
+		Name:         s.Name,
+		Type:         OperationType(s.Type),
+		Done:         s.Done,
+		Failed:       s.Failed,
+		ErrorMessage: s.ErrorMessage,
+		Metadata:     s.Metadata,
+		Parent:       s.Parent,
+		DocumentName: s.DocumentName,
+	}
+}
+
+// Citation is a source cited by an answer.
+type Citation struct {
+	// Index is the 1-based position of the chunk in the response's grounding chunks.
+	Index int
+	// Web is true for web search results and false for retrieved documents.
+	Web          bool
+	Title        string
+	URI          string
+	DocumentName string
+	// FirstPage and LastPage are the pages the chunk spans, or 0 if unknown.
+	FirstPage int
+	LastPage  int
+	Text      string
+}
+
+func newCitation(s grounding.Source) Citation {
+	return Citation{
+		Index:        s.Index,
+		Web:          s.Web,
+		Title:        s.Title,
+		URI:          s.URI,
+		DocumentName: s.DocumentName,
+		FirstPage:    s.FirstPage,
+		LastPage:     s.LastPage,
+		Text:         s.Text,
+	}
+}
+
+func (c *Citation) source() *grounding.Source {
+	return &grounding.Source{
+		Index:        c.Index,
+		Web:          c.Web,
+		Title:        c.Title,
+		URI:          c.URI,
+		DocumentName: c.DocumentName,
+		FirstPage:    c.FirstPage,
+		LastPage:     c.LastPage,
+		Text:         c.Text,
+	}
+}
+
+// Pages formats the page span, e.g. "Page 3" or "Pages 3-5". It returns "" if unknown.
+func (c *Citation) Pages() string {
+	return c.source().Pages()
+}
+
+// Location formats the URI and page span, e.g. "URI: gs://a/b.pdf, Page 3".
+func (c *Citation) Location() string {
+	return c.source().Location()
+}
+
+// RenderOptions controls Answer.Render.
+type RenderOptions struct {
+	// Verbose prints the full text of each source instead of a one-line snippet.
+	Verbose bool
+	// Debug prints the raw grounding metadata as JSON.
+	Debug bool
+}
+
+// Client is a File Search API client. It is safe for concurrent use.
+type Client struct {
+	api         gemini.API
+	model       string
+	concurrency int
+	progress    ProgressFunc
+}
+
+// New creates a client. Without WithAPIKey, the Gemini backend reads the key
+// from GEMINI_API_KEY or GOOGLE_API_KEY.
+func New(ctx context.Context, opts ...Option) (*Client, error) {
+	cfg := &config{
+		client: gemini.ClientConfig{Backend: gemini.BackendGemini},
+		model:  DefaultModel,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if cfg.client.Backend == gemini.BackendGemini && cfg.client.APIKey == "" {
+		cfg.client.APIKey = os.Getenv("GEMINI_API_KEY")
+		if cfg.client.APIKey == "" {
+			cfg.client.APIKey = os.Getenv("GOOGLE_API_KEY")
+		}
+		if cfg.client.APIKey == "" {
+			return nil, fmt.Errorf("%w: no API key; use WithAPIKey or set GEMINI_API_KEY", ErrInvalidArgument)
+		}
+	}
+
+	api, err := gemini.NewClientWithConfig(ctx, &cfg.client)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return &Client{
+		api:         api,
+		model:       cfg.model,
+		concurrency: cfg.concurrency,
+		progress:    cfg.progress,
+	}, nil
+}
+
+// Close releases the client's resources.
+func (c *Client) Close() {
+	c.api.Close()
+}
+
+// Backend returns the backend the client talks to.
+func (c *Client) Backend() Backend {
+	return Backend(c.api.Backend())
+}
+
+// ResolveStore returns the resource name of a store given its display name or resource name.
+func (c *Client) ResolveStore(ctx context.Context, store string) (string, error) {
+	name, err := c.api.ResolveStoreName(ctx, store)
+	return name, wrapError(err)
+}
+
+// ResolveFile returns the resource name of a file given its display name or resource name.
+func (c *Client) ResolveFile(ctx context.Context, file string) (string, error) {
+	name, err := c.api.ResolveFileName(ctx, file)
+	return name, wrapError(err)
+}
+
+// ResolveDocument returns the resource name of a document in store given its
+// display name or resource name.
+func (c *Client) ResolveDocument(ctx context.Context, store, document string) (string, error) {
+	name, err := c.api.ResolveDocumentName(ctx, store, document)
+	return name, wrapError(err)
+}
+
+// ListStores returns all File Search Stores.
+func (c *Client) ListStores(ctx context.Context) ([]*genai.FileSearchStore, error) {
+	stores, err := c.api.ListStores(ctx)
+	return stores, wrapError(err)
+}
+
+// GetStore returns a store by display name or resource name.
+func (c *Client) GetStore(ctx context.Context, store string) (*genai.FileSearchStore, error) {
+	name, err := c.ResolveStore(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	st, err := c.api.GetStore(ctx, name)
+	return st, wrapError(err)
+}
+
+// CreateStore creates a store with the given display name.
+func (c *Client) CreateStore(ctx context.Context, displayName string) (*genai.FileSearchStore, error) {
+	st, err := c.api.CreateStore(ctx, displayName)
+	return st, wrapError(err)
+}
+
+// DeleteStore deletes a store. Unless force is set, deleting a store that still
+// contains documents fails with ErrFailedPrecondition.
+func (c *Client) DeleteStore(ctx context.Context, store string, force bool) error {
+	name, err := c.ResolveStore(ctx, store)
+	if err != nil {
+		return err
+	}
+	return wrapError(c.api.DeleteStore(ctx, name, force))
+}
+
+// ListFiles returns all files in the Files API.
+func (c *Client) ListFiles(ctx context.Context) ([]*genai.File, error) {
+	files, err := c.api.ListFiles(ctx)
+	return files, wrapError(err)
+}
+
+// GetFile returns a file by display name or resource name.
+func (c *Client) GetFile(ctx context.Context, file string) (*genai.File, error) {
+	name, err := c.ResolveFile(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	f, err := c.api.GetFile(ctx, name)
+	return f, wrapError(err)
+}
+
+// DeleteFile deletes a file from the Files API.
+func (c *Client) DeleteFile(ctx context.Context, file string) error {
+	name, err := c.ResolveFile(ctx, file)
+	if err != nil {
+		return err
+	}
+	return wrapError(c.api.DeleteFile(ctx, name))
+}
+
+// ListDocuments returns the documents in a store.
+func (c *Client) ListDocuments(ctx context.Context, store string) ([]*genai.Document, error) {
+	name, err := c.ResolveStore(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := c.api.ListDocuments(ctx, name)
+	return docs, wrapError(err)
+}
+
+// GetDocument returns a document in store by display name or resource name.
+func (c *Client) GetDocument(ctx context.Context, store, document string) (*genai.Document, error) {
+	name, err := c.ResolveDocument(ctx, store, document)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := c.api.GetDocument(ctx, name)
+	return doc, wrapError(err)
+}
+
+// DeleteDocument deletes a document from a store. Unless force is set, deleting
+// a document that has indexed chunks fails with ErrFailedPrecondition.
+func (c *Client) DeleteDocument(ctx context.Context, store, document string, force bool) error {
+	name, err := c.ResolveDocument(ctx, store, document)
+	if err != nil {
+		return err
+	}
+	return wrapError(c.api.DeleteDocument(ctx, name, force))
+}
+
+// GetOperation returns the status of an upload or import operation.
+// An empty operationType tries both.
+func (c *Client) GetOperation(ctx context.Context, name string, operationType OperationType) (*OperationStatus, error) {
+	status, err := c.api.GetOperation(ctx, name, gemini.OperationType(operationType))
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return newOperationStatus(status), nil
+}
+
+// ListModels returns the models available to the client.
+func (c *Client) ListModels(ctx context.Context) ([]*genai.Model, error) {
+	models, err := c.api.ListModels(ctx)
+	return models, wrapError(err)
+}
+
+// Upload uploads a file. With ToStore the file is indexed into the store and
+// Upload returns once indexing finishes, with a nil *genai.File; a document that
+// fails to index is reported as an *OperationError. Without ToStore the file is
+// uploaded to the Files API and returned.
+func (c *Client) Upload(ctx context.Context, path string, opts ...UploadOption) (*genai.File, error) {
+	uo := newUploadOptions(opts)
+	storeName := ""
+	if uo.store != "" {
+		var err error
+		if storeName, err = c.ResolveStore(ctx, uo.store); err != nil {
+			return nil, err
+		}
+	}
+	return c.upload(ctx, path, storeName, uo, c.newTracker(1))
+}
+
+// BatchResult is the outcome of UploadBatch.
+type BatchResult struct {
+	Total     int
+	Succeeded []string
+	Failed    map[string]error
+	// Files holds the uploaded Files API files by path. It is empty for store uploads.
+	Files map[string]*genai.File
+}
+
+// UploadBatch uploads files concurrently (see WithConcurrency). The options
+// apply to every file; WithDisplayName is ignored and each document is named
+// after its file. If any file fails, the returned error is a *BatchError and
+// the result lists every file's outcome.
+func (c *Client) UploadBatch(ctx context.Context, paths []string, opts ...UploadOption) (*BatchResult, error) {
+	uo := newUploadOptions(opts)
+	uo.displayName = ""
+	storeName := ""
+	if uo.store != "" {
+		var err error
+		if storeName, err = c.ResolveStore(ctx, uo.store); err != nil {
+			return nil, err
+		}
+	}
+
+	var mu sync.Mutex
+	files := make(map[string]*genai.File)
+	t := c.newTracker(len(paths))
+	processor := func(ctx context.Context, path string) error {
+		f, err := c.upload(ctx, path, storeName, uo, t)
+		if f != nil {
+			mu.Lock()
+			files[path] = f
+			mu.Unlock()
+		}
+		return err
+	}
+
+	res := batch.Process(ctx, paths, processor, &batch.Options{Concurrency: c.concurrency})
+	result := &BatchResult{
+		Total:     res.Total,
+		Succeeded: res.Succeeded,
+		Failed:    res.Failed,
+		Files:     files,
+	}
+	if len(res.Failed) > 0 {
+		return result, &BatchError{Failed: res.Failed}
+	}
+	return result, nil
+}
+
+// upload uploads one file, reporting its progress to t.
+func (c *Client) upload(ctx context.Context, path, storeName string, uo *uploadOptions, t *tracker) (*genai.File, error) {
+	displayName := uo.displayName
+	if displayName == "" {
+		displayName = filepath.Base(path)
+	}
+
+	start := time.Now()
+	t.start(path)
+	f, err := c.api.UploadFile(ctx, path, &gemini.UploadFileOptions{
+		StoreName:      storeName,
+		DisplayName:    displayName,
+		MIMEType:       uo.mimeType,
+		MaxChunkTokens: uo.maxChunkTokens,
+		ChunkOverlap:   uo.chunkOverlap,
+		Metadata:       uo.metadata,
+		Progress:       t.forward(path),
+	})
+	err = wrapError(err)
+	t.finish(path, time.Since(start), err)
+	return f, err
+}
+
+// Import indexes a file that was already uploaded to the Files API into a store
+// and waits for indexing to finish.
+func (c *Client) Import(ctx context.Context, file, store string) error {
+	fileName, err := c.ResolveFile(ctx, file)
+	if err != nil {
+		return err
+	}
+	storeName, err := c.ResolveStore(ctx, store)
+	if err != nil {
+		return err
+	}
+
+	t := c.newTracker(1)
+	start := time.Now()
+	t.start(fileName)
+	err = wrapError(c.api.ImportFile(ctx, fileName, storeName, &gemini.ImportFileOptions{Progress: t.forward(fileName)}))
+	t.finish(fileName, time.Since(start), err)
+	return err
+}
+
+// Answer is the response to a query.
+type Answer struct {
+	// Text is the model's answer.
+	Text string
+	// Citations are the sources the answer was grounded in, in citation order.
+	Citations []Citation
+	// Response is the raw API response.
+	Response *genai.GenerateContentResponse
+}
+
+// Render writes the answer and its sources in the file-search CLI's text format.
+func (a *Answer) Render(w io.Writer, opts *RenderOptions) {
+	var ro *grounding.RenderOptions
+	if opts != nil {
+		ro = &grounding.RenderOptions{Verbose: opts.Verbose, Debug: opts.Debug}
+	}
+	grounding.Render(w, a.Response, ro)
+}
+
+// Query asks a question, grounded in a store if InStore is given.
+func (c *Client) Query(ctx context.Context, question string, opts ...QueryOption) (*Answer, error) {
+	qo := &queryOptions{model: c.model}
+	for _, opt := range opts {
+		opt(qo)
+	}
+
+	storeName := ""
+	if qo.store != "" {
+		var err error
+		if storeName, err = c.ResolveStore(ctx, qo.store); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.api.Query(ctx, question, storeName, qo.model, qo.filter)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	answer := &Answer{Text: grounding.Text(resp), Response: resp}
+	for _, cand := range resp.Candidates {
+		for _, src := range grounding.Sources(cand.GroundingMetadata) {
+			answer.Citations = append(answer.Citations, newCitation(src))
+		}
+	}
+	return answer, nil
+}
@@ -0,0 +1,371 @@
+package filesearch_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/gemini/fake"
+	"github.com/mikesmitty/file-search/pkg/filesearch"
+)
+
+func newTestClient(t *testing.T, fakeOpts *fake.Options, opts ...filesearch.Option) *filesearch.Client {
+	t.Helper()
+	srv := fake.NewServer(fakeOpts)
+	t.Cleanup(srv.Close)
+
+	opts = append([]filesearch.Option{
+		filesearch.WithAPIKey("test-key"),
+		filesearch.WithBaseURL(srv.URL),
+		filesearch.WithPollInterval(10 * time.Millisecond),
+	}, opts...)
+	client, err := filesearch.New(context.Background(), opts...)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+func TestNewRequiresAPIKey(t *testing.T) {
+	t.Setenv("GEMINI_API_KEY", "")
+	t.Setenv("GOOGLE_API_KEY", "")
+	_, err := filesearch.New(context.Background())
+	if !errors.Is(err, filesearch.ErrInvalidArgument) {
+		t.Errorf("expected ErrInvalidArgument, got %v", err)
+	}
+}
+
+func TestNewReadsAPIKeyFromEnv(t *testing.T) {
+	t.Setenv("GEMINI_API_KEY", "")
+	t.Setenv("GOOGLE_API_KEY", "env-key")
+	srv := fake.NewServer(&fake.Options{APIKey: "env-key"})
+	defer srv.Close()
+
+	client, err := filesearch.New(context.Background(), filesearch.WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, err := client.ListStores(context.Background()); err != nil {
+		t.Errorf("ListStores failed: %v", err)
+	}
+}
+
+func TestAPIErrorIs(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    *filesearch.APIError
+		target error
+		want   bool
+	}{
+		{"not found", &filesearch.APIError{Code: http.StatusNotFound, Status: "NOT_FOUND"}, filesearch.ErrNotFound, true},
+		{"precondition", &filesearch.APIError{Code: http.StatusBadRequest, Status: "FAILED_PRECONDITION"}, filesearch.ErrFailedPrecondition, true},
+		{"precondition is not invalid argument", &filesearch.APIError{Code: http.StatusBadRequest, Status: "FAILED_PRECONDITION"}, filesearch.ErrInvalidArgument, false},
+		{"invalid argument", &filesearch.APIError{Code: http.StatusBadRequest, Status: "INVALID_ARGUMENT"}, filesearch.ErrInvalidArgument, true},
+		{"unauthenticated", &filesearch.APIError{Code: http.StatusUnauthorized}, filesearch.ErrPermissionDenied, true},
+		{"forbidden", &filesearch.APIError{Code: http.StatusForbidden}, filesearch.ErrPermissionDenied, true},
+		{"unrelated", &filesearch.APIError{Code: http.StatusInternalServerError}, filesearch.ErrNotFound, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, tt.target); got != tt.want {
+				t.Errorf("errors.Is(%v, %v) = %v, want %v", tt.err, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTypedErrors(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, nil)
+	if _, err := client.CreateStore(ctx, "Docs"); err != nil {
+		t.Fatalf("CreateStore failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		call   func() error
+		target error
+	}{
+		{"unknown store display name", func() error { _, err := client.GetStore(ctx, "Missing"); return err }, filesearch.ErrNotFound},
+		{"unknown store resource", func() error { _, err := client.GetStore(ctx, "fileSearchStores/missing"); return err }, filesearch.ErrNotFound},
+		{"unknown file", func() error { return client.DeleteFile(ctx, "missing.txt") }, filesearch.ErrNotFound},
+		{"unknown document", func() error { _, err := client.GetDocument(ctx, "Docs", "missing.txt"); return err }, filesearch.ErrNotFound},
+		{"invalid filter", func() error {
+			_, err := client.Query(ctx, "anything", filesearch.InStore("Docs"), filesearch.WithFilter("year >"))
+			return err
+		}, filesearch.ErrInvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, tt.target) {
+				t.Errorf("expected %v, got %v", tt.target, err)
+			}
+		})
+	}
+
+	t.Run("bad credentials", func(t *testing.T) {
+		bad := newTestClient(t, &fake.Options{APIKey: "other-key"})
+		_, err := bad.ListStores(ctx)
+		var apiErr *filesearch.APIError
+		if !errors.As(err, &apiErr) || !errors.Is(err, filesearch.ErrPermissionDenied) {
+			t.Errorf("expected a permission denied APIError, got %v", err)
+		}
+	})
+}
+
+func TestUploadAndQuery(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, nil)
+	if _, err := client.CreateStore(ctx, "Handbook"); err != nil {
+		t.Fatalf("CreateStore failed: %v", err)
+	}
+
+	_, err := client.Upload(ctx, "testdata/handbook.txt",
+		filesearch.ToStore("Handbook"),
+		filesearch.WithDisplayName("Employee Handbook"),
+		filesearch.WithMetadata(map[string]string{"team": "hr"}),
+	)
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	doc, err := client.GetDocument(ctx, "Handbook", "Employee Handbook")
+	if err != nil {
+		t.Fatalf("GetDocument failed: %v", err)
+	}
+	if len(doc.CustomMetadata) != 1 || doc.CustomMetadata[0].StringValue != "hr" {
+		t.Errorf("unexpected metadata: %+v", doc.CustomMetadata)
+	}
+
+	tests := []struct {
+		name          string
+		opts          []filesearch.QueryOption
+		wantCitations int
+		wantText      string
+	}{
+		{"grounded", []filesearch.QueryOption{filesearch.InStore("Handbook")}, 1, "Employee Handbook"},
+		{"matching filter", []filesearch.QueryOption{filesearch.InStore("Handbook"), filesearch.WithFilter(`team = "hr"`)}, 1, "Employee Handbook"},
+		{"excluding filter", []filesearch.QueryOption{filesearch.InStore("Handbook"), filesearch.WithFilter(`team = "it"`)}, 0, "could not find"},
+		{"no store", nil, 0, "fake response"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answer, err := client.Query(ctx, "vacation days", tt.opts...)
+			if err != nil {
+				t.Fatalf("Query failed: %v", err)
+			}
+			if len(answer.Citations) != tt.wantCitations {
+				t.Errorf("got %d citations, want %d: %+v", len(answer.Citations), tt.wantCitations, answer.Citations)
+			}
+			if !strings.Contains(answer.Text, tt.wantText) {
+				t.Errorf("answer %q does not contain %q", answer.Text, tt.wantText)
+			}
+		})
+	}
+
+	t.Run("render", func(t *testing.T) {
+		answer, err := client.Query(ctx, "vacation days", filesearch.InStore("Handbook"))
+		if err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		var b strings.Builder
+		answer.Render(&b, nil)
+		if !strings.Contains(b.String(), "1. [Doc] Employee Handbook") || !strings.Contains(b.String(), "Snippet:") {
+			t.Errorf("unexpected rendering:\n%s", b.String())
+		}
+	})
+}
+
+func TestUploadToFilesAndImport(t *testing.T) {
+	ctx := context.Background()
+	var events []filesearch.Progress
+	stages := make(map[filesearch.Stage]int)
+	client := newTestClient(t, nil, filesearch.WithProgress(func(p filesearch.Progress) {
+		stages[p.Stage]++
+		switch p.Stage {
+		case filesearch.StageUploading:
+			if p.TotalBytes == 0 || p.BytesSent > p.TotalBytes {
+				t.Errorf("unexpected byte counts: %+v", p)
+			}
+		case filesearch.StageIndexing:
+			if p.Operation == "" {
+				t.Errorf("expected an operation name: %+v", p)
+			}
+		default:
+			events = append(events, p)
+		}
+	}))
+	if _, err := client.CreateStore(ctx, "Imports"); err != nil {
+		t.Fatalf("CreateStore failed: %v", err)
+	}
+
+	file, err := client.Upload(ctx, "testdata/security.txt")
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if file == nil || file.DisplayName != "security.txt" {
+		t.Fatalf("unexpected file: %+v", file)
+	}
+	if err := client.Import(ctx, "security.txt", "Imports"); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	docs, err := client.ListDocuments(ctx, "Imports")
+	if err != nil || len(docs) != 1 {
+		t.Fatalf("expected one document, got %+v, %v", docs, err)
+	}
+
+	want := []filesearch.Stage{filesearch.StageStarted, filesearch.StageDone, filesearch.StageStarted, filesearch.StageDone}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i, stage := range want {
+		if events[i].Stage != stage {
+			t.Errorf("event %d: stage %s, want %s", i, events[i].Stage, stage)
+		}
+	}
+	if stages[filesearch.StageUploading] == 0 || stages[filesearch.StageIndexing] == 0 {
+		t.Errorf("expected uploading and indexing events, got %v", stages)
+	}
+	if events[3].Path != file.Name || events[3].Current != 1 || events[3].Total != 1 {
+		t.Errorf("unexpected import event: %+v", events[3])
+	}
+}
+
+func TestUploadBatchErrors(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, &fake.Options{
+		FailIndexing: func(displayName string) bool { return displayName == "security.txt" },
+	})
+	if _, err := client.CreateStore(ctx, "Batch"); err != nil {
+		t.Fatalf("CreateStore failed: %v", err)
+	}
+
+	paths := []string{"testdata/handbook.txt", "testdata/security.txt", "testdata/missing.txt"}
+	result, err := client.UploadBatch(ctx, paths, filesearch.ToStore("Batch"))
+
+	var batchErr *filesearch.BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("expected a BatchError, got %v", err)
+	}
+	if result.Total != 3 || len(result.Succeeded) != 1 || len(batchErr.Failed) != 2 {
+		t.Fatalf("unexpected result: %+v, %v", result, err)
+	}
+
+	var opErr *filesearch.OperationError
+	if !errors.As(batchErr.Failed["testdata/security.txt"], &opErr) {
+		t.Errorf("expected an OperationError for the failed document, got %v", batchErr.Failed["testdata/security.txt"])
+	}
+	if !errors.As(err, &opErr) {
+		t.Error("expected errors.As to find the OperationError through the BatchError")
+	}
+	if _, ok := batchErr.Failed["testdata/missing.txt"]; !ok {
+		t.Error("expected the missing file to fail")
+	}
+}
+
+func TestOperationsAndModels(t *testing.T) {
+	ctx := context.Background()
+	srv := fake.NewServer(nil)
+	defer srv.Close()
+	client, err := filesearch.New(ctx,
+		filesearch.WithAPIKey("test-key"),
+		filesearch.WithBaseURL(srv.URL),
+		filesearch.WithPollInterval(10*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	if _, err := client.CreateStore(ctx, "Ops"); err != nil {
+		t.Fatalf("CreateStore failed: %v", err)
+	}
+	if _, err := client.Upload(ctx, "testdata/handbook.txt", filesearch.ToStore("Ops")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	ops := srv.Operations()
+	status, err := client.GetOperation(ctx, ops[len(ops)-1], filesearch.OperationTypeUpload)
+	if err != nil {
+		t.Fatalf("GetOperation failed: %v", err)
+	}
+	if !status.Done || status.Failed || status.DocumentName == "" {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	models, err := client.ListModels(ctx)
+	if err != nil || len(models) == 0 {
+		t.Errorf("expected models, got %v, %v", models, err)
+	}
+}
+
+// TestExportedFields pins the fields of the package's own types, so a change
+// to them is a deliberate API change rather than a side effect of the
+// internal packages they are converted from.
+func TestExportedFields(t *testing.T) {
+	tests := []struct {
+		v    any
+		want []string
+	}{
+		{filesearch.Citation{}, []string{
+			"Index int", "Web bool", "Title string", "URI string", "DocumentName string",
+			"FirstPage int", "LastPage int", "Text string",
+		}},
+		{filesearch.RenderOptions{}, []string{"Verbose bool", "Debug bool"}},
+		{filesearch.OperationStatus{}, []string{
+			"Name string", "Type filesearch.OperationType", "Done bool", "Failed bool",
+			"ErrorMessage string", "Metadata map[string]interface {}", "Parent string", "DocumentName string",
+		}},
+		{filesearch.OperationError{}, []string{"Name string", "Message string"}},
+		{filesearch.OperationCanceledError{}, []string{"Name string", "Err error"}},
+	}
+	for _, tt := range tests {
+		typ := reflect.TypeOf(tt.v)
+		t.Run(typ.Name(), func(t *testing.T) {
+			if typ.PkgPath() != "github.com/mikesmitty/file-search/pkg/filesearch" {
+				t.Errorf("%s is defined in %s", typ.Name(), typ.PkgPath())
+			}
+			var got []string
+			for i := 0; i < typ.NumField(); i++ {
+				f := typ.Field(i)
+				got = append(got, f.Name+" "+f.Type.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	for _, v := range []any{filesearch.BackendGemini, filesearch.OperationTypeUpload} {
+		if typ := reflect.TypeOf(v); typ.PkgPath() != "github.com/mikesmitty/file-search/pkg/filesearch" {
+			t.Errorf("%s is defined in %s", typ.Name(), typ.PkgPath())
+		}
+	}
+}
+
+func TestOperationCanceledError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	client := newTestClient(t, &fake.Options{IndexingDelay: 200 * time.Millisecond},
+		filesearch.WithProgress(func(p filesearch.Progress) {
+			if p.Stage == filesearch.StageIndexing {
+				cancel()
+			}
+		}))
+	if _, err := client.CreateStore(ctx, "Slow"); err != nil {
+		t.Fatalf("CreateStore failed: %v", err)
+	}
+
+	_, err := client.Upload(ctx, "testdata/handbook.txt", filesearch.ToStore("Slow"))
+	var canceledErr *filesearch.OperationCanceledError
+	if !errors.As(err, &canceledErr) || canceledErr.Name == "" {
+		t.Fatalf("expected an OperationCanceledError naming the operation, got %v", err)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the error to wrap context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package filesearch
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/constants"
+	"github.com/mikesmitty/file-search/internal/gemini"
+)
+
+// DefaultModel is the model used for queries unless WithModel or UsingModel is given.
+const DefaultModel = constants.DefaultModel
+
+// config collects the settings applied by Options.
+type config struct {
+	client      gemini.ClientConfig
+	model       string
+	concurrency int
+	progress    ProgressFunc
+}
+
+// Option configures a Client.
+type Option func(*config)
+
+// WithAPIKey sets the Gemini API key.
+func WithAPIKey(key string) Option {
+	return func(c *config) {
+		c.client.APIKey = key
+	}
+}
+
+// WithVertex selects the Vertex AI backend in the given project and location,
+// authenticated with Application Default Credentials.
+func WithVertex(project, location string) Option {
+	return func(c *config) {
+		c.client.Backend = gemini.BackendVertex
+		c.client.Project = project
+		c.client.Location = location
+	}
+}
+
+// WithBaseURL overrides the API endpoint, e.g. a regional endpoint or a mock server.
+func WithBaseURL(url string) Option {
+	return func(c *config) {
+		c.client.BaseURL = url
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for API requests.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *config) {
+		c.client.HTTPClient = client
+	}
+}
+
+// WithPollInterval sets how often upload and import operations are polled (default: 2s).
+func WithPollInterval(d time.Duration) Option {
+	return func(c *config) {
+		c.client.PollInterval = d
+	}
+}
+
+// WithModel sets the default model for queries.
+func WithModel(model string) Option {
+	return func(c *config) {
+		c.model = model
+	}
+}
+
+// WithConcurrency sets the number of files UploadBatch uploads in parallel (default: 5).
+func WithConcurrency(n int) Option {
+	return func(c *config) {
+		c.concurrency = n
+	}
+}
+
+// WithProgress sets a callback for upload and import progress.
+func WithProgress(fn ProgressFunc) Option {
+	return func(c *config) {
+		c.progress = fn
+	}
+}
+
+// uploadOptions collects the settings applied by UploadOptions.
+type uploadOptions struct {
+	store          string
+	displayName    string
+	mimeType       string
+	maxChunkTokens int
+	chunkOverlap   int
+	metadata       map[string]string
+}
+
+// UploadOption configures an upload.
+type UploadOption func(*uploadOptions)
+
+func newUploadOptions(opts []UploadOption) *uploadOptions {
+	uo := &uploadOptions{}
+	for _, opt := range opts {
+		opt(uo)
+	}
+	return uo
+}
+
+// ToStore indexes the upload into a store, given by display name or resource name.
+func ToStore(store string) UploadOption {
+	return func(o *uploadOptions) {
+		o.store = store
+	}
+}
+
+// WithDisplayName sets the display name (default: the file's base name).
+func WithDisplayName(name string) UploadOption {
+	return func(o *uploadOptions) {
+		o.displayName = name
+	}
+}
+
+// WithMIMEType sets the MIME type instead of detecting it from the file extension.
+func WithMIMEType(mimeType string) UploadOption {
+	return func(o *uploadOptions) {
+		o.mimeType = mimeType
+	}
+}
+
+// WithChunking sets the maximum tokens per chunk and the overlap between chunks
+// for store uploads. Zero keeps the API default.
+func WithChunking(maxTokens, overlap int) UploadOption {
+	return func(o *uploadOptions) {
+		o.maxChunkTokens = maxTokens
+		o.chunkOverlap = overlap
+	}
+}
+
+// WithMetadata adds custom metadata to store uploads, for use in query filters.
+func WithMetadata(metadata map[string]string) UploadOption {
+	return func(o *uploadOptions) {
+		if o.metadata == nil {
+			o.metadata = make(map[string]string, len(metadata))
+		}
+		for k, v := range metadata {
+			o.metadata[k] = v
+		}
+	}
+}
+
+// queryOptions collects the settings applied by QueryOptions.
+type queryOptions struct {
+	store  string
+	model  string
+	filter string
+}
+
+// QueryOption configures a query.
+type QueryOption func(*queryOptions)
+
+// InStore grounds the query in a store, given by display name or resource name.
+func InStore(store string) QueryOption {
+	return func(o *queryOptions) {
+		o.store = store
+	}
+}
+
+// UsingModel overrides the client's default model for one query.
+func UsingModel(model string) QueryOption {
+	return func(o *queryOptions) {
+		o.model = model
+	}
+}
+
+// WithFilter restricts retrieval to documents matching a metadata filter,
+// e.g. `category = "research" AND year > 2020`.
+func WithFilter(filter string) QueryOption {
+	return func(o *queryOptions) {
+		o.filter = filter
+	}
+}
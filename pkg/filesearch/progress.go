@@ -0,0 +1,113 @@
+package filesearch
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mikesmitty/file-search/internal/gemini"
+)
+
+// Stage is the point in an upload or import a Progress event reports.
+type Stage string
+
+const (
+	// StageStarted is reported when a file starts uploading or importing.
+	StageStarted Stage = "started"
+	// StageUploading is reported as the file's bytes are sent.
+	StageUploading Stage = "uploading"
+	// StageIndexing is reported while the store indexes the file.
+	StageIndexing Stage = "indexing"
+	// StageDone is reported when a file has been uploaded and, for stores, indexed.
+	StageDone Stage = "done"
+	// StageFailed is reported when a file fails; Progress.Err holds the cause.
+	StageFailed Stage = "failed"
+)
+
+// Progress reports the state of one file in an upload or import.
+type Progress struct {
+	// Path is the local path (uploads) or file resource name (imports).
+	Path  string
+	Stage Stage
+	// Current is the number of files finished so far, including this one once
+	// it is done or failed. Total is the number of files in the call.
+	Current int
+	Total   int
+	// BytesSent and TotalBytes track the upload. TotalBytes is 0 for imports.
+	BytesSent  int64
+	TotalBytes int64
+	// Operation is the indexing operation's resource name, once it is known.
+	Operation string
+	// Elapsed is the time spent on this file. It is zero for StageStarted.
+	Elapsed time.Duration
+	Err     error
+}
+
+// ProgressFunc receives progress events. Events from one Upload, UploadBatch or
+// Import call are delivered one at a time; it should return quickly.
+type ProgressFunc func(Progress)
+
+// tracker counts finished files within one call and forwards events to fn.
+type tracker struct {
+	fn    ProgressFunc
+	mu    sync.Mutex
+	total int
+	done  int
+}
+
+func (c *Client) newTracker(total int) *tracker {
+	return &tracker{fn: c.progress, total: total}
+}
+
+func (t *tracker) start(path string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.fn != nil {
+		t.fn(Progress{Path: path, Stage: StageStarted, Current: t.done, Total: t.total})
+	}
+}
+
+func (t *tracker) finish(path string, elapsed time.Duration, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.done++
+	if t.fn == nil {
+		return
+	}
+	p := Progress{Path: path, Stage: StageDone, Current: t.done, Total: t.total, Elapsed: elapsed, Err: err}
+	if err != nil {
+		p.Stage = StageFailed
+	}
+	t.fn(p)
+}
+
+// forward returns a reporter that relays the client's upload and indexing
+// events for path as StageUploading and StageIndexing, or nil if nobody is listening.
+func (t *tracker) forward(path string) gemini.ProgressReporter {
+	if t.fn == nil {
+		return nil
+	}
+	return gemini.ProgressFunc(func(e gemini.ProgressEvent) {
+		var stage Stage
+		switch e.Type {
+		case gemini.ProgressBytesSent:
+			stage = StageUploading
+		case gemini.ProgressOperationCreated, gemini.ProgressPolling:
+			stage = StageIndexing
+		default:
+			// Started, done and failed are reported by start and finish
+			return
+		}
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.fn(Progress{
+			Path:       path,
+			Stage:      stage,
+			Current:    t.done,
+			Total:      t.total,
+			BytesSent:  e.BytesSent,
+			TotalBytes: e.TotalBytes,
+			Operation:  e.Operation,
+			Elapsed:    e.Elapsed,
+		})
+	})
+}